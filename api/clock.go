@@ -0,0 +1,12 @@
+package api
+
+import "time"
+
+// Clock is the source of time for the bot, it allows the update loop to be driven by a simulated clock
+type Clock interface {
+	// Now returns the current time according to this clock
+	Now() time.Time
+
+	// Sleep blocks (or advances the clock) for the given duration
+	Sleep(d time.Duration)
+}
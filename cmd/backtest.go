@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/nikhilsaraf/go-tools/multithreading"
+	"github.com/spf13/cobra"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/config"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/database"
+	"github.com/stellar/kelp/support/logger"
+	"github.com/stellar/kelp/support/monitoring"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stellar/kelp/trader"
+)
+
+const backtestExamples = `  kelp backtest --botConf ./path/trader.cfg --strategy buysell --stratConf ./path/buysell.cfg --data ./path/trades.csv --baseBalance 1000 --quoteBalance 100
+  kelp backtest --botConf ./path/trader.cfg --strategy balanced --stratConf ./path/balanced.cfg --start 2021-01-01 --end 2021-02-01 --fee 0.001`
+
+// backtestDateFormat is the format used for the --start and --end flags
+const backtestDateFormat = "2006-01-02"
+
+var backtestCmd = &cobra.Command{
+	Use:     "backtest",
+	Short:   "Replays historical market data against a strategy using a simulated exchange",
+	Example: backtestExamples,
+}
+
+type backtestInputs struct {
+	botConfigPath   *string
+	strategy        *string
+	stratConfigPath *string
+	dataPath        *string
+	start           *string
+	end             *string
+	baseBalance     *float64
+	quoteBalance    *float64
+	feePct          *float64
+}
+
+func init() {
+	options := backtestInputs{}
+	// short flags
+	options.botConfigPath = backtestCmd.Flags().StringP("botConf", "c", "", "(required) trading bot's basic config file path")
+	options.strategy = backtestCmd.Flags().StringP("strategy", "s", "", "(required) type of strategy to run")
+	options.stratConfigPath = backtestCmd.Flags().StringP("stratConf", "f", "", "strategy config file path")
+	options.dataPath = backtestCmd.Flags().StringP("data", "d", "", "CSV file with historical data (timestamp,price,volume[,bid,ask]), uses the trades table from POSTGRES_DB in the botConf when left empty")
+	// long-only flags
+	options.start = backtestCmd.Flags().String("start", "", "start date (inclusive) in the format YYYY-MM-DD when reading historical data from the trades table")
+	options.end = backtestCmd.Flags().String("end", "", "end date (exclusive) in the format YYYY-MM-DD when reading historical data from the trades table")
+	options.baseBalance = backtestCmd.Flags().Float64("baseBalance", 0, "starting balance of the base asset")
+	options.quoteBalance = backtestCmd.Flags().Float64("quoteBalance", 0, "starting balance of the quote asset")
+	options.feePct = backtestCmd.Flags().Float64("fee", 0, "fee charged on the quote value of every fill as a percentage (0.001 = 0.1%)")
+
+	for _, flag := range []string{"botConf", "strategy"} {
+		e := backtestCmd.MarkFlagRequired(flag)
+		if e != nil {
+			panic(e)
+		}
+	}
+	backtestCmd.Flags().SortFlags = false
+
+	backtestCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+		runBacktestCmd(options)
+	}
+}
+
+func runBacktestCmd(options backtestInputs) {
+	l := logger.MakeBasicLogger()
+	botStartTime := time.Now()
+
+	var botConfig trader.BotConfig
+	e := config.Read(*options.botConfigPath, &botConfig)
+	utils.CheckConfigError(botConfig, e, *options.botConfigPath)
+	e = botConfig.Init()
+	if e != nil {
+		logger.Fatal(l, e)
+	}
+	botConfig = convertDeprecatedBotConfigValues(l, botConfig)
+	utils.LogConfig(botConfig)
+	l.Infof("Backtesting %s:%s for %s:%s\n", botConfig.AssetCodeA, botConfig.IssuerA, botConfig.AssetCodeB, botConfig.IssuerB)
+
+	// --- start initialization of objects ----
+	threadTracker := multithreading.MakeThreadTracker()
+	assetBase := botConfig.AssetBase()
+	assetQuote := botConfig.AssetQuote()
+	tradingPair := &model.TradingPair{
+		Base:  model.Asset(utils.Asset2CodeString(assetBase)),
+		Quote: model.Asset(utils.Asset2CodeString(assetQuote)),
+	}
+	sdexAssetMap := map[model.Asset]hProtocol.Asset{
+		tradingPair.Base:  assetBase,
+		tradingPair.Quote: assetQuote,
+	}
+	assetDisplayFn := model.MakePassthroughAssetDisplayFn()
+	if botConfig.IsTradingSdex() {
+		assetDisplayFn = model.MakeSdexMappedAssetDisplayFn(sdexAssetMap)
+	}
+	baseString, e := assetDisplayFn(tradingPair.Base)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("could not convert base trading pair to string: %s", e))
+	}
+	quoteString, e := assetDisplayFn(tradingPair.Quote)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("could not convert quote trading pair to string: %s", e))
+	}
+	marketID := plugins.MakeMarketID(botConfig.TradingExchangeName(), baseString, quoteString)
+
+	var db *sql.DB
+	if botConfig.PostgresDbConfig != nil {
+		db, e = database.ConnectInitializedDatabase(botConfig.PostgresDbConfig, upgradeScripts, version)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("problem encountered while initializing the db: %s", e))
+		}
+		log.Printf("made db instance with config: %s\n", botConfig.PostgresDbConfig.MakeConnectString())
+	}
+
+	if botConfig.TickIntervalMillis <= 0 {
+		logger.Fatal(l, fmt.Errorf("TICK_INTERVAL_MILLIS needs to be greater than 0 to step through the backtest, was %d", botConfig.TickIntervalMillis))
+	}
+	ticks := loadBacktestTicks(l, options, db, marketID)
+	// the virtual clock drives all components of the bot, including the backtest exchange which replays the ticks up to the time of the clock
+	clock := plugins.MakeVirtualClock(time.Time{})
+	backtestExchange, e := plugins.MakeBacktestExchange(tradingPair, ticks, *options.baseBalance, *options.quoteBalance, *options.feePct, clock)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("unable to make backtest exchange: %s", e))
+	}
+	clock.Set(backtestExchange.FirstTick().Time)
+	l.Infof("loaded %d ticks of historical data from %s to %s\n",
+		backtestExchange.NumTicks(),
+		backtestExchange.FirstTick().Time.Format(time.RFC3339),
+		backtestExchange.LastTick().Time.Format(time.RFC3339),
+	)
+
+	exchangeShim := plugins.MakeBatchedExchange(backtestExchange, false, assetBase, assetQuote, botConfig.TradingAccount())
+	// update precision overrides
+	exchangeShim.OverrideOrderConstraints(tradingPair, model.MakeOrderConstraintsOverride(
+		botConfig.CentralizedPricePrecisionOverride,
+		botConfig.CentralizedVolumePrecisionOverride,
+		nil,
+		nil,
+	))
+	if botConfig.CentralizedMinBaseVolumeOverride != nil {
+		// use updated precision overrides to convert the minCentralizedBaseVolume to a model.Number
+		exchangeShim.OverrideOrderConstraints(tradingPair, model.MakeOrderConstraintsOverride(
+			nil,
+			nil,
+			model.NumberFromFloat(*botConfig.CentralizedMinBaseVolumeOverride, exchangeShim.GetOrderConstraints(tradingPair).VolumePrecision),
+			nil,
+		))
+	}
+
+	// the backtest exchange keeps balances and offers locally so we never use the SDEX accounting of the native asset
+	ieif := plugins.MakeIEIF(false)
+	// the backtest runs offline, so there is no horizon client and no seeds. The SDEX instance only builds the offer operations that the
+	// batched exchange translates into orders on the backtest exchange and never submits anything to the network
+	sdex := plugins.MakeSDEX(
+		nil,
+		ieif,
+		exchangeShim,
+		"",
+		"",
+		"",
+		botConfig.TradingAccount(),
+		"",
+		threadTracker,
+		0,
+		0,
+		false,
+		tradingPair,
+		sdexAssetMap,
+		plugins.SdexFixedFeeFn(0),
+	)
+	filterFactory := &plugins.FilterFactory{
+		ExchangeName:   botConfig.TradingExchangeName(),
+		TradingPair:    tradingPair,
+		AssetDisplayFn: assetDisplayFn,
+		BaseAsset:      assetBase,
+		QuoteAsset:     assetQuote,
+		DB:             db,
+		IEIF:           ieif,
+		Clock:          clock,
+	}
+	// exchange feeds on the market being backtested are priced off the replayed data
+	priceFeedFactory := &plugins.PriceFeedFactory{
+		ExchangeName: botConfig.TradingExchangeName(),
+		TradingPair:  tradingPair,
+		Exchange:     backtestExchange,
+	}
+
+	strategy, e := plugins.MakeStrategy(
+		sdex,
+		exchangeShim,
+		exchangeShim,
+		ieif,
+		tradingPair,
+		&assetBase,
+		&assetQuote,
+		marketID,
+		*options.strategy,
+		*options.stratConfigPath,
+		false,
+		false,
+		filterFactory,
+		priceFeedFactory,
+		db,
+		clock,
+	)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("unable to make strategy: %s", e))
+	}
+
+	fillTracker := plugins.MakeFillTracker(tradingPair, threadTracker, exchangeShim, 0, botConfig.FillTrackerDeleteCyclesThreshold, "0", clock)
+	fillTracker.RegisterHandler(plugins.MakeFillLogger())
+	strategyFillHandlers, e := strategy.GetFillHandlers()
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("problem encountered while instantiating the fill tracker: %s", e))
+	}
+	for _, h := range strategyFillHandlers {
+		fillTracker.RegisterHandler(h)
+	}
+
+	submitMode, e := api.ParseSubmitMode(botConfig.SubmitMode)
+	if e != nil {
+		logger.Fatal(l, e)
+	}
+	submitFilters := makeBacktestSubmitFilters(l, botConfig, options, exchangeShim, sdex, tradingPair, filterFactory, submitMode)
+
+	metricsTracker, e := plugins.MakeMetricsTracker(
+		http.DefaultClient,
+		amplitudeAPIKey,
+		"",
+		"",
+		"",
+		botStartTime,
+		true, // never send metrics for backtests
+		plugins.MakeCommonProps(
+			version,
+			gitHash,
+			env,
+			runtime.GOOS,
+			runtime.GOARCH,
+			goarm,
+			runtime.Version(),
+			0,
+			false,
+			"",
+		),
+		nil,
+	)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("could not generate metrics tracker: %s", e))
+	}
+	alert, _ := monitoring.MakeAlert("", "")
+	// the time controller runs on the virtual clock, so sleeping between update cycles fast-forwards the backtest instead of blocking
+	timeController := plugins.MakeIntervalTimeController(time.Duration(botConfig.TickIntervalMillis)*time.Millisecond, 0, clock)
+
+	bot := trader.MakeTrader(
+		nil,
+		ieif,
+		assetBase,
+		assetQuote,
+		nil,
+		nil,
+		botConfig.TradingAccount(),
+		sdex,
+		exchangeShim,
+		strategy,
+		timeController,
+		clock,
+		trader.ParseSleepMode(botConfig.SleepMode),
+		false, // fills are tracked explicitly before every update cycle
+		0,
+		fillTracker,
+		botConfig.DeleteCyclesThreshold,
+		submitMode,
+		submitFilters,
+		threadTracker,
+		nil,
+		model.MakeSortedBotKey(assetBase, assetQuote),
+		alert,
+		metricsTracker,
+		botStartTime,
+	)
+	// --- end initialization of objects ---
+
+	lastTickTime := backtestExchange.LastTick().Time
+	numUpdates := 0
+	numFailedUpdates := 0
+	for !clock.Now().After(lastTickTime) {
+		currentUpdateTime := clock.Now()
+		_, e = fillTracker.FillTrackSingleIteration()
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("problem encountered while tracking fills at %s: %s", currentUpdateTime.Format(time.RFC3339), e))
+		}
+
+		log.Printf("backtest update cycle at %s\n", currentUpdateTime.Format(time.RFC3339))
+		updateResult := bot.UpdateOnce()
+		if !updateResult.Success {
+			numFailedUpdates++
+		}
+		numUpdates++
+		// sleeping on the virtual clock moves it to the next update cycle, the backtest exchange replays the ticks in between
+		clock.Sleep(timeController.SleepTime(currentUpdateTime))
+	}
+	// handle fills from the ticks after the last update cycle
+	_, e = fillTracker.FillTrackSingleIteration()
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("problem encountered while tracking fills at the end of the backtest: %s", e))
+	}
+
+	printBacktestResults(backtestExchange, tradingPair, *options.baseBalance, *options.quoteBalance, numUpdates, numFailedUpdates)
+}
+
+func loadBacktestTicks(l logger.Logger, options backtestInputs, db *sql.DB, marketID string) []plugins.BacktestTick {
+	if *options.dataPath != "" {
+		ticks, e := plugins.LoadBacktestTicksFromCSV(*options.dataPath)
+		if e != nil {
+			logger.Fatal(l, e)
+		}
+		return ticks
+	}
+
+	if db == nil {
+		utils.PrintErrorHintf("either pass a CSV file using the --data flag or set POSTGRES_DB in the trader.cfg file to read historical data from the trades table")
+		logger.Fatal(l, fmt.Errorf("no source of historical data for the backtest"))
+	}
+	if *options.start == "" || *options.end == "" {
+		logger.Fatal(l, fmt.Errorf("need to specify both --start and --end when reading historical data from the trades table"))
+	}
+	start, e := time.Parse(backtestDateFormat, *options.start)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("could not parse --start: %s", e))
+	}
+	end, e := time.Parse(backtestDateFormat, *options.end)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("could not parse --end: %s", e))
+	}
+
+	ticks, e := plugins.LoadBacktestTicksFromDB(db, marketID, start, end)
+	if e != nil {
+		logger.Fatal(l, e)
+	}
+	return ticks
+}
+
+func makeBacktestSubmitFilters(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	options backtestInputs,
+	exchangeShim api.ExchangeShim,
+	sdex *plugins.SDEX,
+	tradingPair *model.TradingPair,
+	filterFactory *plugins.FilterFactory,
+	submitMode api.SubmitMode,
+) []plugins.SubmitFilter {
+	submitFilters := []plugins.SubmitFilter{}
+	if submitMode == api.SubmitModeMakerOnly {
+		submitFilters = append(submitFilters,
+			plugins.MakeFilterMakerMode(exchangeShim, sdex, tradingPair),
+		)
+	}
+	if len(botConfig.Filters) > 0 && *options.strategy != "sell" && *options.strategy != "sell_twap" && *options.strategy != "buy_twap" && *options.strategy != "delete" {
+		utils.PrintErrorHintf("FILTERS currently only supported on 'sell', 'sell_twap', 'buy_twap', 'delete' strategies, remove FILTERS from the trader config file")
+		logger.Fatal(l, fmt.Errorf("invalid trader.cfg config, FILTERS not supported for strategy '%s'", *options.strategy))
+	}
+	for _, filterString := range botConfig.Filters {
+		filter, e := filterFactory.MakeFilter(filterString)
+		if e != nil {
+			logger.Fatal(l, e)
+		}
+		submitFilters = append(submitFilters, filter)
+	}
+	// exchange constraints filter is last so we catch any modifications made by previous filters
+	submitFilters = append(submitFilters,
+		plugins.MakeFilterOrderConstraints(exchangeShim.GetOrderConstraints(tradingPair), botConfig.AssetBase(), botConfig.AssetQuote()),
+	)
+	return submitFilters
+}
+
+func printBacktestResults(
+	backtestExchange *plugins.BacktestExchange,
+	tradingPair *model.TradingPair,
+	startBaseBalance float64,
+	startQuoteBalance float64,
+	numUpdates int,
+	numFailedUpdates int,
+) {
+	fills := backtestExchange.Fills()
+	fmt.Printf("\n")
+	fmt.Printf("  Fills (%d)\n", len(fills))
+	fmt.Printf("  Time\t\t\t\tAction\tPrice\t\tVolume\t\tCost\t\tFee\n")
+	fmt.Printf("  -----------------------------------------------------------------------------------------------------------\n")
+	for _, f := range fills {
+		fillTime := time.Unix(0, f.Timestamp.AsInt64()*int64(time.Millisecond)).UTC()
+		fmt.Printf("  %s\t\t%s\t%.7f\t%.7f\t%.7f\t%.7f\n",
+			fillTime.Format(time.RFC3339),
+			f.OrderAction,
+			f.Price.AsFloat(),
+			f.Volume.AsFloat(),
+			f.Cost.AsFloat(),
+			f.Fee.AsFloat(),
+		)
+	}
+
+	startPrice := backtestExchange.FirstTick().Price
+	endPrice := backtestExchange.LastTick().Price
+	endBaseBalance := backtestExchange.Balance(tradingPair.Base)
+	endQuoteBalance := backtestExchange.Balance(tradingPair.Quote)
+	// all values are in units of the quote asset, marked to the last traded price at the start and end of the replay
+	startValue := startBaseBalance*startPrice + startQuoteBalance
+	endValue := endBaseBalance*endPrice + endQuoteBalance
+	holdValue := startBaseBalance*endPrice + startQuoteBalance
+
+	fmt.Printf("\n")
+	fmt.Printf("  Update cycles: %d (failed: %d)\n", numUpdates, numFailedUpdates)
+	fmt.Printf("\n")
+	fmt.Printf("  Asset\t\tStart Balance\t\tEnd Balance\n")
+	fmt.Printf("  -----------------------------------------------------------\n")
+	fmt.Printf("  %s\t\t%.7f\t\t%.7f\n", tradingPair.Base, startBaseBalance, endBaseBalance)
+	fmt.Printf("  %s\t\t%.7f\t\t%.7f\n", tradingPair.Quote, startQuoteBalance, endQuoteBalance)
+	fmt.Printf("\n")
+	fmt.Printf("  PnL (in units of %s)\n", tradingPair.Quote)
+	fmt.Printf("  -----------------------------------------------------------\n")
+	fmt.Printf("  start price\t\t\t%.7f\n", startPrice)
+	fmt.Printf("  end price\t\t\t%.7f\n", endPrice)
+	fmt.Printf("  start value\t\t\t%.7f\n", startValue)
+	fmt.Printf("  end value\t\t\t%.7f\n", endValue)
+	fmt.Printf("  fees paid\t\t\t%.7f\n", backtestExchange.FeesPaid())
+	fmt.Printf("  PnL\t\t\t\t%.7f\n", endValue-startValue)
+	fmt.Printf("  PnL vs. holding\t\t%.7f\n", endValue-holdValue)
+}
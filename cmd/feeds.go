@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/plugins"
+	"github.com/stellar/kelp/support/logger"
+)
+
+const feedsExamples = `  kelp feeds exchange/ccxt-kraken/XLM/USD/mid exchange/ccxt-binance/XLM/USDT/mid
+  kelp feeds "function/median(exchange/ccxt-kraken/XLM/USD/mid,exchange/ccxt-coinbasepro/XLM/USD/mid)" fixed/0.1 --iterations 5 --interval 2000
+  kelp feeds crypto/https://api.coinmarketcap.com/v1/ticker/stellar/ --format json`
+
+// output formats of the feeds command
+const (
+	feedsFormatTable = "table"
+	feedsFormatJSON  = "json"
+)
+
+var feedsCmd = &cobra.Command{
+	Use:     "feeds <feed_type>/<feed_url> [<feed_type>/<feed_url>...]",
+	Short:   "Evaluates price feeds and reports their values, latency, errors, and the spread between them",
+	Example: feedsExamples,
+	Args:    cobra.MinimumNArgs(1),
+}
+
+type feedsInputs struct {
+	iterations     *int
+	intervalMillis *int64
+	format         *string
+}
+
+// feedSample is the result of fetching the price from a feed once
+type feedSample struct {
+	Iteration     int     `json:"iteration"`
+	Price         float64 `json:"price,omitempty"`
+	LatencyMillis int64   `json:"latency_millis"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// feedReport is the result of fetching the price from a feed on every iteration
+type feedReport struct {
+	Spec             string       `json:"spec"`
+	Samples          []feedSample `json:"samples"`
+	NumErrors        int          `json:"num_errors"`
+	Last             float64      `json:"last,omitempty"`
+	Min              float64      `json:"min,omitempty"`
+	Max              float64      `json:"max,omitempty"`
+	Mean             float64      `json:"mean,omitempty"`
+	AvgLatencyMillis int64        `json:"avg_latency_millis"`
+	MaxLatencyMillis int64        `json:"max_latency_millis"`
+}
+
+// feedsReport is the output of the feeds command, the spread is (max - min) / min of the prices of the feeds that did not fail on an
+// iteration, and is only computed when there are at least 2 prices on the iteration
+type feedsReport struct {
+	Feeds        []*feedReport `json:"feeds"`
+	SpreadPcts   []*float64    `json:"spread_pcts,omitempty"`
+	MaxSpreadPct *float64      `json:"max_spread_pct,omitempty"`
+}
+
+func init() {
+	options := feedsInputs{}
+	options.iterations = feedsCmd.Flags().IntP("iterations", "n", 1, "number of times to fetch the price from each feed")
+	options.intervalMillis = feedsCmd.Flags().Int64P("interval", "i", 1000, "milliseconds to wait between iterations")
+	options.format = feedsCmd.Flags().StringP("format", "o", feedsFormatTable, fmt.Sprintf("output format, one of '%s' or '%s'", feedsFormatTable, feedsFormatJSON))
+	feedsCmd.Flags().SortFlags = false
+
+	feedsCmd.Run = func(ccmd *cobra.Command, args []string) {
+		checkInitRootFlags()
+		runFeedsCmd(options, args)
+	}
+}
+
+func runFeedsCmd(options feedsInputs, specs []string) {
+	l := logger.MakeBasicLogger()
+	if *options.iterations <= 0 {
+		logger.Fatal(l, fmt.Errorf("iterations needs to be greater than 0"))
+	}
+	if *options.intervalMillis < 0 {
+		logger.Fatal(l, fmt.Errorf("interval cannot be negative"))
+	}
+	if *options.format != feedsFormatTable && *options.format != feedsFormatJSON {
+		logger.Fatal(l, fmt.Errorf("format needs to be either '%s' or '%s'", feedsFormatTable, feedsFormatJSON))
+	}
+
+	feeds := []api.PriceFeed{}
+	for _, spec := range specs {
+		feed, e := plugins.MakePriceFeedFromSpec(spec)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("could not make price feed '%s': %s", spec, e))
+		}
+		feeds = append(feeds, feed)
+	}
+
+	report := evaluateFeeds(specs, feeds, *options.iterations, time.Duration(*options.intervalMillis)*time.Millisecond, time.Now, time.Sleep)
+
+	if *options.format == feedsFormatJSON {
+		reportBytes, e := json.MarshalIndent(report, "", "  ")
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("could not marshal report: %s", e))
+		}
+		fmt.Println(string(reportBytes))
+		return
+	}
+	printFeedsTable(report)
+}
+
+// evaluateFeeds fetches the price from every feed on each iteration
+func evaluateFeeds(
+	specs []string,
+	feeds []api.PriceFeed,
+	iterations int,
+	interval time.Duration,
+	nowFn func() time.Time,
+	sleepFn func(time.Duration),
+) *feedsReport {
+	report := &feedsReport{
+		Feeds:      []*feedReport{},
+		SpreadPcts: []*float64{},
+	}
+	for _, spec := range specs {
+		report.Feeds = append(report.Feeds, &feedReport{
+			Spec:    spec,
+			Samples: []feedSample{},
+		})
+	}
+
+	for i := 0; i < iterations; i++ {
+		if i > 0 {
+			sleepFn(interval)
+		}
+
+		prices := []float64{}
+		for j, feed := range feeds {
+			start := nowFn()
+			price, e := feed.GetPrice()
+			sample := feedSample{
+				Iteration:     i,
+				LatencyMillis: nowFn().Sub(start).Milliseconds(),
+			}
+			if e != nil {
+				sample.Error = e.Error()
+			} else {
+				sample.Price = price
+				prices = append(prices, price)
+			}
+			report.Feeds[j].Samples = append(report.Feeds[j].Samples, sample)
+		}
+		report.SpreadPcts = append(report.SpreadPcts, computeSpreadPct(prices))
+	}
+
+	for _, f := range report.Feeds {
+		summarizeFeedReport(f)
+	}
+	for _, s := range report.SpreadPcts {
+		if s != nil && (report.MaxSpreadPct == nil || *s > *report.MaxSpreadPct) {
+			report.MaxSpreadPct = s
+		}
+	}
+	return report
+}
+
+// computeSpreadPct returns nil when there are fewer than 2 prices
+func computeSpreadPct(prices []float64) *float64 {
+	if len(prices) < 2 {
+		return nil
+	}
+
+	min := math.Inf(1)
+	max := math.Inf(-1)
+	for _, p := range prices {
+		min = math.Min(min, p)
+		max = math.Max(max, p)
+	}
+	if min <= 0 {
+		return nil
+	}
+	spread := 100 * (max - min) / min
+	return &spread
+}
+
+func summarizeFeedReport(f *feedReport) {
+	sum := 0.0
+	numPrices := 0
+	totalLatency := int64(0)
+	for _, s := range f.Samples {
+		totalLatency += s.LatencyMillis
+		if s.LatencyMillis > f.MaxLatencyMillis {
+			f.MaxLatencyMillis = s.LatencyMillis
+		}
+
+		if s.Error != "" {
+			f.NumErrors++
+			continue
+		}
+		if numPrices == 0 || s.Price < f.Min {
+			f.Min = s.Price
+		}
+		if numPrices == 0 || s.Price > f.Max {
+			f.Max = s.Price
+		}
+		f.Last = s.Price
+		sum += s.Price
+		numPrices++
+	}
+
+	if len(f.Samples) > 0 {
+		f.AvgLatencyMillis = totalLatency / int64(len(f.Samples))
+	}
+	if numPrices > 0 {
+		f.Mean = sum / float64(numPrices)
+	}
+}
+
+func printFeedsTable(report *feedsReport) {
+	fmt.Printf("  %-16s\t%-16s\t%-16s\t%-16s\t%-8s\t%-12s\t%-12s\t%s\n", "Last", "Min", "Max", "Mean", "Errors", "Avg Latency", "Max Latency", "Feed")
+	fmt.Printf("  -----------------------------------------------------------------------------------------------------------------------------\n")
+	for _, f := range report.Feeds {
+		fmt.Printf("  %-16.10f\t%-16.10f\t%-16.10f\t%-16.10f\t%d/%-6d\t%-12s\t%-12s\t%s\n",
+			f.Last,
+			f.Min,
+			f.Max,
+			f.Mean,
+			f.NumErrors,
+			len(f.Samples),
+			fmt.Sprintf("%dms", f.AvgLatencyMillis),
+			fmt.Sprintf("%dms", f.MaxLatencyMillis),
+			f.Spec,
+		)
+	}
+
+	if len(report.Feeds) > 1 {
+		fmt.Println()
+		for i, s := range report.SpreadPcts {
+			if s == nil {
+				fmt.Printf("  spread on iteration %d: n/a (fewer than 2 feeds returned a price)\n", i)
+				continue
+			}
+			fmt.Printf("  spread on iteration %d: %.4f%%\n", i, *s)
+		}
+		if report.MaxSpreadPct != nil {
+			fmt.Printf("  max spread: %.4f%%\n", *report.MaxSpreadPct)
+		}
+	}
+
+	hasErrors := false
+	for _, f := range report.Feeds {
+		for _, s := range f.Samples {
+			if s.Error == "" {
+				continue
+			}
+			if !hasErrors {
+				fmt.Println()
+				fmt.Println("  errors:")
+				hasErrors = true
+			}
+			fmt.Printf("  iteration %d, %s: %s\n", s.Iteration, f.Spec, s.Error)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/plugins"
+)
+
+type feedsTestFeed struct {
+	prices []float64
+	calls  int
+}
+
+func (f *feedsTestFeed) GetPrice() (float64, error) {
+	price := f.prices[f.calls]
+	f.calls++
+	if price <= 0 {
+		return 0, fmt.Errorf("feed failed")
+	}
+	return price, nil
+}
+
+func TestEvaluateFeeds(t *testing.T) {
+	fixedFeed, e := plugins.MakePriceFeedFromSpec("fixed/1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	feeds := []api.PriceFeed{
+		fixedFeed,
+		&feedsTestFeed{prices: []float64{1.1, 0, 1.05}},
+		&feedsTestFeed{prices: []float64{0, 0, 0.9}},
+	}
+
+	// every call to now advances the time by 5ms so each fetch has a latency of 5ms
+	now := time.Unix(0, 0)
+	nowFn := func() time.Time {
+		now = now.Add(5 * time.Millisecond)
+		return now
+	}
+	sleeps := []time.Duration{}
+	sleepFn := func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+
+	report := evaluateFeeds([]string{"fixed/1.0", "a", "b"}, feeds, 3, time.Second, nowFn, sleepFn)
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, sleeps)
+	if !assert.Equal(t, 3, len(report.Feeds)) {
+		return
+	}
+
+	assert.Equal(t, 0, report.Feeds[0].NumErrors)
+	assert.Equal(t, 1.0, report.Feeds[0].Last)
+	assert.Equal(t, int64(5), report.Feeds[0].AvgLatencyMillis)
+
+	assert.Equal(t, 1, report.Feeds[1].NumErrors)
+	assert.Equal(t, "feed failed", report.Feeds[1].Samples[1].Error)
+	assert.Equal(t, 1.05, report.Feeds[1].Last)
+	assert.Equal(t, 1.05, report.Feeds[1].Min)
+	assert.Equal(t, 1.1, report.Feeds[1].Max)
+	assert.InDelta(t, 1.075, report.Feeds[1].Mean, 0.0000001)
+
+	assert.Equal(t, 2, report.Feeds[2].NumErrors)
+	assert.Equal(t, 0.9, report.Feeds[2].Last)
+
+	if !assert.Equal(t, 3, len(report.SpreadPcts)) {
+		return
+	}
+	assert.InDelta(t, 10.0, *report.SpreadPcts[0], 0.0000001)
+	assert.Nil(t, report.SpreadPcts[1])
+	assert.InDelta(t, 16.6666667, *report.SpreadPcts[2], 0.0000001)
+	assert.InDelta(t, 16.6666667, *report.MaxSpreadPct, 0.0000001)
+}
+
+func TestComputeSpreadPct(t *testing.T) {
+	assert.Nil(t, computeSpreadPct([]float64{}))
+	assert.Nil(t, computeSpreadPct([]float64{1.0}))
+	assert.InDelta(t, 50.0, *computeSpreadPct([]float64{1.5, 1.0, 1.2}), 0.0000001)
+}
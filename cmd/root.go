@@ -77,9 +77,11 @@ func init() {
 	rootCcxtRestURL = RootCmd.PersistentFlags().String("ccxt-rest-url", "", "URL to use for the CCXT-rest API. Takes precendence over the CCXT_REST_URL param set in the botConfg file for the trade command and passed as a parameter into the Kelp subprocesses started by the GUI (default URL is https://localhost:3000)")
 
 	RootCmd.AddCommand(tradeCmd)
+	RootCmd.AddCommand(backtestCmd)
 	RootCmd.AddCommand(serverCmd)
 	RootCmd.AddCommand(strategiesCmd)
 	RootCmd.AddCommand(exchangesCmd)
+	RootCmd.AddCommand(feedsCmd)
 	RootCmd.AddCommand(terminateCmd)
 	RootCmd.AddCommand(versionCmd)
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io"
@@ -59,6 +60,12 @@ var upgradeScripts = []*database.UpgradeScript{
 		kelpdb.SqlStrategyMirrorTradeTriggersTableCreate,
 		kelpdb.SqlTradesTableAlter2,
 	),
+	database.MakeUpgradeScript(7,
+		kelpdb.SqlStrategyGridStateTableCreate,
+	),
+	database.MakeUpgradeScript(8,
+		kelpdb.SqlPriceFeedStateTableCreate,
+	),
 }
 
 const tradeExamples = `  kelp trade --botConf ./path/trader.cfg --strategy buysell --stratConf ./path/buysell.cfg
@@ -66,6 +73,16 @@ const tradeExamples = `  kelp trade --botConf ./path/trader.cfg --strategy buyse
 
 const prefsFilename = "kelp.prefs"
 
+// eventStreamReconnectDelay is how long we wait before reconnecting to the horizon trade stream that triggers the event time controller
+const eventStreamReconnectDelay = 5 * time.Second
+
+// sdexStreamReconnectDelay is how long we wait before reconnecting to the horizon streams that back the SDEX orderbook and trades
+const sdexStreamReconnectDelay = 5 * time.Second
+
+// sdexStreamDefaultStaleAfter is how long the horizon orderbook stream can go without an event before we poll horizon instead, used when
+// HORIZON_STREAMING_STALE_AFTER_MILLIS is not set
+const sdexStreamDefaultStaleAfter = 60 * time.Second
+
 var tradeCmd = &cobra.Command{
 	Use:     "trade",
 	Short:   "Trades against the Stellar universal marketplace using the specified strategy",
@@ -152,6 +169,18 @@ func validateBotConfig(l logger.Logger, botConfig trader.BotConfig) {
 	if botConfig.SleepMode != "" && botConfig.SleepMode != trader.SleepModeBegin.String() && botConfig.SleepMode != trader.SleepModeEnd.String() {
 		logger.Fatal(l, fmt.Errorf("SLEEP_MODE needs to be set to either '%s' or '%s'", trader.SleepModeBegin, trader.SleepModeEnd))
 	}
+
+	if botConfig.TimeController != "" && botConfig.TimeController != trader.TimeControllerInterval && botConfig.TimeController != trader.TimeControllerEvent {
+		logger.Fatal(l, fmt.Errorf("TIME_CONTROLLER needs to be set to either '%s' or '%s'", trader.TimeControllerInterval, trader.TimeControllerEvent))
+	}
+
+	if botConfig.PriceFeedCacheTTLMillis < 0 {
+		logger.Fatal(l, fmt.Errorf("PRICE_FEED_CACHE_TTL_MILLIS cannot be negative"))
+	}
+
+	if botConfig.HorizonStreamingStaleAfterMillis < 0 {
+		logger.Fatal(l, fmt.Errorf("HORIZON_STREAMING_STALE_AFTER_MILLIS cannot be negative"))
+	}
 }
 
 func validatePrecisionConfig(l logger.Logger, isTradingSdex bool, precisionField *int8, name string) {
@@ -369,6 +398,29 @@ func makeExchangeShimSdex(
 	return exchangeShim, sdex
 }
 
+// startSdexStreamCache serves the SDEX orderbook and trades from horizon's streaming endpoints when enabled in the config
+func startSdexStreamCache(l logger.Logger, botConfig trader.BotConfig, sdex *plugins.SDEX) {
+	if !botConfig.HorizonStreamingEnable {
+		return
+	}
+	if !botConfig.IsTradingSdex() {
+		l.Info("HORIZON_STREAMING_ENABLE only applies when trading on SDEX, polling horizon instead")
+		return
+	}
+
+	staleAfter := sdexStreamDefaultStaleAfter
+	if botConfig.HorizonStreamingStaleAfterMillis > 0 {
+		staleAfter = time.Duration(botConfig.HorizonStreamingStaleAfterMillis) * time.Millisecond
+	}
+	streamCache := plugins.MakeSdexStreamCache(sdex, sdexStreamReconnectDelay, staleAfter, plugins.MakeRealClock())
+	e := streamCache.Start(context.Background())
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("unable to start the horizon stream cache: %s", e))
+	}
+	sdex.SetStreamCache(streamCache)
+	l.Info("serving the SDEX orderbook and trades from horizon streams")
+}
+
 func makeStrategy(
 	l logger.Logger,
 	network string,
@@ -382,6 +434,8 @@ func makeStrategy(
 	ieif *plugins.IEIF,
 	tradingPair *model.TradingPair,
 	filterFactory *plugins.FilterFactory,
+	strategyName string,
+	stratConfigPath string,
 	options inputs,
 	threadTracker *multithreading.ThreadTracker,
 	db *sql.DB,
@@ -405,12 +459,14 @@ func makeStrategy(
 		&assetBase,
 		&assetQuote,
 		marketID,
-		*options.strategy,
-		*options.stratConfigPath,
+		strategyName,
+		stratConfigPath,
 		*options.simMode,
 		botConfig.IsTradingSdex(),
 		filterFactory,
+		&plugins.PriceFeedFactory{},
 		db,
+		plugins.MakeRealClock(),
 	)
 	if e != nil {
 		l.Info("")
@@ -421,6 +477,97 @@ func makeStrategy(
 	return strategy
 }
 
+// makeTimeController makes the time controller that decides when to run the update cycle, the event time controller is also returned
+// when it is enabled so it can be connected to the sources of events
+func makeTimeController(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	client *horizonclient.Client,
+	sdex *plugins.SDEX,
+	exchangeShim api.ExchangeShim,
+	threadTracker *multithreading.ThreadTracker,
+	metricsTracker *plugins.MetricsTracker,
+) (api.TimeController, *plugins.EventTimeController) {
+	if !botConfig.IsEventTimeController() {
+		return plugins.MakeIntervalTimeController(
+			time.Duration(botConfig.TickIntervalMillis)*time.Millisecond,
+			botConfig.MaxTickDelayMillis,
+			plugins.MakeRealClock(),
+		), nil
+	}
+
+	var priceFeed api.PriceFeed
+	if botConfig.EventPriceFeedType != "" {
+		var e error
+		priceFeed, e = plugins.MakePriceFeed(botConfig.EventPriceFeedType, botConfig.EventPriceFeedURL)
+		if e != nil {
+			log.Println()
+			log.Printf("could not make the price feed for the event time controller: %s\n", e)
+			// we want to delete all the offers and exit here since there is something wrong with our setup
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		}
+	}
+
+	// TICK_INTERVAL_MILLIS is the longest we go without running an update cycle when using the event time controller
+	eventTimeController, e := plugins.MakeEventTimeController(
+		time.Duration(botConfig.EventMinIntervalMillis)*time.Millisecond,
+		time.Duration(botConfig.TickIntervalMillis)*time.Millisecond,
+		time.Duration(botConfig.EventPollIntervalMillis)*time.Millisecond,
+		priceFeed,
+		botConfig.EventPriceChangeThreshold,
+		plugins.MakeRealClock(),
+	)
+	if e != nil {
+		log.Println()
+		log.Printf("could not make the event time controller: %s\n", e)
+		// we want to delete all the offers and exit here since there is something wrong with our setup
+		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+	}
+	return eventTimeController, eventTimeController
+}
+
+// startEventTriggers connects the event time controller to the fills of our offers, streamed from horizon when trading on SDEX and
+// from the fill trackers otherwise
+func startEventTriggers(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	client *horizonclient.Client,
+	sdex *plugins.SDEX,
+	exchangeShim api.ExchangeShim,
+	eventTimeController *plugins.EventTimeController,
+	fillTrackers []api.FillTracker,
+	threadTracker *multithreading.ThreadTracker,
+	metricsTracker *plugins.MetricsTracker,
+) {
+	if !botConfig.EventTriggerOnFills {
+		return
+	}
+
+	if !botConfig.IsTradingSdex() {
+		if len(fillTrackers) == 0 {
+			log.Println()
+			utils.PrintErrorHintf("EVENT_TRIGGER_ON_FILLS needs fill tracking to be enabled when not trading on SDEX (set FILL_TRACKER_SLEEP_MILLIS to a non-zero value)")
+			// we want to delete all the offers and exit here since there is something wrong with our setup
+			deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+		}
+		for _, fillTracker := range fillTrackers {
+			fillTracker.RegisterHandler(eventTimeController)
+		}
+		l.Info("event time controller will be triggered by the fill tracker")
+		return
+	}
+
+	go func() {
+		for {
+			e := eventTimeController.StreamTrades(context.Background(), client, botConfig.TradingAccount())
+			// the update cycle still runs every TICK_INTERVAL_MILLIS so we only log here and reconnect
+			l.Infof("horizon trade stream for the event time controller ended, reconnecting in %s: %v\n", eventStreamReconnectDelay, e)
+			time.Sleep(eventStreamReconnectDelay)
+		}
+	}()
+	l.Info("event time controller will be triggered by trades streamed from horizon")
+}
+
 func makeBot(
 	l logger.Logger,
 	botConfig trader.BotConfig,
@@ -429,18 +576,20 @@ func makeBot(
 	exchangeShim api.ExchangeShim,
 	ieif *plugins.IEIF,
 	tradingPair *model.TradingPair,
+	assetBase hProtocol.Asset,
+	assetQuote hProtocol.Asset,
 	filterFactory *plugins.FilterFactory,
+	strategyName string,
+	filters []string,
 	strategy api.Strategy,
 	fillTracker api.FillTracker,
+	timeController api.TimeController,
 	threadTracker *multithreading.ThreadTracker,
 	options inputs,
 	metricsTracker *plugins.MetricsTracker,
+	alert api.Alert,
 	botStartTime time.Time,
 ) *trader.Trader {
-	timeController := plugins.MakeIntervalTimeController(
-		time.Duration(botConfig.TickIntervalMillis)*time.Millisecond,
-		botConfig.MaxTickDelayMillis,
-	)
 	submitMode, e := api.ParseSubmitMode(botConfig.SubmitMode)
 	if e != nil {
 		log.Println()
@@ -456,13 +605,7 @@ func makeBot(
 		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
 	}
 
-	assetBase := botConfig.AssetBase()
-	assetQuote := botConfig.AssetQuote()
 	dataKey := model.MakeSortedBotKey(assetBase, assetQuote)
-	alert, e := monitoring.MakeAlert(botConfig.AlertType, botConfig.AlertAPIKey)
-	if e != nil {
-		l.Infof("Unable to set up monitoring for alert type '%s' with the given API key\n", botConfig.AlertType)
-	}
 
 	var valueBaseFeed api.PriceFeed
 	var valueQuoteFeed api.PriceFeed
@@ -491,13 +634,13 @@ func makeBot(
 			plugins.MakeFilterMakerMode(exchangeShim, sdex, tradingPair),
 		)
 	}
-	if len(botConfig.Filters) > 0 && *options.strategy != "sell" && *options.strategy != "sell_twap" && *options.strategy != "buy_twap" && *options.strategy != "delete" {
+	if len(filters) > 0 && strategyName != "sell" && strategyName != "sell_twap" && strategyName != "buy_twap" && strategyName != "delete" {
 		log.Println()
 		utils.PrintErrorHintf("FILTERS currently only supported on 'sell', 'sell_twap', 'buy_twap', 'delete' strategies, remove FILTERS from the trader config file")
 		// we want to delete all the offers and exit here since there is something wrong with our setup
 		deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
 	}
-	for _, filterString := range botConfig.Filters {
+	for _, filterString := range filters {
 		filter, e := filterFactory.MakeFilter(filterString)
 		if e != nil {
 			log.Println()
@@ -526,6 +669,7 @@ func makeBot(
 		exchangeShim,
 		strategy,
 		timeController,
+		plugins.MakeRealClock(),
 		trader.ParseSleepMode(botConfig.SleepMode),
 		botConfig.SynchronizeStateLoadEnable,
 		botConfig.SynchronizeStateLoadMaxRetries,
@@ -683,6 +827,11 @@ func runTradeCmd(options inputs) {
 
 	ieif := plugins.MakeIEIF(botConfig.IsTradingSdex())
 	network := utils.ParseNetwork(botConfig.HorizonURL)
+	// the alert is shared by the traders and the filters, such as the circuit breaker, so they raise alerts on the same monitoring service
+	alert, e := monitoring.MakeAlert(botConfig.AlertType, botConfig.AlertAPIKey)
+	if e != nil {
+		l.Infof("Unable to set up monitoring for alert type '%s' with the given API key\n", botConfig.AlertType)
+	}
 	sdexAssetMap := map[model.Asset]hProtocol.Asset{
 		tradingPair.Base:  botConfig.AssetBase(),
 		tradingPair.Quote: botConfig.AssetQuote(),
@@ -692,6 +841,11 @@ func runTradeCmd(options inputs) {
 		assetDisplayFn = model.MakeSdexMappedAssetDisplayFn(sdexAssetMap)
 	}
 
+	e = plugins.SetPriceFeedCacheTTL(time.Duration(botConfig.PriceFeedCacheTTLMillis) * time.Millisecond)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("could not set the price feed cache TTL: %s", e))
+	}
+
 	var db *sql.DB
 	if botConfig.PostgresDbConfig != nil {
 		if !botConfig.SynchronizeStateLoadEnable && botConfig.FillTrackerSleepMillis == 0 {
@@ -712,6 +866,11 @@ func runTradeCmd(options inputs) {
 			logger.Fatal(l, fmt.Errorf("problem encountered while initializing the db: %s", e))
 		}
 		log.Printf("made db instance with config: %s\n", botConfig.PostgresDbConfig.MakeConnectString())
+
+		e = plugins.SetPrivatePriceFeedDBHack(db)
+		if e != nil {
+			logger.Fatal(l, fmt.Errorf("could not set the db used by the price feeds: %s", e))
+		}
 	}
 	exchangeShim, sdex := makeExchangeShimSdex(
 		l,
@@ -724,6 +883,7 @@ func runTradeCmd(options inputs) {
 		tradingPair,
 		sdexAssetMap,
 	)
+	startSdexStreamCache(l, botConfig, sdex)
 	filterFactory := &plugins.FilterFactory{
 		ExchangeName:   botConfig.TradingExchangeName(),
 		TradingPair:    tradingPair,
@@ -731,6 +891,9 @@ func runTradeCmd(options inputs) {
 		BaseAsset:      assetBase,
 		QuoteAsset:     assetQuote,
 		DB:             db,
+		IEIF:           ieif,
+		Alert:          alert,
+		HorizonClient:  client,
 	}
 	baseString, e := assetDisplayFn(tradingPair.Base)
 	if e != nil {
@@ -754,6 +917,8 @@ func runTradeCmd(options inputs) {
 		ieif,
 		tradingPair,
 		filterFactory,
+		*options.strategy,
+		*options.stratConfigPath,
 		options,
 		threadTracker,
 		db,
@@ -773,6 +938,7 @@ func runTradeCmd(options inputs) {
 		botConfig.DbOverrideAccountID,
 		metricsTracker,
 	)
+	timeController, eventTimeController := makeTimeController(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
 	bot := makeBot(
 		l,
 		botConfig,
@@ -781,17 +947,54 @@ func runTradeCmd(options inputs) {
 		exchangeShim,
 		ieif,
 		tradingPair,
+		assetBase,
+		assetQuote,
 		filterFactory,
+		*options.strategy,
+		botConfig.Filters,
 		strategy,
 		fillTracker,
+		timeController,
 		threadTracker,
 		options,
 		metricsTracker,
+		alert,
 		botStartTime,
 	)
+	bots := []*trader.Trader{bot}
+	fillTrackers := []api.FillTracker{}
+	if fillTracker != nil {
+		fillTrackers = append(fillTrackers, fillTracker)
+	}
+	for _, market := range botConfig.Markets {
+		l.Infof("Trading additional market %s with strategy '%s'\n", market.TradingPair(), market.Strategy)
+		marketBot, marketFillTracker := makeMarketBot(
+			l,
+			botConfig,
+			market,
+			client,
+			sdex,
+			ieif,
+			network,
+			db,
+			timeController,
+			threadTracker,
+			options,
+			metricsTracker,
+			alert,
+			botStartTime,
+		)
+		bots = append(bots, marketBot)
+		if marketFillTracker != nil {
+			fillTrackers = append(fillTrackers, marketFillTracker)
+		}
+	}
 	// --- end initialization of objects ---
 	// --- start initialization of services ---
 	validateTrustlines(l, client, &botConfig)
+	if eventTimeController != nil {
+		startEventTriggers(l, botConfig, client, sdex, exchangeShim, eventTimeController, fillTrackers, threadTracker, metricsTracker)
+	}
 	if botConfig.MonitoringPort != 0 {
 		go func() {
 			e := startMonitoringServer(l, botConfig)
@@ -806,7 +1009,7 @@ func runTradeCmd(options inputs) {
 			}
 		}()
 	}
-	if fillTracker != nil && botConfig.FillTrackerSleepMillis != 0 {
+	if len(botConfig.Markets) == 0 && fillTracker != nil && botConfig.FillTrackerSleepMillis != 0 {
 		l.Infof("Starting fill tracker with %d handlers\n", fillTracker.NumHandlers())
 		go func() {
 			e := fillTracker.TrackFills()
@@ -817,11 +1020,152 @@ func runTradeCmd(options inputs) {
 				deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
 			}
 		}()
+	} else if len(botConfig.Markets) > 0 && len(fillTrackers) > 0 && botConfig.FillTrackerSleepMillis != 0 {
+		// a single thread tracks the fills of all the markets so we don't multiply the requests made to horizon
+		multiFillTracker := plugins.MakeMultiFillTracker(fillTrackers, botConfig.FillTrackerSleepMillis, botConfig.FillTrackerDeleteCyclesThreshold, plugins.MakeRealClock())
+		l.Infof("Starting fill tracker for %d markets with %d handlers\n", len(fillTrackers), multiFillTracker.NumHandlers())
+		go func() {
+			e := multiFillTracker.TrackFills()
+			if e != nil {
+				l.Info("")
+				l.Errorf("problem encountered while running the fill tracker: %s", e)
+				// we want to delete all the offers and exit here because we don't want the bot to run if fill tracking isn't working
+				deleteAllOffersAndExit(l, botConfig, client, sdex, exchangeShim, threadTracker, metricsTracker)
+			}
+		}()
 	}
 	// --- end initialization of services ---
 
-	l.Info("Starting the trader bot...")
-	bot.Start()
+	if len(bots) == 1 {
+		l.Info("Starting the trader bot...")
+		bot.Start()
+		return
+	}
+
+	l.Infof("Starting the trader bot for %d markets...\n", len(bots))
+	multiTrader := trader.MakeMultiTrader(
+		bots,
+		timeController,
+		plugins.MakeRealClock(),
+		trader.ParseSleepMode(botConfig.SleepMode),
+		threadTracker,
+		options.fixedIterations,
+		metricsTracker,
+		botStartTime,
+	)
+	multiTrader.Start()
+}
+
+// makeMarketBot makes the trader for an additional market listed in MARKETS, which trades from the same account as the primary market
+// and shares its sequence number, IEIF and thread tracker
+func makeMarketBot(
+	l logger.Logger,
+	botConfig trader.BotConfig,
+	market *trader.MarketConfig,
+	client *horizonclient.Client,
+	primarySdex *plugins.SDEX,
+	ieif *plugins.IEIF,
+	network string,
+	db *sql.DB,
+	timeController api.TimeController,
+	threadTracker *multithreading.ThreadTracker,
+	options inputs,
+	metricsTracker *plugins.MetricsTracker,
+	alert api.Alert,
+	botStartTime time.Time,
+) (*trader.Trader, api.FillTracker) {
+	assetBase := market.AssetBase()
+	assetQuote := market.AssetQuote()
+	tradingPair := &model.TradingPair{
+		Base:  model.Asset(utils.Asset2CodeString(assetBase)),
+		Quote: model.Asset(utils.Asset2CodeString(assetQuote)),
+	}
+	sdexAssetMap := map[model.Asset]hProtocol.Asset{
+		tradingPair.Base:  assetBase,
+		tradingPair.Quote: assetQuote,
+	}
+	assetDisplayFn := model.MakeSdexMappedAssetDisplayFn(sdexAssetMap)
+	sdex := primarySdex.ForPair(tradingPair, sdexAssetMap)
+	startSdexStreamCache(l, botConfig, sdex)
+
+	filterFactory := &plugins.FilterFactory{
+		ExchangeName:   botConfig.TradingExchangeName(),
+		TradingPair:    tradingPair,
+		AssetDisplayFn: assetDisplayFn,
+		BaseAsset:      assetBase,
+		QuoteAsset:     assetQuote,
+		DB:             db,
+		IEIF:           ieif,
+		Alert:          alert,
+		HorizonClient:  client,
+	}
+	baseString, e := assetDisplayFn(tradingPair.Base)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("could not convert base trading pair to string for market %s: %s", market.TradingPair(), e))
+	}
+	quoteString, e := assetDisplayFn(tradingPair.Quote)
+	if e != nil {
+		logger.Fatal(l, fmt.Errorf("could not convert quote trading pair to string for market %s: %s", market.TradingPair(), e))
+	}
+	marketID := plugins.MakeMarketID(botConfig.TradingExchangeName(), baseString, quoteString)
+
+	strategy := makeStrategy(
+		l,
+		network,
+		botConfig,
+		client,
+		sdex,
+		sdex,
+		assetBase,
+		assetQuote,
+		marketID,
+		ieif,
+		tradingPair,
+		filterFactory,
+		market.Strategy,
+		market.StrategyConfigPath,
+		options,
+		threadTracker,
+		db,
+		metricsTracker,
+	)
+	fillTracker := makeFillTracker(
+		l,
+		strategy,
+		botConfig,
+		client,
+		sdex,
+		sdex,
+		tradingPair,
+		assetDisplayFn,
+		db,
+		threadTracker,
+		botConfig.DbOverrideAccountID,
+		metricsTracker,
+	)
+	bot := makeBot(
+		l,
+		botConfig,
+		client,
+		sdex,
+		sdex,
+		ieif,
+		tradingPair,
+		assetBase,
+		assetQuote,
+		filterFactory,
+		market.Strategy,
+		market.Filters,
+		strategy,
+		fillTracker,
+		timeController,
+		threadTracker,
+		options,
+		metricsTracker,
+		alert,
+		botStartTime,
+	)
+	return bot, fillTracker
 }
 
 func getUserID(l logger.Logger, botConfig trader.BotConfig) (string, error) {
@@ -936,7 +1280,7 @@ func makeFillTracker(
 		log.Printf("set latest trade cursor from where to start tracking fills (used override value): %v\n", lastCursor)
 	}
 
-	fillTracker := plugins.MakeFillTracker(tradingPair, threadTracker, exchangeShim, botConfig.FillTrackerSleepMillis, botConfig.FillTrackerDeleteCyclesThreshold, lastCursor)
+	fillTracker := plugins.MakeFillTracker(tradingPair, threadTracker, exchangeShim, botConfig.FillTrackerSleepMillis, botConfig.FillTrackerDeleteCyclesThreshold, lastCursor, plugins.MakeRealClock())
 	fillLogger := plugins.MakeFillLogger()
 	fillTracker.RegisterHandler(fillLogger)
 	if db != nil {
@@ -980,6 +1324,18 @@ func validateTrustlines(l logger.Logger, client *horizonclient.Client, botConfig
 		}
 	}
 
+	for _, market := range botConfig.Markets {
+		for _, asset := range []hProtocol.Asset{market.AssetBase(), market.AssetQuote()} {
+			if asset.Type == utils.Native {
+				continue
+			}
+			balance := utils.GetCreditBalance(account, asset.Code, asset.Issuer)
+			if balance == nil {
+				missingTrustlines = append(missingTrustlines, fmt.Sprintf("%s:%s", asset.Code, asset.Issuer))
+			}
+		}
+	}
+
 	if len(missingTrustlines) > 0 {
 		logger.Fatal(l, fmt.Errorf("error: your trading account does not have the required trustlines: %v", missingTrustlines))
 	}
@@ -1019,6 +1375,11 @@ func deleteAllOffersAndExit(
 	}
 	sellingAOffers, buyingAOffers := utils.FilterOffers(offers, botConfig.AssetBase(), botConfig.AssetQuote())
 	allOffers := append(sellingAOffers, buyingAOffers...)
+	for _, market := range botConfig.Markets {
+		sellingOffers, buyingOffers := utils.FilterOffers(offers, market.AssetBase(), market.AssetQuote())
+		allOffers = append(allOffers, sellingOffers...)
+		allOffers = append(allOffers, buyingOffers...)
+	}
 
 	dOps := sdex.DeleteAllOffers(allOffers)
 	l.Infof("created %d operations to delete offers\n", len(dOps))
@@ -23,11 +23,13 @@ func TestTradeUpgradeScripts(t *testing.T) {
 	}
 
 	// assert current state of the database
-	assert.Equal(t, 4, database.GetNumTablesInDb(db))
+	assert.Equal(t, 6, database.GetNumTablesInDb(db))
 	assert.True(t, database.CheckTableExists(db, "db_version"))
 	assert.True(t, database.CheckTableExists(db, "markets"))
 	assert.True(t, database.CheckTableExists(db, "trades"))
 	assert.True(t, database.CheckTableExists(db, "strategy_mirror_trade_triggers"))
+	assert.True(t, database.CheckTableExists(db, "strategy_grid_state"))
+	assert.True(t, database.CheckTableExists(db, "price_feed_state"))
 
 	// check schema of db_version table
 	var columns []database.TableColumn
@@ -256,10 +258,106 @@ func TestTradeUpgradeScripts(t *testing.T) {
 	assert.Equal(t, 1, len(indexes))
 	database.AssertIndex(t, "strategy_mirror_trade_triggers", "strategy_mirror_trade_triggers_pkey", "CREATE UNIQUE INDEX strategy_mirror_trade_triggers_pkey ON public.strategy_mirror_trade_triggers USING btree (market_id, txid)", indexes)
 
+	// check schema of strategy_grid_state table
+	columns = database.GetTableSchema(db, "strategy_grid_state")
+	assert.Equal(t, 7, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "market_id",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "min_price",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "max_price",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "double precision",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "num_levels",
+		OrdinalPosition:        4,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "integer",
+		CharacterMaximumLength: nil,
+	}, &columns[3])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "spacing",
+		OrdinalPosition:        5,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[4])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "anchor_index",
+		OrdinalPosition:        6,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "integer",
+		CharacterMaximumLength: nil,
+	}, &columns[5])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "updated_at_utc",
+		OrdinalPosition:        7,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[6])
+	// check indexes of strategy_grid_state table
+	indexes = database.GetTableIndexes(db, "strategy_grid_state")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "strategy_grid_state", "strategy_grid_state_pkey", "CREATE UNIQUE INDEX strategy_grid_state_pkey ON public.strategy_grid_state USING btree (market_id)", indexes)
+
+	// check schema of price_feed_state table
+	columns = database.GetTableSchema(db, "price_feed_state")
+	assert.Equal(t, 3, len(columns), fmt.Sprintf("%v", columns))
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "feed_key",
+		OrdinalPosition:        1,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[0])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "samples",
+		OrdinalPosition:        2,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "text",
+		CharacterMaximumLength: nil,
+	}, &columns[1])
+	database.AssertTableColumnsEqual(t, &database.TableColumn{
+		ColumnName:             "updated_at_utc",
+		OrdinalPosition:        3,
+		ColumnDefault:          nil,
+		IsNullable:             "NO",
+		DataType:               "timestamp without time zone",
+		CharacterMaximumLength: nil,
+	}, &columns[2])
+	// check indexes of price_feed_state table
+	indexes = database.GetTableIndexes(db, "price_feed_state")
+	assert.Equal(t, 1, len(indexes))
+	database.AssertIndex(t, "price_feed_state", "price_feed_state_pkey", "CREATE UNIQUE INDEX price_feed_state_pkey ON public.price_feed_state USING btree (feed_key)", indexes)
+
 	// check entries of db_version table
 	var allRows [][]interface{}
 	allRows = database.QueryAllRows(db, "db_version")
-	assert.Equal(t, 6, len(allRows))
+	assert.Equal(t, 8, len(allRows))
 	// first three code_version_string is nil becuase the field was not supported at the time when the upgrade script was run, and only in version 4 of
 	// the database do we add the field. See upgradeScripts and RunUpgradeScripts() for more details
 	database.ValidateDBVersionRow(t, allRows[0], 1, time.Now(), 1, 50, nil)
@@ -268,6 +366,8 @@ func TestTradeUpgradeScripts(t *testing.T) {
 	database.ValidateDBVersionRow(t, allRows[3], 4, time.Now(), 1, 50, &codeVersionString)
 	database.ValidateDBVersionRow(t, allRows[4], 5, time.Now(), 2, 100, &codeVersionString)
 	database.ValidateDBVersionRow(t, allRows[5], 6, time.Now(), 2, 100, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[6], 7, time.Now(), 1, 50, &codeVersionString)
+	database.ValidateDBVersionRow(t, allRows[7], 8, time.Now(), 1, 50, &codeVersionString)
 
 	// check entries of markets table
 	allRows = database.QueryAllRows(db, "markets")
@@ -280,4 +380,12 @@ func TestTradeUpgradeScripts(t *testing.T) {
 	// check entries of strategy_mirror_trade_triggers table
 	allRows = database.QueryAllRows(db, "strategy_mirror_trade_triggers")
 	assert.Equal(t, 0, len(allRows))
+
+	// check entries of strategy_grid_state table
+	allRows = database.QueryAllRows(db, "strategy_grid_state")
+	assert.Equal(t, 0, len(allRows))
+
+	// check entries of price_feed_state table
+	allRows = database.QueryAllRows(db, "price_feed_state")
+	assert.Equal(t, 0, len(allRows))
 }
@@ -8,6 +8,8 @@ const SqlTradesTableCreate = "CREATE TABLE IF NOT EXISTS trades (market_id TEXT
 const SqlTradesTableAlter1 = "ALTER TABLE trades ADD COLUMN account_id TEXT"
 const SqlStrategyMirrorTradeTriggersTableCreate = "CREATE TABLE IF NOT EXISTS strategy_mirror_trade_triggers (market_id TEXT NOT NULL, txid TEXT NOT NULL, backing_market_id TEXT NOT NULL, backing_order_id TEXT NOT NULL, PRIMARY KEY (market_id, txid))"
 const SqlTradesTableAlter2 = "ALTER TABLE trades ADD COLUMN order_id TEXT"
+const SqlStrategyGridStateTableCreate = "CREATE TABLE IF NOT EXISTS strategy_grid_state (market_id TEXT PRIMARY KEY, min_price DOUBLE PRECISION NOT NULL, max_price DOUBLE PRECISION NOT NULL, num_levels INTEGER NOT NULL, spacing TEXT NOT NULL, anchor_index INTEGER NOT NULL, updated_at_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL)"
+const SqlPriceFeedStateTableCreate = "CREATE TABLE IF NOT EXISTS price_feed_state (feed_key TEXT PRIMARY KEY, samples TEXT NOT NULL, updated_at_utc TIMESTAMP WITHOUT TIME ZONE NOT NULL)"
 
 /*
 	indexes
@@ -33,8 +35,17 @@ const SqlTradesInsertTemplate = "INSERT INTO trades (market_id, txid, date_utc,
 // SqlStrategyMirrorTradeTriggersInsertTemplate inserts into the strategy_mirror_trade_triggers table
 const SqlStrategyMirrorTradeTriggersInsertTemplate = "INSERT INTO strategy_mirror_trade_triggers (market_id, txid, backing_market_id, backing_order_id) VALUES ('%s', '%s', '%s', '%s')"
 
+// SqlStrategyGridStateUpsert inserts into the strategy_grid_state table, replacing any existing state for the market
+const SqlStrategyGridStateUpsert = "INSERT INTO strategy_grid_state (market_id, min_price, max_price, num_levels, spacing, anchor_index, updated_at_utc) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (market_id) DO UPDATE SET min_price = EXCLUDED.min_price, max_price = EXCLUDED.max_price, num_levels = EXCLUDED.num_levels, spacing = EXCLUDED.spacing, anchor_index = EXCLUDED.anchor_index, updated_at_utc = EXCLUDED.updated_at_utc"
+
+// SqlPriceFeedStateUpsertTemplate inserts into the price_feed_state table, replacing any existing samples for the feed
+const SqlPriceFeedStateUpsertTemplate = "INSERT INTO price_feed_state (feed_key, samples, updated_at_utc) VALUES ('%s', '%s', '%s') ON CONFLICT (feed_key) DO UPDATE SET samples = EXCLUDED.samples, updated_at_utc = EXCLUDED.updated_at_utc"
+
 /*
 	queries
 */
 // SqlQueryMarketsById queries the markets table
 const SqlQueryMarketsById = "SELECT market_id, exchange_name, base, quote FROM markets WHERE market_id = $1 LIMIT 1"
+
+// SqlQueryTradesForMarketInRange queries the trades table for all trades of a market within a time range, ordered by time
+const SqlQueryTradesForMarketInRange = "SELECT date_utc, counter_price, base_volume FROM trades WHERE market_id = $1 AND date_utc >= $2 AND date_utc < $3 ORDER BY date_utc ASC, txid ASC"
@@ -0,0 +1,514 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/stellargohorizonclientv300/build"
+	"github.com/stellar/kelp/support/toml"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// arbitrageConfig contains the configuration params for this strategy
+type arbitrageConfig struct {
+	Exchange                                  string                   `valid:"-" toml:"EXCHANGE"` // "kraken" or any "ccxt-*" exchange
+	ExchangeBase                              string                   `valid:"-" toml:"EXCHANGE_BASE"`
+	ExchangeQuote                             string                   `valid:"-" toml:"EXCHANGE_QUOTE"`
+	OrderbookDepth                            int                      `valid:"-" toml:"ORDERBOOK_DEPTH"`
+	MinNetSpread                              float64                  `valid:"-" toml:"MIN_NET_SPREAD"`     // min spread after fees needed to take an opportunity, 0.002 = 0.2%
+	PrimaryFee                                float64                  `valid:"-" toml:"PRIMARY_FEE"`        // fee charged on SDEX trades as a fraction of the trade value
+	BackingFee                                float64                  `valid:"-" toml:"BACKING_FEE"`        // fee charged on backing exchange trades as a fraction of the trade value
+	MaxHedgeSlippage                          float64                  `valid:"-" toml:"MAX_HEDGE_SLIPPAGE"` // how much worse than break-even the hedge price can be, 0.005 = 0.5%
+	MaxTradeBaseCap                           *float64                 `valid:"-" toml:"MAX_TRADE_BASE_CAP"` // use a pointer here so a nil value is clearly not user-entered
+	MinBaseVolumeOverride                     *float64                 `valid:"-" toml:"MIN_BASE_VOLUME_OVERRIDE"`
+	BackingDbOverrideAccountID                string                   `valid:"-" toml:"BACKING_DB_OVERRIDE__ACCOUNT_ID"`
+	BackingFillTrackerLastTradeCursorOverride string                   `valid:"-" toml:"BACKING_FILL_TRACKER_LAST_TRADE_CURSOR_OVERRIDE"`
+	ExchangeAPIKeys                           toml.ExchangeAPIKeysToml `valid:"-" toml:"EXCHANGE_API_KEYS"`
+	ExchangeParams                            toml.ExchangeParamsToml  `valid:"-" toml:"EXCHANGE_PARAMS"`
+	ExchangeHeaders                           toml.ExchangeHeadersToml `valid:"-" toml:"EXCHANGE_HEADERS"`
+}
+
+// String impl.
+func (c arbitrageConfig) String() string {
+	return utils.StructString(c, 0, map[string]func(interface{}) interface{}{
+		"EXCHANGE_API_KEYS": utils.Hide,
+		"EXCHANGE_PARAMS":   utils.Hide,
+		"EXCHANGE_HEADERS":  utils.Hide,
+	})
+}
+
+// arbitrageOpportunity is a crossed market between the primary and backing exchanges, the volume is in units of the base asset
+type arbitrageOpportunity struct {
+	primaryAction model.OrderAction // action we take on the primary exchange, we hedge with the reverse action on the backing exchange
+	takePrice     float64           // worst price on the primary exchange that we need to cross to take the full volume
+	hedgePrice    float64           // worst price on the backing exchange that we need to cross to hedge the full volume
+	volume        float64
+	netSpread     float64 // net spread after fees at the worst level that we take
+}
+
+// String impl.
+func (o arbitrageOpportunity) String() string {
+	return fmt.Sprintf("arbitrageOpportunity[primaryAction=%s, takePrice=%.8f, hedgePrice=%.8f, volume=%.8f, netSpread=%.8f]",
+		o.primaryAction, o.takePrice, o.hedgePrice, o.volume, o.netSpread)
+}
+
+// netArbitrageSpread is the return from selling at sellPrice and buying at buyPrice after paying the fees on both trades
+func netArbitrageSpread(sellPrice float64, sellFee float64, buyPrice float64, buyFee float64) float64 {
+	effectiveBuyPrice := buyPrice * (1 + buyFee)
+	return (sellPrice*(1-sellFee) - effectiveBuyPrice) / effectiveBuyPrice
+}
+
+// computeArbitrageOpportunity walks the orders that we would take on the primary exchange against the orders that we would hedge against on the
+// backing exchange for as long as the net spread stays above minNetSpread. When isPrimaryBuy is true primaryOrders are the asks on the primary
+// exchange and backingOrders are the bids on the backing exchange, otherwise primaryOrders are the bids and backingOrders are the asks.
+// Returns nil when there is no opportunity.
+func computeArbitrageOpportunity(
+	primaryOrders []model.Order,
+	backingOrders []model.Order,
+	isPrimaryBuy bool,
+	primaryFee float64,
+	backingFee float64,
+	minNetSpread float64,
+	maybeMaxVolume *float64,
+) *arbitrageOpportunity {
+	var opportunity *arbitrageOpportunity
+	totalVolume, primaryRemaining, backingRemaining := 0.0, 0.0, 0.0
+	i, j := 0, 0
+	for i < len(primaryOrders) && j < len(backingOrders) {
+		if primaryRemaining == 0 {
+			primaryRemaining = primaryOrders[i].Volume.AsFloat()
+		}
+		if backingRemaining == 0 {
+			backingRemaining = backingOrders[j].Volume.AsFloat()
+		}
+		primaryPrice := primaryOrders[i].Price.AsFloat()
+		backingPrice := backingOrders[j].Price.AsFloat()
+
+		var netSpread float64
+		if isPrimaryBuy {
+			netSpread = netArbitrageSpread(backingPrice, backingFee, primaryPrice, primaryFee)
+		} else {
+			netSpread = netArbitrageSpread(primaryPrice, primaryFee, backingPrice, backingFee)
+		}
+		if netSpread < minNetSpread {
+			break
+		}
+
+		volume := math.Min(primaryRemaining, backingRemaining)
+		if maybeMaxVolume != nil {
+			volume = math.Min(volume, *maybeMaxVolume-totalVolume)
+		}
+		if volume <= 0 {
+			break
+		}
+
+		if opportunity == nil {
+			opportunity = &arbitrageOpportunity{primaryAction: model.OrderActionSell}
+			if isPrimaryBuy {
+				opportunity.primaryAction = model.OrderActionBuy
+			}
+		}
+		opportunity.takePrice = primaryPrice
+		opportunity.hedgePrice = backingPrice
+		totalVolume += volume
+		opportunity.volume = totalVolume
+		opportunity.netSpread = netSpread
+
+		primaryRemaining -= volume
+		backingRemaining -= volume
+		if primaryRemaining <= 0 {
+			primaryRemaining = 0
+			i++
+		}
+		if backingRemaining <= 0 {
+			backingRemaining = 0
+			j++
+		}
+	}
+	return opportunity
+}
+
+// arbitrageHedgePrice is the limit price for the hedge on the backing exchange, it is the price at which the round trip breaks even after fees
+// moved against us by maxSlippage. A hedge at the break-even price never locks in a loss but does not fill once the backing orderbook has
+// moved past it since we took the opportunity on the primary exchange, which leaves the position unhedged, so the slippage trades a small
+// loss on the round trip for a hedge that still fills after a small move.
+func arbitrageHedgePrice(primaryAction model.OrderAction, primaryPrice float64, primaryFee float64, backingFee float64, maxSlippage float64) float64 {
+	if primaryAction.IsBuy() {
+		// we bought on the primary exchange so we sell on the backing exchange
+		return primaryPrice * (1 + primaryFee) / (1 - backingFee) * (1 - maxSlippage)
+	}
+	// we sold on the primary exchange so we buy on the backing exchange
+	return primaryPrice * (1 - primaryFee) / (1 + backingFee) * (1 + maxSlippage)
+}
+
+// arbitrageStrategy takes crossed prices on SDEX and hedges the resulting fills on a centralized exchange
+type arbitrageStrategy struct {
+	sdex                 *SDEX
+	ieif                 *IEIF
+	pair                 *model.TradingPair
+	baseAsset            *hProtocol.Asset
+	quoteAsset           *hProtocol.Asset
+	primaryConstraints   *model.OrderConstraints
+	backingPair          *model.TradingPair
+	backingConstraints   *model.OrderConstraints
+	backingFillTracker   api.FillTracker
+	tradeTriggers        *tradeTriggers
+	orderbookDepth       int
+	minNetSpread         float64
+	primaryFee           float64
+	backingFee           float64
+	maxHedgeSlippage     float64
+	maybeMaxTradeBaseCap *float64 // using a nil value makes it clear whether this value exists or not
+	exchange             api.Exchange
+	mutex                *sync.Mutex
+	baseSurplus          map[model.OrderAction]*model.Number // baseSurplus keeps track of the base asset that is pending to be hedged on the backing exchange
+
+	// uninitialized
+	sellOnPrimaryBalanceCoordinator *balanceCoordinator
+	buyOnPrimaryBalanceCoordinator  *balanceCoordinator
+}
+
+// ensure this implements api.Strategy
+var _ api.Strategy = &arbitrageStrategy{}
+
+// ensure this implements api.FillHandler
+var _ api.FillHandler = &arbitrageStrategy{}
+
+// makeArbitrageStrategy is a factory method
+func makeArbitrageStrategy(
+	sdex *SDEX,
+	ieif *IEIF,
+	pair *model.TradingPair,
+	baseAsset *hProtocol.Asset,
+	quoteAsset *hProtocol.Asset,
+	marketID string,
+	config *arbitrageConfig,
+	db *sql.DB,
+	simMode bool,
+) (api.Strategy, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the arbitrage strategy needs the POSTGRES_DB config to be set in the trader.cfg file so it can record the hedged trades")
+		return nil, fmt.Errorf("db should not be nil for the arbitrage strategy")
+	}
+	if config.Exchange != "kraken" && !strings.HasPrefix(config.Exchange, "ccxt-") {
+		return nil, fmt.Errorf("invalid arbitrage strategy config file, EXCHANGE needs to be 'kraken' or a 'ccxt-*' exchange, was '%s'", config.Exchange)
+	}
+	if config.OrderbookDepth <= 0 || config.OrderbookDepth > int(maxOrderbookDepth) {
+		return nil, fmt.Errorf("invalid arbitrage strategy config file, ORDERBOOK_DEPTH needs to be between 1 and %d, was %d", maxOrderbookDepth, config.OrderbookDepth)
+	}
+	if config.MinNetSpread < 0 {
+		return nil, fmt.Errorf("invalid arbitrage strategy config file, MIN_NET_SPREAD cannot be negative, was %f", config.MinNetSpread)
+	}
+	if config.PrimaryFee < 0 || config.PrimaryFee >= 1 || config.BackingFee < 0 || config.BackingFee >= 1 {
+		return nil, fmt.Errorf("invalid arbitrage strategy config file, PRIMARY_FEE (%f) and BACKING_FEE (%f) need to be in the range [0, 1)", config.PrimaryFee, config.BackingFee)
+	}
+	if config.MaxHedgeSlippage < 0 || config.MaxHedgeSlippage >= 1 {
+		return nil, fmt.Errorf("invalid arbitrage strategy config file, MAX_HEDGE_SLIPPAGE needs to be in the range [0, 1), was %f", config.MaxHedgeSlippage)
+	}
+	if config.MinBaseVolumeOverride != nil && *config.MinBaseVolumeOverride <= 0.0 {
+		return nil, fmt.Errorf("need to specify positive MIN_BASE_VOLUME_OVERRIDE config param in arbitrage strategy config file")
+	}
+	if config.BackingDbOverrideAccountID == "" {
+		utils.PrintErrorHintf("BACKING_DB_OVERRIDE__ACCOUNT_ID needs to be set in the arbitrage strategy config file so we can assign an account_id to trades that are fetched from the backing exchange before writing them in the db")
+		return nil, fmt.Errorf("invalid arbitrage strategy config file, need to set BACKING_DB_OVERRIDE__ACCOUNT_ID")
+	}
+
+	exchangeAPIKeys := config.ExchangeAPIKeys.ToExchangeAPIKeys()
+	exchangeParams := config.ExchangeParams.ToExchangeParams()
+	exchangeHeaders := config.ExchangeHeaders.ToExchangeHeaders()
+	exchange, e := MakeTradingExchange(config.Exchange, exchangeAPIKeys, exchangeParams, exchangeHeaders, simMode)
+	if e != nil {
+		return nil, e
+	}
+
+	primaryConstraints := sdex.GetOrderConstraints(pair)
+	// backingPair is taken from the arbitrage strategy config not from the passed in trading pair
+	backingPair := &model.TradingPair{
+		Base:  exchange.GetAssetConverter().MustFromString(config.ExchangeBase),
+		Quote: exchange.GetAssetConverter().MustFromString(config.ExchangeQuote),
+	}
+	if config.MinBaseVolumeOverride != nil {
+		exchange.OverrideOrderConstraints(backingPair, model.MakeOrderConstraintsOverride(
+			nil,
+			nil,
+			model.NumberFromFloat(*config.MinBaseVolumeOverride, exchange.GetOrderConstraints(backingPair).VolumePrecision),
+			nil,
+		))
+	}
+	backingConstraints := exchange.GetOrderConstraints(backingPair)
+	log.Printf("primaryPair='%s', primaryConstraints=%s\n", pair, primaryConstraints)
+	log.Printf("backingPair='%s', backingConstraints=%s\n", backingPair, backingConstraints)
+	if config.MaxTradeBaseCap != nil && *config.MaxTradeBaseCap < backingConstraints.MinBaseVolume.AsFloat() {
+		utils.PrintErrorHintf("MAX_TRADE_BASE_CAP (%f) cannot be less than minBaseVolume allowed on backing exchange (%s)", *config.MaxTradeBaseCap, backingConstraints.MinBaseVolume.AsString())
+		return nil, fmt.Errorf("MAX_TRADE_BASE_CAP (%f) cannot be less than minBaseVolume allowed on backing exchange (%s)", *config.MaxTradeBaseCap, backingConstraints.MinBaseVolume.AsString())
+	}
+
+	backingMarketID, e := FetchOrRegisterMarketID(db, config.Exchange, config.ExchangeBase, config.ExchangeQuote)
+	if e != nil {
+		return nil, fmt.Errorf("error calling FetchOrRegisterMarketID: %s", e)
+	}
+	triggers, e := makeTradeTriggers(db, marketID, backingMarketID)
+	if e != nil {
+		return nil, e
+	}
+
+	// make fill tracker for backing exchange so the hedges are written to the db
+	backingFillTracker, e := makeBackingFillTracker(
+		"arbitrage",
+		exchange,
+		config.Exchange,
+		backingPair,
+		config.BackingFillTrackerLastTradeCursorOverride,
+		db,
+		config.BackingDbOverrideAccountID,
+	)
+	if e != nil {
+		return nil, e
+	}
+	e = trackBackingFills(backingFillTracker, "factory method")
+	if e != nil {
+		return nil, e
+	}
+
+	return &arbitrageStrategy{
+		sdex:                 sdex,
+		ieif:                 ieif,
+		pair:                 pair,
+		baseAsset:            baseAsset,
+		quoteAsset:           quoteAsset,
+		primaryConstraints:   primaryConstraints,
+		backingPair:          backingPair,
+		backingConstraints:   backingConstraints,
+		backingFillTracker:   backingFillTracker,
+		tradeTriggers:        triggers,
+		orderbookDepth:       config.OrderbookDepth,
+		minNetSpread:         config.MinNetSpread,
+		primaryFee:           config.PrimaryFee,
+		backingFee:           config.BackingFee,
+		maxHedgeSlippage:     config.MaxHedgeSlippage,
+		maybeMaxTradeBaseCap: config.MaxTradeBaseCap,
+		exchange:             exchange,
+		mutex:                &sync.Mutex{},
+		baseSurplus: map[model.OrderAction]*model.Number{
+			model.OrderActionBuy:  model.NumberConstants.Zero,
+			model.OrderActionSell: model.NumberConstants.Zero,
+		},
+	}, nil
+}
+
+// PruneExistingOffers deletes any offers left over from the previous update, we only ever want to take liquidity so nothing should rest on the book
+func (s *arbitrageStrategy) PruneExistingOffers(buyingAOffers []hProtocol.Offer, sellingAOffers []hProtocol.Offer) ([]build.TransactionMutator, []hProtocol.Offer, []hProtocol.Offer) {
+	pruneOps := []txnbuild.Operation{}
+	for _, offers := range [][]hProtocol.Offer{buyingAOffers, sellingAOffers} {
+		for i := 0; i < len(offers); i++ {
+			pOp := s.sdex.DeleteOffer(offers[i])
+			pruneOps = append(pruneOps, &pOp)
+		}
+	}
+	if len(pruneOps) > 0 {
+		log.Printf("arbitrageStrategy: deleting %d leftover offers\n", len(pruneOps))
+	}
+	return api.ConvertOperation2TM(pruneOps), []hProtocol.Offer{}, []hProtocol.Offer{}
+}
+
+// PreUpdate changes the strategy's state in prepration for the update
+func (s *arbitrageStrategy) PreUpdate(maxAssetA float64, maxAssetB float64, trustA float64, trustB float64) error {
+	balanceMap, e := s.exchange.GetAccountBalances([]interface{}{s.backingPair.Base, s.backingPair.Quote})
+	if e != nil {
+		return fmt.Errorf("unable to fetch balances from backing exchange: %s", e)
+	}
+	baseBackingBalance, ok := balanceMap[s.backingPair.Base]
+	if !ok {
+		return fmt.Errorf("unable to fetch balance for base asset: %s", string(s.backingPair.Base))
+	}
+	quoteBackingBalance, ok := balanceMap[s.backingPair.Quote]
+	if !ok {
+		return fmt.Errorf("unable to fetch balance for quote asset: %s", string(s.backingPair.Quote))
+	}
+
+	// buying on the primary exchange sells quote there and sells base on the backing exchange
+	s.buyOnPrimaryBalanceCoordinator = &balanceCoordinator{
+		primaryBalance:     model.NumberFromFloat(maxAssetB, s.primaryConstraints.VolumePrecision),
+		placedPrimaryUnits: model.NumberConstants.Zero,
+		primaryAssetType:   "quote",
+		isPrimaryBuy:       true,
+		backingBalance:     &baseBackingBalance,
+		placedBackingUnits: model.NumberConstants.Zero,
+		backingAssetType:   "base",
+	}
+
+	// selling on the primary exchange sells base there and sells quote on the backing exchange
+	s.sellOnPrimaryBalanceCoordinator = &balanceCoordinator{
+		primaryBalance:     model.NumberFromFloat(maxAssetA, s.primaryConstraints.VolumePrecision),
+		placedPrimaryUnits: model.NumberConstants.Zero,
+		primaryAssetType:   "base",
+		isPrimaryBuy:       false,
+		backingBalance:     &quoteBackingBalance,
+		placedBackingUnits: model.NumberConstants.Zero,
+		backingAssetType:   "quote",
+	}
+	return nil
+}
+
+// UpdateWithOps builds the operations we want performed on the account
+func (s *arbitrageStrategy) UpdateWithOps(
+	buyingAOffers []hProtocol.Offer,
+	sellingAOffers []hProtocol.Offer,
+) ([]build.TransactionMutator, error) {
+	primaryOB, e := s.sdex.GetOrderBook(s.pair, int32(s.orderbookDepth))
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch primary orderbook: %s", e)
+	}
+	backingOB, e := s.exchange.GetOrderBook(s.backingPair, int32(s.orderbookDepth))
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch backing orderbook: %s", e)
+	}
+
+	ops := []txnbuild.Operation{}
+	// sell into the bids on SDEX and hedge by buying the asks on the backing exchange
+	op, e := s.takeOpportunity(
+		computeArbitrageOpportunity(primaryOB.Bids(), backingOB.Asks(), false, s.primaryFee, s.backingFee, s.minNetSpread, s.maybeMaxTradeBaseCap),
+		s.sellOnPrimaryBalanceCoordinator,
+	)
+	if e != nil {
+		return nil, e
+	}
+	if op != nil {
+		ops = append(ops, op)
+	}
+
+	// buy the asks on SDEX and hedge by selling into the bids on the backing exchange
+	op, e = s.takeOpportunity(
+		computeArbitrageOpportunity(primaryOB.Asks(), backingOB.Bids(), true, s.primaryFee, s.backingFee, s.minNetSpread, s.maybeMaxTradeBaseCap),
+		s.buyOnPrimaryBalanceCoordinator,
+	)
+	if e != nil {
+		return nil, e
+	}
+	if op != nil {
+		ops = append(ops, op)
+	}
+
+	log.Printf("arbitrageStrategy: num. ops in this update: %d\n", len(ops))
+	return api.ConvertOperation2TM(ops), nil
+}
+
+// takeOpportunity creates an offer on SDEX that crosses the book up to the takePrice, the fill handler hedges the resulting fills
+func (s *arbitrageStrategy) takeOpportunity(opportunity *arbitrageOpportunity, bc *balanceCoordinator) (txnbuild.Operation, error) {
+	if opportunity == nil {
+		return nil, nil
+	}
+	log.Printf("arbitrageStrategy: found %s\n", opportunity)
+
+	price := model.NumberFromFloat(opportunity.takePrice, s.primaryConstraints.PricePrecision)
+	volume := model.NumberFromFloat(opportunity.volume, s.primaryConstraints.VolumePrecision)
+	hasBalance, newBaseVolume, _ := bc.checkBalance(volume, price)
+	if !hasBalance {
+		return nil, nil
+	}
+	volume = model.NumberByCappingPrecision(newBaseVolume, s.backingConstraints.VolumePrecision)
+	if volume.AsFloat() < s.backingConstraints.MinBaseVolume.AsFloat() {
+		log.Printf("arbitrageStrategy: skip opportunity, baseVolume (%s) < minBaseVolume (%s) of backing exchange\n", volume.AsString(), s.backingConstraints.MinBaseVolume.AsString())
+		return nil, nil
+	}
+
+	incrementalNativeAmountRaw := s.sdex.ComputeIncrementalNativeAmountRaw(true)
+	var mo *txnbuild.ManageSellOffer
+	var e error
+	if opportunity.primaryAction.IsBuy() {
+		mo, e = s.sdex.CreateBuyOffer(*s.baseAsset, *s.quoteAsset, price.AsFloat(), volume.AsFloat(), incrementalNativeAmountRaw)
+	} else {
+		mo, e = s.sdex.CreateSellOffer(*s.baseAsset, *s.quoteAsset, price.AsFloat(), volume.AsFloat(), incrementalNativeAmountRaw)
+	}
+	if e != nil {
+		return nil, fmt.Errorf("unable to create offer to take %s: %s", opportunity, e)
+	}
+	if mo == nil {
+		return nil, nil
+	}
+
+	// update the cached liabilities since we created a valid operation to create an offer
+	if opportunity.primaryAction.IsBuy() {
+		s.ieif.AddLiabilities(*s.quoteAsset, *s.baseAsset, volume.Multiply(*price).AsFloat(), volume.AsFloat(), incrementalNativeAmountRaw)
+	} else {
+		s.ieif.AddLiabilities(*s.baseAsset, *s.quoteAsset, volume.AsFloat(), volume.Multiply(*price).AsFloat(), incrementalNativeAmountRaw)
+	}
+	log.Printf("arbitrageStrategy: taking %s units of base at price %s (action=%s)\n", volume.AsString(), price.AsString(), opportunity.primaryAction)
+	return mo, nil
+}
+
+// PostUpdate changes the strategy's state after the update has taken place
+func (s *arbitrageStrategy) PostUpdate() error {
+	return nil
+}
+
+// GetFillHandlers impl
+func (s *arbitrageStrategy) GetFillHandlers() ([]api.FillHandler, error) {
+	return []api.FillHandler{s}, nil
+}
+
+// HandleFill hedges a fill on SDEX with the reverse order on the backing exchange
+func (s *arbitrageStrategy) HandleFill(trade model.Trade) error {
+	// we should only ever have one active fill handler to avoid inconsistent R/W on baseSurplus
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// first check if this trade has already been hedged
+	rowExists, e := s.tradeTriggers.exists(trade.TransactionID.String())
+	if e != nil {
+		return e
+	}
+	if rowExists {
+		log.Printf("trade with txid '%s' was previously hedged, not handling again and returning\n", trade.TransactionID.String())
+		return nil
+	}
+
+	newOrderAction := trade.OrderAction.Reverse()
+	s.baseSurplus[newOrderAction] = s.baseSurplus[newOrderAction].Add(*trade.Volume)
+	if s.baseSurplus[newOrderAction].AsFloat() < s.backingConstraints.MinBaseVolume.AsFloat() {
+		log.Printf("hedge-skip | tradeID=%s | tradeBaseAmt=%f | tradePriceQuote=%f | newOrderAction=%s | baseSurplus=%f | minBaseVolume=%f\n",
+			trade.TransactionID.String(),
+			trade.Volume.AsFloat(),
+			trade.Price.AsFloat(),
+			newOrderAction.String(),
+			s.baseSurplus[newOrderAction].AsFloat(),
+			s.backingConstraints.MinBaseVolume.AsFloat())
+		return nil
+	}
+
+	hedgePrice := arbitrageHedgePrice(trade.OrderAction, trade.Price.AsFloat(), s.primaryFee, s.backingFee, s.maxHedgeSlippage)
+	newOrder := model.Order{
+		Pair:        s.backingPair,
+		OrderAction: newOrderAction,
+		OrderType:   model.OrderTypeLimit,
+		Price:       model.NumberFromFloat(hedgePrice, s.backingConstraints.PricePrecision),
+		Volume:      model.NumberByCappingPrecision(s.baseSurplus[newOrderAction], s.backingConstraints.VolumePrecision),
+		Timestamp:   nil,
+	}
+	transactionID, e := s.tradeTriggers.placeBackingOrder(s.exchange, &newOrder, trade.TransactionID.String())
+	if e != nil {
+		return fmt.Errorf("error when hedging trade: %s", e)
+	}
+	s.baseSurplus[newOrderAction] = s.baseSurplus[newOrderAction].Subtract(*newOrder.Volume)
+
+	log.Printf("hedge-success | tradeID=%s | tradeBaseAmt=%f | tradePriceQuote=%f | newOrderAction=%s | newOrderBaseAmt=%f | newOrderPriceQuote=%f | baseSurplus=%f | transactionID=%s\n",
+		trade.TransactionID.String(),
+		trade.Volume.AsFloat(),
+		trade.Price.AsFloat(),
+		newOrderAction.String(),
+		newOrder.Volume.AsFloat(),
+		newOrder.Price.AsFloat(),
+		s.baseSurplus[newOrderAction].AsFloat(),
+		transactionID)
+
+	// trigger fill tracking on backing exchange so the hedge is recorded in the db
+	return trackBackingFills(s.backingFillTracker, "HandleFill")
+}
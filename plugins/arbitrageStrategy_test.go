@@ -0,0 +1,134 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func makeArbitrageTestOrders(priceVolumes ...float64) []model.Order {
+	orders := []model.Order{}
+	for i := 0; i+1 < len(priceVolumes); i += 2 {
+		orders = append(orders, model.Order{
+			Price:  model.NumberFromFloat(priceVolumes[i], 7),
+			Volume: model.NumberFromFloat(priceVolumes[i+1], 7),
+		})
+	}
+	return orders
+}
+
+func TestComputeArbitrageOpportunity(t *testing.T) {
+	maxVolume := 15.0
+	testCases := []struct {
+		name            string
+		primaryOrders   []model.Order
+		backingOrders   []model.Order
+		isPrimaryBuy    bool
+		fee             float64
+		maybeMaxVolume  *float64
+		wantOpportunity *arbitrageOpportunity
+	}{
+		{
+			name:            "books not crossed",
+			primaryOrders:   makeArbitrageTestOrders(1.0, 10),
+			backingOrders:   makeArbitrageTestOrders(1.01, 10),
+			isPrimaryBuy:    false,
+			wantOpportunity: nil,
+		}, {
+			name:            "crossed but fees eat the spread",
+			primaryOrders:   makeArbitrageTestOrders(1.01, 10),
+			backingOrders:   makeArbitrageTestOrders(1.0, 10),
+			isPrimaryBuy:    false,
+			fee:             0.01,
+			wantOpportunity: nil,
+		}, {
+			name:          "sell on primary walks levels until the spread closes",
+			primaryOrders: makeArbitrageTestOrders(1.10, 5, 1.05, 10, 1.0, 10),
+			backingOrders: makeArbitrageTestOrders(1.0, 8, 1.02, 20),
+			isPrimaryBuy:  false,
+			wantOpportunity: &arbitrageOpportunity{
+				primaryAction: model.OrderActionSell,
+				takePrice:     1.05,
+				hedgePrice:    1.02,
+				volume:        15,
+				netSpread:     (1.05 - 1.02) / 1.02,
+			},
+		}, {
+			name:           "sell on primary capped by max volume",
+			primaryOrders:  makeArbitrageTestOrders(1.10, 5, 1.05, 10, 1.04, 10),
+			backingOrders:  makeArbitrageTestOrders(1.0, 8, 1.02, 20),
+			isPrimaryBuy:   false,
+			maybeMaxVolume: &maxVolume,
+			wantOpportunity: &arbitrageOpportunity{
+				primaryAction: model.OrderActionSell,
+				takePrice:     1.05,
+				hedgePrice:    1.02,
+				volume:        15,
+				netSpread:     (1.05 - 1.02) / 1.02,
+			},
+		}, {
+			name:          "buy on primary",
+			primaryOrders: makeArbitrageTestOrders(0.90, 4, 0.95, 10),
+			backingOrders: makeArbitrageTestOrders(1.0, 10, 0.96, 10),
+			isPrimaryBuy:  true,
+			wantOpportunity: &arbitrageOpportunity{
+				primaryAction: model.OrderActionBuy,
+				takePrice:     0.95,
+				hedgePrice:    0.96,
+				volume:        14,
+				netSpread:     (0.96 - 0.95) / 0.95,
+			},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			opportunity := computeArbitrageOpportunity(k.primaryOrders, k.backingOrders, k.isPrimaryBuy, k.fee, k.fee, 0.001, k.maybeMaxVolume)
+			if k.wantOpportunity == nil {
+				assert.Nil(t, opportunity)
+				return
+			}
+			if !assert.NotNil(t, opportunity) {
+				return
+			}
+			assert.Equal(t, k.wantOpportunity.primaryAction, opportunity.primaryAction)
+			assert.InDelta(t, k.wantOpportunity.takePrice, opportunity.takePrice, 0.0000001)
+			assert.InDelta(t, k.wantOpportunity.hedgePrice, opportunity.hedgePrice, 0.0000001)
+			assert.InDelta(t, k.wantOpportunity.volume, opportunity.volume, 0.0000001)
+			assert.InDelta(t, k.wantOpportunity.netSpread, opportunity.netSpread, 0.0000001)
+		})
+	}
+}
+
+func TestArbitrageHedgePrice(t *testing.T) {
+	testCases := []struct {
+		primaryAction model.OrderAction
+		maxSlippage   float64
+		wantPrice     float64
+	}{
+		{primaryAction: model.OrderActionSell, maxSlippage: 0.0, wantPrice: 1.0 * 0.99 / 1.02},
+		{primaryAction: model.OrderActionBuy, maxSlippage: 0.0, wantPrice: 1.0 * 1.01 / 0.98},
+		{primaryAction: model.OrderActionSell, maxSlippage: 0.005, wantPrice: 1.0 * 0.99 / 1.02 * 1.005},
+		{primaryAction: model.OrderActionBuy, maxSlippage: 0.005, wantPrice: 1.0 * 1.01 / 0.98 * 0.995},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%s/%.3f", k.primaryAction.String(), k.maxSlippage), func(t *testing.T) {
+			price := arbitrageHedgePrice(k.primaryAction, 1.0, 0.01, 0.02, k.maxSlippage)
+			assert.InDelta(t, k.wantPrice, price, 0.0000001)
+			// the round trip at the hedge price should break even after fees when there is no slippage and lose money otherwise
+			netSpread := netArbitrageSpread(1.0, 0.01, price, 0.02)
+			if k.primaryAction.IsBuy() {
+				netSpread = netArbitrageSpread(price, 0.02, 1.0, 0.01)
+			}
+			if k.maxSlippage == 0 {
+				assert.InDelta(t, 0.0, netSpread, 0.0000001)
+			} else {
+				assert.True(t, netSpread < 0, "netSpread=%f", netSpread)
+			}
+		})
+	}
+}
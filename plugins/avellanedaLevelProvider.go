@@ -0,0 +1,257 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// avellanedaQuote is the output of the avellaneda model for a single update cycle
+type avellanedaQuote struct {
+	midPrice         float64
+	inventory        float64
+	variance         float64
+	reservationPrice float64
+	spread           float64
+	bid              float64
+	ask              float64
+}
+
+// String impl.
+func (q avellanedaQuote) String() string {
+	return fmt.Sprintf("avellanedaQuote[midPrice=%.8f, inventory=%.4f, variance=%.10f, reservationPrice=%.8f, spread=%.6f, bid=%.8f, ask=%.8f]",
+		q.midPrice, q.inventory, q.variance, q.reservationPrice, q.spread, q.bid, q.ask)
+}
+
+// avellanedaModel computes the reservation price and optimal spread following Avellaneda & Stoikov (2008), using relative units so
+// the parameters do not depend on the magnitude of the price. reservationPrice = midPrice * (1 - q * gamma * sigma^2 * T) and
+// spread = gamma * sigma^2 * T + (2 / gamma) * ln(1 + gamma / k), where q is the deviation of the base asset's share of the total value from the target share, sigma^2 is the variance of log returns
+// per second, T is the time horizon in seconds, gamma is the risk aversion and k is the order book depth factor.
+// A single model is shared by both sides of the strategy, the quote is computed once per update cycle by update and read by both sides.
+type avellanedaModel struct {
+	pf                   *api.FeedPair
+	ieif                 *IEIF
+	assetBase            hProtocol.Asset
+	assetQuote           hProtocol.Asset
+	riskAversion         float64
+	orderBookDepthFactor float64
+	timeHorizonSeconds   float64
+	targetBasePercent    float64
+	minSpread            float64
+	maxSpread            float64
+	volatility           *volatilityEstimator
+
+	// uninitialized
+	quote *avellanedaQuote
+}
+
+// makeAvellanedaModel is a factory method
+func makeAvellanedaModel(
+	pf *api.FeedPair,
+	ieif *IEIF,
+	assetBase hProtocol.Asset,
+	assetQuote hProtocol.Asset,
+	riskAversion float64,
+	orderBookDepthFactor float64,
+	timeHorizonSeconds float64,
+	targetBasePercent float64,
+	minSpread float64,
+	maxSpread float64,
+	volatility *volatilityEstimator,
+) (*avellanedaModel, error) {
+	if riskAversion <= 0 {
+		return nil, fmt.Errorf("riskAversion needs to be greater than 0, was %f", riskAversion)
+	}
+	if orderBookDepthFactor <= 0 {
+		return nil, fmt.Errorf("orderBookDepthFactor needs to be greater than 0, was %f", orderBookDepthFactor)
+	}
+	if timeHorizonSeconds <= 0 {
+		return nil, fmt.Errorf("timeHorizonSeconds needs to be greater than 0, was %f", timeHorizonSeconds)
+	}
+	if targetBasePercent < 0 || targetBasePercent > 1 {
+		return nil, fmt.Errorf("targetBasePercent needs to be between 0.0 and 1.0 (inclusive), was %f", targetBasePercent)
+	}
+	if minSpread < 0 {
+		return nil, fmt.Errorf("minSpread cannot be negative, was %f", minSpread)
+	}
+	if maxSpread != 0 && maxSpread < minSpread {
+		return nil, fmt.Errorf("maxSpread (%f) needs to be 0 (disabled) or greater than or equal to minSpread (%f)", maxSpread, minSpread)
+	}
+	if maxSpread >= 2 {
+		return nil, fmt.Errorf("maxSpread needs to be less than 2.0 so the bid price is positive, was %f", maxSpread)
+	}
+
+	return &avellanedaModel{
+		pf:                   pf,
+		ieif:                 ieif,
+		assetBase:            assetBase,
+		assetQuote:           assetQuote,
+		riskAversion:         riskAversion,
+		orderBookDepthFactor: orderBookDepthFactor,
+		timeHorizonSeconds:   timeHorizonSeconds,
+		targetBasePercent:    targetBasePercent,
+		minSpread:            minSpread,
+		maxSpread:            maxSpread,
+		volatility:           volatility,
+	}, nil
+}
+
+// update computes the quote for the current update cycle, it is called once per cycle before either side makes its levels
+func (m *avellanedaModel) update() error {
+	// clear the quote from the previous cycle so it is never used if this one fails
+	m.quote = nil
+	q, e := m.computeQuote()
+	if e != nil {
+		return e
+	}
+	m.quote = q
+	return nil
+}
+
+// computeQuote fetches the mid price, balances and volatility and runs the model for the current update cycle
+func (m *avellanedaModel) computeQuote() (*avellanedaQuote, error) {
+	midPrice, e := m.pf.GetFeedPairPrice()
+	if e != nil {
+		return nil, fmt.Errorf("mid price couldn't be loaded: %s", e)
+	}
+	if midPrice <= 0 {
+		return nil, fmt.Errorf("mid price needs to be positive, was %.10f", midPrice)
+	}
+
+	baseBalance, e := m.ieif.GetAssetBalance(m.assetBase)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch balance of base asset: %s", e)
+	}
+	quoteBalance, e := m.ieif.GetAssetBalance(m.assetQuote)
+	if e != nil {
+		return nil, fmt.Errorf("could not fetch balance of quote asset: %s", e)
+	}
+	inventory, e := computeInventorySkew(baseBalance.Balance, quoteBalance.Balance, midPrice, m.targetBasePercent)
+	if e != nil {
+		return nil, fmt.Errorf("could not compute inventory: %s", e)
+	}
+
+	variance, ok, e := m.volatility.variancePerSecond(midPrice)
+	if e != nil {
+		return nil, fmt.Errorf("could not estimate volatility: %s", e)
+	}
+	if !ok {
+		log.Printf("avellaneda: not enough price samples so using a variance of 0\n")
+	}
+
+	q := computeAvellanedaQuote(
+		midPrice,
+		inventory,
+		variance,
+		m.riskAversion,
+		m.orderBookDepthFactor,
+		m.timeHorizonSeconds,
+		m.minSpread,
+		m.maxSpread,
+	)
+	log.Printf("avellaneda: %s\n", q)
+	return q, nil
+}
+
+// computeAvellanedaQuote runs the model on the given inputs
+func computeAvellanedaQuote(
+	midPrice float64,
+	inventory float64,
+	variance float64,
+	riskAversion float64,
+	orderBookDepthFactor float64,
+	timeHorizonSeconds float64,
+	minSpread float64,
+	maxSpread float64,
+) *avellanedaQuote {
+	inventoryRisk := riskAversion * variance * timeHorizonSeconds
+	reservationPrice := midPrice * (1 - inventory*inventoryRisk)
+	spread := inventoryRisk + (2/riskAversion)*math.Log(1+riskAversion/orderBookDepthFactor)
+	spread = math.Max(spread, minSpread)
+	if maxSpread > 0 {
+		spread = math.Min(spread, maxSpread)
+	}
+
+	return &avellanedaQuote{
+		midPrice:         midPrice,
+		inventory:        inventory,
+		variance:         variance,
+		reservationPrice: reservationPrice,
+		spread:           spread,
+		bid:              reservationPrice * (1 - spread/2),
+		ask:              reservationPrice * (1 + spread/2),
+	}
+}
+
+// avellanedaLevelProvider provides levels on one side of the book around the price computed by the avellaneda model
+type avellanedaLevelProvider struct {
+	model            *avellanedaModel
+	amountOfBase     float64
+	numLevels        int16
+	levelSpacing     float64
+	isBuySide        bool
+	orderConstraints *model.OrderConstraints
+}
+
+// ensure it implements the LevelProvider interface
+var _ api.LevelProvider = &avellanedaLevelProvider{}
+
+// makeAvellanedaLevelProvider is a factory method
+func makeAvellanedaLevelProvider(
+	m *avellanedaModel,
+	amountOfBase float64,
+	numLevels int16,
+	levelSpacing float64,
+	isBuySide bool,
+	orderConstraints *model.OrderConstraints,
+) api.LevelProvider {
+	return &avellanedaLevelProvider{
+		model:            m,
+		amountOfBase:     amountOfBase,
+		numLevels:        numLevels,
+		levelSpacing:     levelSpacing,
+		isBuySide:        isBuySide,
+		orderConstraints: orderConstraints,
+	}
+}
+
+// GetLevels impl.
+func (p *avellanedaLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	if p.model.quote == nil {
+		return nil, fmt.Errorf("avellaneda quote was not computed for this update cycle")
+	}
+	return p.makeLevels(p.model.quote), nil
+}
+
+// makeLevels converts the quote into levels for this side, prices on the buy side are inverted since levels are always in terms of
+// the asset being sold and the amount is always specified in units of the base asset
+func (p *avellanedaLevelProvider) makeLevels(q *avellanedaQuote) []api.Level {
+	levels := []api.Level{}
+	for i := 0; i < int(p.numLevels); i++ {
+		levelOffset := float64(i) * p.levelSpacing
+		price := q.ask * (1 + levelOffset)
+		if p.isBuySide {
+			bidPrice := q.bid * (1 - levelOffset)
+			if bidPrice <= 0 {
+				log.Printf("avellaneda: stopping at level %d on the buy side because bid price is not positive (%.10f)\n", i, bidPrice)
+				break
+			}
+			price = 1 / bidPrice
+		}
+
+		levels = append(levels, api.Level{
+			Price:  *model.NumberFromFloat(price, p.orderConstraints.PricePrecision),
+			Amount: *model.NumberFromFloat(p.amountOfBase, p.orderConstraints.VolumePrecision),
+		})
+	}
+	return levels
+}
+
+// GetFillHandlers impl
+func (p *avellanedaLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	return nil, nil
+}
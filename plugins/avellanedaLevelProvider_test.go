@@ -0,0 +1,118 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestComputeAvellanedaQuote(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		inventory            float64
+		variance             float64
+		minSpread            float64
+		maxSpread            float64
+		wantReservationPrice float64
+		wantSpread           float64
+	}{
+		{
+			name:                 "no volatility",
+			inventory:            0.5,
+			variance:             0,
+			minSpread:            0,
+			maxSpread:            0,
+			wantReservationPrice: 1.0,
+			wantSpread:           20 * math.Log(1.0005),
+		}, {
+			name:                 "long inventory lowers reservation price",
+			inventory:            0.5,
+			variance:             0.0001,
+			minSpread:            0,
+			maxSpread:            0,
+			wantReservationPrice: 1.0 * (1 - 0.5*0.1*0.0001*100),
+			wantSpread:           0.1*0.0001*100 + 20*math.Log(1.0005),
+		}, {
+			name:                 "short inventory raises reservation price",
+			inventory:            -0.5,
+			variance:             0.0001,
+			minSpread:            0,
+			maxSpread:            0,
+			wantReservationPrice: 1.0 * (1 + 0.5*0.1*0.0001*100),
+			wantSpread:           0.1*0.0001*100 + 20*math.Log(1.0005),
+		}, {
+			name:                 "spread clamped to min",
+			inventory:            0,
+			variance:             0,
+			minSpread:            0.05,
+			maxSpread:            0,
+			wantReservationPrice: 1.0,
+			wantSpread:           0.05,
+		}, {
+			name:                 "spread clamped to max",
+			inventory:            0,
+			variance:             0,
+			minSpread:            0,
+			maxSpread:            0.001,
+			wantReservationPrice: 1.0,
+			wantSpread:           0.001,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			q := computeAvellanedaQuote(1.0, k.inventory, k.variance, 0.1, 200, 100, k.minSpread, k.maxSpread)
+			assert.InDelta(t, k.wantReservationPrice, q.reservationPrice, 0.000000001)
+			assert.InDelta(t, k.wantSpread, q.spread, 0.000000001)
+			assert.InDelta(t, q.reservationPrice*(1-q.spread/2), q.bid, 0.000000001)
+			assert.InDelta(t, q.reservationPrice*(1+q.spread/2), q.ask, 0.000000001)
+		})
+	}
+}
+
+func TestAvellanedaMakeLevels(t *testing.T) {
+	q := &avellanedaQuote{bid: 0.8, ask: 1.25}
+	orderConstraints := model.MakeOrderConstraints(4, 2, 1)
+
+	testCases := []struct {
+		isBuySide  bool
+		wantPrices []float64
+	}{
+		{isBuySide: false, wantPrices: []float64{1.25, 1.375, 1.5}},
+		{isBuySide: true, wantPrices: []float64{1.25, 1.3889, 1.5625}},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("isBuySide=%v", k.isBuySide), func(t *testing.T) {
+			p := makeAvellanedaLevelProvider(nil, 10, 3, 0.1, k.isBuySide, orderConstraints).(*avellanedaLevelProvider)
+			levels := p.makeLevels(q)
+			if !assert.Equal(t, len(k.wantPrices), len(levels)) {
+				return
+			}
+			for i, l := range levels {
+				assert.Equal(t, k.wantPrices[i], l.Price.AsFloat())
+				assert.Equal(t, 10.0, l.Amount.AsFloat())
+			}
+		})
+	}
+}
+
+func TestAvellanedaGetLevelsUsesModelQuote(t *testing.T) {
+	orderConstraints := model.MakeOrderConstraints(4, 2, 1)
+	m := &avellanedaModel{}
+	p := makeAvellanedaLevelProvider(m, 10, 1, 0, false, orderConstraints)
+
+	_, e := p.GetLevels(0, 0)
+	assert.Error(t, e, "levels should not be made before the quote is computed for the cycle")
+
+	m.quote = &avellanedaQuote{bid: 0.8, ask: 1.25}
+	levels, e := p.GetLevels(0, 0)
+	if !assert.NoError(t, e) || !assert.Equal(t, 1, len(levels)) {
+		return
+	}
+	assert.Equal(t, 1.25, levels[0].Price.AsFloat())
+}
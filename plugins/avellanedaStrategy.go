@@ -0,0 +1,166 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// avellanedaConfig contains the configuration params for this strategy
+type avellanedaConfig struct {
+	PriceTolerance          float64 `valid:"-" toml:"PRICE_TOLERANCE"`
+	AmountTolerance         float64 `valid:"-" toml:"AMOUNT_TOLERANCE"`
+	DataTypeA               string  `valid:"-" toml:"DATA_TYPE_A"`
+	DataFeedAURL            string  `valid:"-" toml:"DATA_FEED_A_URL"`
+	DataTypeB               string  `valid:"-" toml:"DATA_TYPE_B"`
+	DataFeedBURL            string  `valid:"-" toml:"DATA_FEED_B_URL"`
+	AmountOfABase           float64 `valid:"-" toml:"AMOUNT_OF_A_BASE"`          // the size of each order in units of the base asset
+	NumLevels               int16   `valid:"-" toml:"NUM_LEVELS"`                // number of levels on either side
+	LevelSpacing            float64 `valid:"-" toml:"LEVEL_SPACING"`             // additional spread between consecutive levels, specified as a decimal
+	RiskAversion            float64 `valid:"-" toml:"RISK_AVERSION"`             // gamma in the avellaneda-stoikov model
+	OrderBookDepthFactor    float64 `valid:"-" toml:"ORDER_BOOK_DEPTH_FACTOR"`   // k in the avellaneda-stoikov model
+	TimeHorizonSeconds      float64 `valid:"-" toml:"TIME_HORIZON_SECONDS"`      // T in the avellaneda-stoikov model
+	TargetBasePercent       float64 `valid:"-" toml:"TARGET_BASE_PERCENT"`       // share of the total value we want to hold in the base asset, specified as a decimal
+	MinSpread               float64 `valid:"-" toml:"MIN_SPREAD"`                // lower bound on the bid-ask spread, specified as a decimal
+	MaxSpread               float64 `valid:"-" toml:"MAX_SPREAD"`                // upper bound on the bid-ask spread, specified as a decimal (0 disables)
+	VolatilitySource        string  `valid:"-" toml:"VOLATILITY_SOURCE"`         // "trades" or "feed"
+	VolatilityWindowSeconds int64   `valid:"-" toml:"VOLATILITY_WINDOW_SECONDS"` // lookback window used to estimate volatility
+	MinVolatilitySamples    int     `valid:"-" toml:"MIN_VOLATILITY_SAMPLES"`    // number of price samples needed before volatility is used
+}
+
+// String impl.
+func (c avellanedaConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// makeAvellanedaStrategy is a factory method
+func makeAvellanedaStrategy(
+	sdex *SDEX,
+	pair *model.TradingPair,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	marketID string,
+	db *sql.DB,
+	clock api.Clock,
+	priceFeedFactory *PriceFeedFactory,
+	config *avellanedaConfig,
+) (api.Strategy, error) {
+	if config.NumLevels <= 0 {
+		return nil, fmt.Errorf("NUM_LEVELS needs to be greater than 0, was %d", config.NumLevels)
+	}
+	if config.LevelSpacing < 0 {
+		return nil, fmt.Errorf("LEVEL_SPACING cannot be negative, was %f", config.LevelSpacing)
+	}
+
+	pf, e := priceFeedFactory.MakeFeedPair(
+		config.DataTypeA,
+		config.DataFeedAURL,
+		config.DataTypeB,
+		config.DataFeedBURL,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the avellaneda strategy because we could not make the feed pair: %s", e)
+	}
+
+	// a single estimator is shared by both sides of the strategy through the model
+	volatility, e := makeVolatilityEstimator(
+		config.VolatilitySource,
+		time.Duration(config.VolatilityWindowSeconds)*time.Second,
+		config.MinVolatilitySamples,
+		db,
+		marketID,
+		clock,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the volatility estimator: %s", e)
+	}
+	avellaneda, e := makeAvellanedaModel(
+		pf,
+		ieif,
+		*assetBase,
+		*assetQuote,
+		config.RiskAversion,
+		config.OrderBookDepthFactor,
+		config.TimeHorizonSeconds,
+		config.TargetBasePercent,
+		config.MinSpread,
+		config.MaxSpread,
+		volatility,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the avellaneda model: %s", e)
+	}
+
+	orderConstraints := sdex.GetOrderConstraints(pair)
+	sellSideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		makeAvellanedaLevelProvider(
+			avellaneda,
+			config.AmountOfABase,
+			config.NumLevels,
+			config.LevelSpacing,
+			false,
+			orderConstraints,
+		),
+		config.PriceTolerance,
+		config.AmountTolerance,
+		false,
+	)
+	// switch sides of base/quote here for buy side
+	buySideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetQuote,
+		assetBase,
+		makeAvellanedaLevelProvider(
+			avellaneda,
+			config.AmountOfABase,
+			config.NumLevels,
+			config.LevelSpacing,
+			true,
+			orderConstraints,
+		),
+		config.PriceTolerance,
+		config.AmountTolerance,
+		true,
+	)
+
+	return &avellanedaStrategy{
+		Strategy: makeComposeStrategy(
+			assetBase,
+			assetQuote,
+			buySideStrategy,
+			sellSideStrategy,
+		),
+		model: avellaneda,
+	}, nil
+}
+
+// avellanedaStrategy composes the buy and sell sides and computes the quote of the shared model before the sides make their levels
+type avellanedaStrategy struct {
+	api.Strategy
+	model *avellanedaModel
+}
+
+// ensure it implements the Strategy interface
+var _ api.Strategy = &avellanedaStrategy{}
+
+// PreUpdate impl
+func (s *avellanedaStrategy) PreUpdate(maxAssetBase float64, maxAssetQuote float64, trustBase float64, trustQuote float64) error {
+	e := s.model.update()
+	if e != nil {
+		return fmt.Errorf("unable to compute avellaneda quote: %s", e)
+	}
+	return s.Strategy.PreUpdate(maxAssetBase, maxAssetQuote, trustBase, trustQuote)
+}
@@ -0,0 +1,135 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/nikhilsaraf/go-tools/multithreading"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/queries"
+)
+
+// makeBackingFillTracker makes the fill tracker for the backing exchange of the mirror and arbitrage strategies, which writes the fills of the
+// orders we place on the backing exchange to the db. Tracking starts at cursorOverride when it is set, otherwise at the latest trade.
+func makeBackingFillTracker(
+	strategyName string,
+	exchange api.Exchange,
+	exchangeName string,
+	backingPair *model.TradingPair,
+	cursorOverride string,
+	db *sql.DB,
+	dbOverrideAccountID string,
+) (api.FillTracker, error) {
+	var backingLastCursor interface{}
+	if cursorOverride == "" {
+		// loads cursor by fetching from exchange
+		var e error
+		backingLastCursor, e = exchange.GetLatestTradeCursor()
+		if e != nil {
+			return nil, fmt.Errorf("could not get last trade cursor from backing exchange in %s strategy: %s", strategyName, e)
+		}
+		log.Printf("set backingLastCursor from where to start tracking fills for backing exchange in %s strategy (no override specified): %v\n", strategyName, backingLastCursor)
+	} else {
+		// loads cursor from config file
+		backingLastCursor = cursorOverride
+		log.Printf("set backingLastCursor from where to start tracking fills for backing exchange in %s strategy (used override value): %v\n", strategyName, backingLastCursor)
+	}
+
+	backingFillTracker := MakeFillTracker(backingPair, multithreading.MakeThreadTracker(), exchange, 0, 0, backingLastCursor, MakeRealClock())
+	backingFillTracker.RegisterHandler(MakeFillLogger())
+	backingFillTracker.RegisterHandler(MakeFillDBWriter(db, model.MakePassthroughAssetDisplayFn(), exchangeName, dbOverrideAccountID))
+	return backingFillTracker, nil
+}
+
+// trackBackingFills runs a single iteration of the backing fill tracker so the fills on the backing exchange are written to the db
+func trackBackingFills(backingFillTracker api.FillTracker, caller string) error {
+	trades, e := backingFillTracker.FillTrackSingleIteration()
+	if e != nil {
+		return fmt.Errorf("unable to track a single iteration of fills from the backing exchange in %s: %s", caller, e)
+	}
+	log.Printf("found %d trades on load from backing exchange in %s\n", len(trades), caller)
+	return nil
+}
+
+// tradeTriggers records the orders that we placed on the backing exchange for the trades on the primary exchange in the
+// strategy_mirror_trade_triggers table, so a trade is never offset twice
+type tradeTriggers struct {
+	db              *sql.DB
+	existsQuery     *queries.StrategyMirrorTradeTriggerExists
+	marketID        string
+	backingMarketID string
+}
+
+// makeTradeTriggers is a factory method
+func makeTradeTriggers(db *sql.DB, marketID string, backingMarketID string) (*tradeTriggers, error) {
+	existsQuery, e := queries.MakeStrategyMirrorTradeTriggerExists(db, marketID)
+	if e != nil {
+		return nil, fmt.Errorf("unable to create strategyMirrorTradeTriggerExistsQuery: %s", e)
+	}
+
+	return &tradeTriggers{
+		db:              db,
+		existsQuery:     existsQuery,
+		marketID:        marketID,
+		backingMarketID: backingMarketID,
+	}, nil
+}
+
+// exists returns true if we already placed an order on the backing exchange for the trade with the passed in transactionID
+func (t *tradeTriggers) exists(primaryTxID string) (bool, error) {
+	queryResult, e := t.existsQuery.QueryRow(primaryTxID)
+	if e != nil {
+		return false, fmt.Errorf("unable to fetch trade trigger for transactionID '%s': %s", primaryTxID, e)
+	}
+	rowExists, ok := queryResult.(bool)
+	if !ok {
+		return false, fmt.Errorf("unable to convert result of strategyMirrorTradeTriggerExistsQuery to bool: %v (type=%T)", queryResult, queryResult)
+	}
+	return rowExists, nil
+}
+
+// insert records the order placed on the backing exchange for the trade on the primary exchange, reinserting an existing row is ignored
+func (t *tradeTriggers) insert(primaryTxID string, backingTxID string) error {
+	sqlInsert := fmt.Sprintf(kelpdb.SqlStrategyMirrorTradeTriggersInsertTemplate,
+		t.marketID,
+		primaryTxID,
+		t.backingMarketID,
+		backingTxID,
+	)
+	_, e := t.db.Exec(sqlInsert)
+	if e != nil {
+		if strings.Contains(e.Error(), "duplicate key value violates unique constraint \"strategy_mirror_trade_triggers_pkey\"") {
+			log.Printf("trying to reinsert trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s) to db, ignore and continue\n", t.marketID, primaryTxID, t.backingMarketID, backingTxID)
+			return nil
+		}
+
+		// return an error on any other errors
+		return fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
+	}
+
+	log.Printf("wrote trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s) to db\n", t.marketID, primaryTxID, t.backingMarketID, backingTxID)
+	return nil
+}
+
+// placeBackingOrder places the order on the backing exchange for the trade on the primary exchange and records the trade trigger
+// immediately after the order is placed. orders on the backing exchange are always submitted as taker orders so we use api.SubmitModeBoth
+func (t *tradeTriggers) placeBackingOrder(exchange api.Exchange, newOrder *model.Order, primaryTxID string) (*model.TransactionID, error) {
+	transactionID, e := exchange.AddOrder(newOrder, api.SubmitModeBoth)
+	if e != nil {
+		return nil, fmt.Errorf("error when placing order on the backing exchange (newOrder=%s): %s", *newOrder, e)
+	}
+	if transactionID == nil {
+		return nil, fmt.Errorf("error when placing order on the backing exchange (newOrder=%s): transactionID was <nil>", *newOrder)
+	}
+
+	e = t.insert(primaryTxID, transactionID.String())
+	if e != nil {
+		return nil, fmt.Errorf("error when inserting trade trigger with txID=%s (newOrder=%s) (PK dupes not allowed): %s", transactionID.String(), *newOrder, e)
+	}
+	return transactionID, nil
+}
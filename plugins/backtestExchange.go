@@ -0,0 +1,667 @@
+package plugins
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// backtestOrderConstraints is the default order constraints for the backtest exchange, which mirrors the SDEX
+var backtestOrderConstraints = model.MakeOrderConstraints(7, 7, 0.0000001)
+
+// BacktestTick is a single historical data point that is replayed by the BacktestExchange
+type BacktestTick struct {
+	Time   time.Time
+	Price  float64 // last traded price
+	Volume float64 // base volume traded at Price, this is the liquidity available to match our resting orders
+	Bid    float64 // optional top bid from an orderbook snapshot, 0 if unknown
+	Ask    float64 // optional top ask from an orderbook snapshot, 0 if unknown
+}
+
+// topBid returns the bid from the orderbook snapshot, falling back to the last traded price
+func (t BacktestTick) topBid() float64 {
+	if t.Bid > 0 {
+		return t.Bid
+	}
+	return t.Price
+}
+
+// topAsk returns the ask from the orderbook snapshot, falling back to the last traded price
+func (t BacktestTick) topAsk() float64 {
+	if t.Ask > 0 {
+		return t.Ask
+	}
+	return t.Price
+}
+
+// BacktestExchange is a simulated exchange with a local matching engine that replays historical trades and orderbook snapshots.
+// the exchange follows the clock that drives the backtest, whenever it is used it first replays all the ticks up to the current time
+type BacktestExchange struct {
+	pair               *model.TradingPair
+	ticks              []BacktestTick
+	feePct             float64
+	clock              api.Clock
+	ocOverridesHandler *OrderConstraintsOverridesHandler
+
+	// initialized runtime vars
+	mutex       *sync.Mutex
+	tickIndex   int
+	balances    map[model.Asset]float64
+	openOrders  []*model.OpenOrder
+	fills       []model.Trade
+	feesPaid    float64
+	nextOrderID int64
+	usedVolume  float64 // base volume of the current tick that was already filled, all our orders in a tick share the volume of the tick
+}
+
+// ensure that BacktestExchange conforms to the Exchange interface
+var _ api.Exchange = &BacktestExchange{}
+
+// MakeBacktestExchange is a factory method for the BacktestExchange
+func MakeBacktestExchange(
+	pair *model.TradingPair,
+	ticks []BacktestTick,
+	startBaseBalance float64,
+	startQuoteBalance float64,
+	feePct float64,
+	clock api.Clock,
+) (*BacktestExchange, error) {
+	if len(ticks) == 0 {
+		return nil, fmt.Errorf("need at least one tick of historical data to run a backtest")
+	}
+	if feePct < 0 {
+		return nil, fmt.Errorf("feePct cannot be negative: %f", feePct)
+	}
+
+	sortedTicks := make([]BacktestTick, len(ticks))
+	copy(sortedTicks, ticks)
+	sort.SliceStable(sortedTicks, func(i int, j int) bool {
+		return sortedTicks[i].Time.Before(sortedTicks[j].Time)
+	})
+
+	return &BacktestExchange{
+		pair:               pair,
+		ticks:              sortedTicks,
+		feePct:             feePct,
+		clock:              clock,
+		ocOverridesHandler: MakeEmptyOrderConstraintsOverridesHandler(),
+		// initialized runtime vars
+		mutex:     &sync.Mutex{},
+		tickIndex: -1,
+		balances: map[model.Asset]float64{
+			pair.Base:  startBaseBalance,
+			pair.Quote: startQuoteBalance,
+		},
+		openOrders:  []*model.OpenOrder{},
+		fills:       []model.Trade{},
+		feesPaid:    0,
+		nextOrderID: 1,
+		usedVolume:  0,
+	}, nil
+}
+
+// replayUntilNow matches our resting orders against every tick up to the current time of the clock, the caller should hold the lock
+func (b *BacktestExchange) replayUntilNow() {
+	now := b.clock.Now()
+	for b.tickIndex+1 < len(b.ticks) && !b.ticks[b.tickIndex+1].Time.After(now) {
+		b.tickIndex++
+		b.usedVolume = 0
+		b.matchRestingOrders(b.ticks[b.tickIndex])
+	}
+}
+
+// NumTicks returns the total number of ticks in the replay
+func (b *BacktestExchange) NumTicks() int {
+	return len(b.ticks)
+}
+
+// FirstTick returns the first tick in the replay
+func (b *BacktestExchange) FirstTick() BacktestTick {
+	return b.ticks[0]
+}
+
+// LastTick returns the last tick in the replay
+func (b *BacktestExchange) LastTick() BacktestTick {
+	return b.ticks[len(b.ticks)-1]
+}
+
+// Fills returns all the fills of our orders so far
+func (b *BacktestExchange) Fills() []model.Trade {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	fills := make([]model.Trade, len(b.fills))
+	copy(fills, b.fills)
+	return fills
+}
+
+// FeesPaid returns the total fees paid so far in units of the quote asset
+func (b *BacktestExchange) FeesPaid() float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	return b.feesPaid
+}
+
+// Balance returns the current total balance of the asset
+func (b *BacktestExchange) Balance(asset model.Asset) float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	return b.balances[asset]
+}
+
+func (b *BacktestExchange) currentTick() (*BacktestTick, error) {
+	if b.tickIndex < 0 {
+		return nil, fmt.Errorf("backtest exchange has no historical data at or before %s", b.clock.Now().UTC().Format(time.RFC3339))
+	}
+	return &b.ticks[b.tickIndex], nil
+}
+
+func (b *BacktestExchange) checkPair(pair model.TradingPair) error {
+	if pair != *b.pair {
+		return fmt.Errorf("backtest exchange only supports the trading pair %s, but was asked for %s", b.pair, pair)
+	}
+	return nil
+}
+
+// matchRestingOrders fills our open orders that cross the traded price or the top of the book, best priced orders first.
+// orders are filled at their limit price (as a maker) and orders on both sides share the volume traded in the tick
+func (b *BacktestExchange) matchRestingOrders(tick BacktestTick) {
+	sellOrders := []*model.OpenOrder{}
+	buyOrders := []*model.OpenOrder{}
+	for _, o := range b.openOrders {
+		price := o.Price.AsFloat()
+		if o.OrderAction.IsSell() && (price <= tick.Price || (tick.Bid > 0 && price <= tick.Bid)) {
+			sellOrders = append(sellOrders, o)
+		} else if o.OrderAction.IsBuy() && (price >= tick.Price || (tick.Ask > 0 && price >= tick.Ask)) {
+			buyOrders = append(buyOrders, o)
+		}
+	}
+	sort.SliceStable(sellOrders, func(i int, j int) bool {
+		return sellOrders[i].Price.AsFloat() < sellOrders[j].Price.AsFloat()
+	})
+	sort.SliceStable(buyOrders, func(i int, j int) bool {
+		return buyOrders[i].Price.AsFloat() > buyOrders[j].Price.AsFloat()
+	})
+
+	for _, orders := range [][]*model.OpenOrder{sellOrders, buyOrders} {
+		for _, o := range orders {
+			remainingTickVolume := tick.Volume - b.usedVolume
+			if remainingTickVolume <= 0 {
+				break
+			}
+			fillVolume := math.Min(o.Volume.AsFloat(), remainingTickVolume)
+			b.fillOrder(o, o.Price.AsFloat(), fillVolume, tick.Time)
+			b.usedVolume += fillVolume
+		}
+	}
+	b.removeFilledOrders()
+}
+
+// fillOrder executes fillVolume units of the order at the given price and updates the balances
+func (b *BacktestExchange) fillOrder(o *model.OpenOrder, price float64, fillVolume float64, fillTime time.Time) {
+	oc := b.GetOrderConstraints(b.pair)
+	cost := price * fillVolume
+	fee := cost * b.feePct
+	if o.OrderAction.IsSell() {
+		b.balances[b.pair.Base] -= fillVolume
+		b.balances[b.pair.Quote] += cost - fee
+	} else {
+		b.balances[b.pair.Base] += fillVolume
+		b.balances[b.pair.Quote] -= cost + fee
+	}
+	b.feesPaid += fee
+
+	remainingVolume := o.Volume.AsFloat() - fillVolume
+	executedVolume := fillVolume
+	if o.VolumeExecuted != nil {
+		executedVolume += o.VolumeExecuted.AsFloat()
+	}
+	o.Volume = model.NumberFromFloat(remainingVolume, oc.VolumePrecision)
+	o.VolumeExecuted = model.NumberFromFloat(executedVolume, oc.VolumePrecision)
+
+	trade := model.Trade{
+		Order: model.Order{
+			Pair:        b.pair,
+			OrderAction: o.OrderAction,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(price, oc.PricePrecision),
+			Volume:      model.NumberFromFloat(fillVolume, oc.VolumePrecision),
+			Timestamp:   model.MakeTimestampFromTime(fillTime),
+		},
+		TransactionID: model.MakeTransactionID(fmt.Sprintf("backtest-%d", len(b.fills)+1)),
+		OrderID:       o.ID,
+		Cost:          model.NumberFromFloat(cost, largePrecision),
+		Fee:           model.NumberFromFloat(fee, largePrecision),
+	}
+	b.fills = append(b.fills, trade)
+	log.Printf("backtestExchange: filled order at %s: %s\n", fillTime.UTC().Format(time.RFC3339), trade)
+}
+
+func (b *BacktestExchange) removeFilledOrders() {
+	minBaseVolume := b.GetOrderConstraints(b.pair).MinBaseVolume.AsFloat()
+	remaining := []*model.OpenOrder{}
+	for _, o := range b.openOrders {
+		if o.Volume.AsFloat() >= minBaseVolume && o.Volume.AsFloat() > 0 {
+			remaining = append(remaining, o)
+		}
+	}
+	b.openOrders = remaining
+}
+
+// availableBalance is the balance of the asset that is not reserved by open orders
+func (b *BacktestExchange) availableBalance(asset model.Asset) float64 {
+	reserved := 0.0
+	for _, o := range b.openOrders {
+		if o.OrderAction.IsSell() && asset == b.pair.Base {
+			reserved += o.Volume.AsFloat()
+		} else if o.OrderAction.IsBuy() && asset == b.pair.Quote {
+			reserved += o.Volume.AsFloat() * o.Price.AsFloat() * (1 + b.feePct)
+		}
+	}
+	return b.balances[asset] - reserved
+}
+
+// GetTickerPrice impl.
+func (b *BacktestExchange) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	tick, e := b.currentTick()
+	if e != nil {
+		return nil, e
+	}
+
+	oc := b.GetOrderConstraints(b.pair)
+	priceResult := map[model.TradingPair]api.Ticker{}
+	for _, p := range pairs {
+		if e := b.checkPair(p); e != nil {
+			return nil, e
+		}
+		priceResult[p] = api.Ticker{
+			AskPrice:  model.NumberFromFloat(tick.topAsk(), oc.PricePrecision),
+			BidPrice:  model.NumberFromFloat(tick.topBid(), oc.PricePrecision),
+			LastPrice: model.NumberFromFloat(tick.Price, oc.PricePrecision),
+		}
+	}
+	return priceResult, nil
+}
+
+// GetAccountBalances impl.
+func (b *BacktestExchange) GetAccountBalances(assetList []interface{}) (map[interface{}]model.Number, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	m := map[interface{}]model.Number{}
+	for _, elem := range assetList {
+		asset, ok := elem.(model.Asset)
+		if !ok {
+			return nil, fmt.Errorf("invalid type of asset passed in, only model.Asset accepted")
+		}
+		m[asset] = *model.NumberFromFloat(b.balances[asset], largePrecision)
+	}
+	return m, nil
+}
+
+// GetAssetConverter impl.
+func (b *BacktestExchange) GetAssetConverter() model.AssetConverterInterface {
+	return model.Display
+}
+
+// GetOrderConstraints impl.
+func (b *BacktestExchange) GetOrderConstraints(pair *model.TradingPair) *model.OrderConstraints {
+	return b.ocOverridesHandler.Apply(pair, backtestOrderConstraints)
+}
+
+// OverrideOrderConstraints impl, can partially override values for specific pairs
+func (b *BacktestExchange) OverrideOrderConstraints(pair *model.TradingPair, override *model.OrderConstraintsOverride) {
+	b.ocOverridesHandler.Upsert(pair, override)
+}
+
+// GetOrderBook impl. the orderbook is synthesized from the snapshot (or last trade) in the current tick
+func (b *BacktestExchange) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	if e := b.checkPair(*pair); e != nil {
+		return nil, e
+	}
+	tick, e := b.currentTick()
+	if e != nil {
+		return nil, e
+	}
+
+	oc := b.GetOrderConstraints(pair)
+	ts := model.MakeTimestampFromTime(tick.Time)
+	asks := []model.Order{}
+	bids := []model.Order{}
+	if maxCount > 0 {
+		asks = append(asks, model.Order{
+			Pair:        pair,
+			OrderAction: model.OrderActionSell,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(tick.topAsk(), oc.PricePrecision),
+			Volume:      model.NumberFromFloat(tick.Volume, oc.VolumePrecision),
+			Timestamp:   ts,
+		})
+		bids = append(bids, model.Order{
+			Pair:        pair,
+			OrderAction: model.OrderActionBuy,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(tick.topBid(), oc.PricePrecision),
+			Volume:      model.NumberFromFloat(tick.Volume, oc.VolumePrecision),
+			Timestamp:   ts,
+		})
+	}
+	return model.MakeOrderBook(pair, asks, bids), nil
+}
+
+// GetTrades impl.
+func (b *BacktestExchange) GetTrades(pair *model.TradingPair, maybeCursor interface{}) (*api.TradesResult, error) {
+	result, e := b.GetTradeHistory(*pair, maybeCursor, nil)
+	if e != nil {
+		return nil, e
+	}
+	return &api.TradesResult{
+		Cursor: result.Cursor,
+		Trades: result.Trades,
+	}, nil
+}
+
+// GetTradeHistory impl. the cursor is the number of fills that have already been seen
+func (b *BacktestExchange) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	if e := b.checkPair(pair); e != nil {
+		return nil, e
+	}
+
+	start := 0
+	if maybeCursorStart != nil {
+		var e error
+		start, e = strconv.Atoi(fmt.Sprintf("%v", maybeCursorStart))
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse cursorStart (%v) as an int: %s", maybeCursorStart, e)
+		}
+	}
+	end := len(b.fills)
+	if maybeCursorEnd != nil {
+		var e error
+		end, e = strconv.Atoi(fmt.Sprintf("%v", maybeCursorEnd))
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse cursorEnd (%v) as an int: %s", maybeCursorEnd, e)
+		}
+		if end > len(b.fills) {
+			end = len(b.fills)
+		}
+	}
+	if start > end {
+		start = end
+	}
+
+	trades := make([]model.Trade, end-start)
+	copy(trades, b.fills[start:end])
+	return &api.TradeHistoryResult{
+		Cursor: fmt.Sprintf("%d", end),
+		Trades: trades,
+	}, nil
+}
+
+// GetLatestTradeCursor impl.
+func (b *BacktestExchange) GetLatestTradeCursor() (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	return fmt.Sprintf("%d", len(b.fills)), nil
+}
+
+// GetOpenOrders impl.
+func (b *BacktestExchange) GetOpenOrders(pairs []*model.TradingPair) (map[model.TradingPair][]model.OpenOrder, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	m := map[model.TradingPair][]model.OpenOrder{}
+	for _, p := range pairs {
+		if e := b.checkPair(*p); e != nil {
+			return nil, e
+		}
+		openOrders := []model.OpenOrder{}
+		for _, o := range b.openOrders {
+			openOrders = append(openOrders, *o)
+		}
+		m[*p] = openOrders
+	}
+	return m, nil
+}
+
+// AddOrder impl. orders that cross the book are filled immediately as a taker unless submitMode is maker only, the taker fill is limited by
+// the volume of the tick that was not already taken by other orders and the rest of the order stays on the book
+func (b *BacktestExchange) AddOrder(order *model.Order, submitMode api.SubmitMode) (*model.TransactionID, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	if e := b.checkPair(*order.Pair); e != nil {
+		return nil, e
+	}
+	tick, e := b.currentTick()
+	if e != nil {
+		return nil, e
+	}
+
+	price := order.Price.AsFloat()
+	volume := order.Volume.AsFloat()
+	if price <= 0 || volume <= 0 {
+		return nil, fmt.Errorf("order needs a positive price and volume: %s", order)
+	}
+
+	if order.OrderAction.IsSell() {
+		available := b.availableBalance(b.pair.Base)
+		if volume > available {
+			return nil, fmt.Errorf("insufficient balance of %s to sell %f units, available=%f", b.pair.Base, volume, available)
+		}
+	} else {
+		available := b.availableBalance(b.pair.Quote)
+		needed := volume * price * (1 + b.feePct)
+		if needed > available {
+			return nil, fmt.Errorf("insufficient balance of %s to buy %f units at price %f (needs %f), available=%f", b.pair.Quote, volume, price, needed, available)
+		}
+	}
+
+	openOrder := &model.OpenOrder{
+		Order: model.Order{
+			Pair:        b.pair,
+			OrderAction: order.OrderAction,
+			OrderType:   model.OrderTypeLimit,
+			Price:       order.Price,
+			Volume:      order.Volume,
+			Timestamp:   model.MakeTimestampFromTime(tick.Time),
+		},
+		ID:             fmt.Sprintf("%d", b.nextOrderID),
+		StartTime:      model.MakeTimestampFromTime(tick.Time),
+		ExpireTime:     nil,
+		VolumeExecuted: nil,
+	}
+	b.nextOrderID++
+
+	crossesBook := (order.OrderAction.IsSell() && price <= tick.topBid()) || (order.OrderAction.IsBuy() && price >= tick.topAsk())
+	if crossesBook {
+		if submitMode == api.SubmitModeMakerOnly {
+			return nil, fmt.Errorf("order would cross the book in maker only mode (topBid=%f, topAsk=%f): %s", tick.topBid(), tick.topAsk(), order)
+		}
+
+		// taker fills execute at the price on the other side of the book
+		takerPrice := tick.topAsk()
+		if order.OrderAction.IsSell() {
+			takerPrice = tick.topBid()
+		}
+		takerFillVolume := math.Min(volume, tick.Volume-b.usedVolume)
+		if takerFillVolume > 0 {
+			b.fillOrder(openOrder, takerPrice, takerFillVolume, tick.Time)
+			b.usedVolume += takerFillVolume
+		}
+		if openOrder.Volume.AsFloat() < b.GetOrderConstraints(b.pair).MinBaseVolume.AsFloat() {
+			return model.MakeTransactionID(openOrder.ID), nil
+		}
+		log.Printf("backtestExchange: taker fill was limited by the tick volume (%f), leaving %s units of the order on the book\n", tick.Volume, openOrder.Volume.AsString())
+	}
+
+	b.openOrders = append(b.openOrders, openOrder)
+	return model.MakeTransactionID(openOrder.ID), nil
+}
+
+// CancelOrder impl.
+func (b *BacktestExchange) CancelOrder(txID *model.TransactionID, pair model.TradingPair) (model.CancelOrderResult, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.replayUntilNow()
+
+	if e := b.checkPair(pair); e != nil {
+		return model.CancelResultFailed, e
+	}
+
+	for i, o := range b.openOrders {
+		if o.ID == txID.String() {
+			b.openOrders = append(b.openOrders[:i], b.openOrders[i+1:]...)
+			return model.CancelResultCancelSuccessful, nil
+		}
+	}
+	// the order may have been filled completely already
+	return model.CancelResultFailed, nil
+}
+
+// PrepareDeposit impl.
+func (b *BacktestExchange) PrepareDeposit(asset model.Asset, amount *model.Number) (*api.PrepareDepositResult, error) {
+	return nil, fmt.Errorf("deposits are not supported on the backtest exchange")
+}
+
+// GetWithdrawInfo impl.
+func (b *BacktestExchange) GetWithdrawInfo(asset model.Asset, amountToWithdraw *model.Number, address string) (*api.WithdrawInfo, error) {
+	return nil, fmt.Errorf("withdrawals are not supported on the backtest exchange")
+}
+
+// WithdrawFunds impl.
+func (b *BacktestExchange) WithdrawFunds(
+	asset model.Asset,
+	amountToWithdraw *model.Number,
+	address string,
+) (*api.WithdrawFunds, error) {
+	return nil, fmt.Errorf("withdrawals are not supported on the backtest exchange")
+}
+
+// LoadBacktestTicksFromCSV reads ticks from a CSV file with the columns: timestamp,price,volume[,bid,ask]
+// timestamp is either in RFC3339 format or millis since epoch. A header row is optional.
+func LoadBacktestTicksFromCSV(filename string) ([]BacktestTick, error) {
+	f, e := os.Open(filename)
+	if e != nil {
+		return nil, fmt.Errorf("could not open backtest data file '%s': %s", filename, e)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	ticks := []BacktestTick{}
+	for lineNum := 1; ; lineNum++ {
+		record, e := r.Read()
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return nil, fmt.Errorf("could not read line %d of backtest data file '%s': %s", lineNum, filename, e)
+		}
+		if lineNum == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "timestamp") {
+			// skip header
+			continue
+		}
+
+		tick, e := parseBacktestRecord(record)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse line %d of backtest data file '%s': %s", lineNum, filename, e)
+		}
+		ticks = append(ticks, *tick)
+	}
+	return ticks, nil
+}
+
+func parseBacktestRecord(record []string) (*BacktestTick, error) {
+	if len(record) != 3 && len(record) != 5 {
+		return nil, fmt.Errorf("expected 3 (timestamp,price,volume) or 5 (timestamp,price,volume,bid,ask) columns but found %d", len(record))
+	}
+
+	ts, e := parseBacktestTimestamp(strings.TrimSpace(record[0]))
+	if e != nil {
+		return nil, e
+	}
+
+	values := []float64{}
+	for _, s := range record[1:] {
+		v, e := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if e != nil {
+			return nil, fmt.Errorf("could not parse value '%s' as a float: %s", s, e)
+		}
+		if v < 0 {
+			return nil, fmt.Errorf("values cannot be negative: %f", v)
+		}
+		values = append(values, v)
+	}
+
+	tick := &BacktestTick{
+		Time:   ts,
+		Price:  values[0],
+		Volume: values[1],
+	}
+	if len(values) == 4 {
+		tick.Bid = values[2]
+		tick.Ask = values[3]
+	}
+	return tick, nil
+}
+
+func parseBacktestTimestamp(s string) (time.Time, error) {
+	if millis, e := strconv.ParseInt(s, 10, 64); e == nil {
+		return time.Unix(0, millis*int64(time.Millisecond)).UTC(), nil
+	}
+
+	t, e := time.Parse(time.RFC3339, s)
+	if e != nil {
+		return time.Time{}, fmt.Errorf("could not parse timestamp '%s', needs to be in RFC3339 format or millis since epoch", s)
+	}
+	return t.UTC(), nil
+}
+
+// LoadBacktestTicksFromDB reads ticks from the trades table for the given market in the range [start, end)
+func LoadBacktestTicksFromDB(db *sql.DB, marketID string, start time.Time, end time.Time) ([]BacktestTick, error) {
+	ticks := []BacktestTick{}
+	e := loadTradesInRangeFromDB(db, marketID, start, end, func(t time.Time, price float64, volume float64) {
+		ticks = append(ticks, BacktestTick{Time: t, Price: price, Volume: volume})
+	})
+	if e != nil {
+		return nil, e
+	}
+	return ticks, nil
+}
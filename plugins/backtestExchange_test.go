@@ -0,0 +1,293 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+func makeTestBacktestExchange(t *testing.T, ticks []BacktestTick, clock api.Clock) *BacktestExchange {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USD}
+	b, e := MakeBacktestExchange(pair, ticks, 100, 100, 0.001, clock)
+	if !assert.NoError(t, e) {
+		return nil
+	}
+	return b
+}
+
+func TestBacktestExchange_MatchRestingOrders(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	ticks := []BacktestTick{
+		{Time: start, Price: 1.0, Volume: 50},
+		{Time: start.Add(time.Minute), Price: 1.2, Volume: 30},
+		{Time: start.Add(2 * time.Minute), Price: 0.8, Volume: 100},
+	}
+
+	testCases := []struct {
+		name            string
+		action          model.OrderAction
+		price           float64
+		volume          float64
+		wantNumFills    int
+		wantBaseBalance float64
+		wantQuote       float64
+	}{
+		{
+			name:            "sell limited by tick volume",
+			action:          model.OrderActionSell,
+			price:           1.1,
+			volume:          40,
+			wantNumFills:    1,
+			wantBaseBalance: 70,
+			wantQuote:       100 + 33 - 0.033,
+		}, {
+			name:            "buy filled completely",
+			action:          model.OrderActionBuy,
+			price:           0.9,
+			volume:          10,
+			wantNumFills:    1,
+			wantBaseBalance: 110,
+			wantQuote:       100 - 9 - 0.009,
+		}, {
+			name:            "order never crosses",
+			action:          model.OrderActionSell,
+			price:           2.0,
+			volume:          10,
+			wantNumFills:    0,
+			wantBaseBalance: 100,
+			wantQuote:       100,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			clock := MakeVirtualClock(start)
+			b := makeTestBacktestExchange(t, ticks, clock)
+
+			txID, e := b.AddOrder(&model.Order{
+				Pair:        b.pair,
+				OrderAction: k.action,
+				OrderType:   model.OrderTypeLimit,
+				Price:       model.NumberFromFloat(k.price, 7),
+				Volume:      model.NumberFromFloat(k.volume, 7),
+			}, api.SubmitModeMakerOnly)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.NotNil(t, txID)
+
+			clock.Set(ticks[len(ticks)-1].Time)
+
+			assert.Equal(t, k.wantNumFills, len(b.Fills()))
+			assert.InDelta(t, k.wantBaseBalance, b.Balance(model.XLM), 0.0000001)
+			assert.InDelta(t, k.wantQuote, b.Balance(model.USD), 0.0000001)
+		})
+	}
+}
+
+func TestBacktestExchange_AddOrder(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	ticks := []BacktestTick{
+		{Time: start, Price: 1.0, Volume: 50, Bid: 0.99, Ask: 1.01},
+	}
+
+	testCases := []struct {
+		name                 string
+		action               model.OrderAction
+		price                float64
+		volume               float64
+		submitMode           api.SubmitMode
+		wantErr              bool
+		wantNumFills         int
+		wantFillVolume       float64
+		wantNumOpenOrders    int
+		wantOpenVolume       float64
+		secondOrderVolume    float64 // a second crossing order of the same kind in the same tick when > 0
+		wantSecondFillVolume float64
+	}{
+		{
+			name:              "resting order",
+			action:            model.OrderActionBuy,
+			price:             0.95,
+			volume:            10,
+			submitMode:        api.SubmitModeMakerOnly,
+			wantErr:           false,
+			wantNumFills:      0,
+			wantNumOpenOrders: 1,
+			wantOpenVolume:    10,
+		}, {
+			name:         "crossing order in maker only mode",
+			action:       model.OrderActionBuy,
+			price:        1.05,
+			volume:       10,
+			submitMode:   api.SubmitModeMakerOnly,
+			wantErr:      true,
+			wantNumFills: 0,
+		}, {
+			name:           "crossing order taken immediately",
+			action:         model.OrderActionSell,
+			price:          0.95,
+			volume:         10,
+			submitMode:     api.SubmitModeBoth,
+			wantErr:        false,
+			wantNumFills:   1,
+			wantFillVolume: 10,
+		}, {
+			name:              "crossing order limited by tick volume",
+			action:            model.OrderActionSell,
+			price:             0.95,
+			volume:            60,
+			submitMode:        api.SubmitModeBoth,
+			wantErr:           false,
+			wantNumFills:      1,
+			wantFillVolume:    50,
+			wantNumOpenOrders: 1,
+			wantOpenVolume:    10,
+		}, {
+			name:                 "crossing orders share the tick volume",
+			action:               model.OrderActionBuy,
+			price:                1.05,
+			volume:               30,
+			submitMode:           api.SubmitModeBoth,
+			wantErr:              false,
+			wantNumFills:         2,
+			wantFillVolume:       50,
+			wantNumOpenOrders:    1,
+			wantOpenVolume:       10,
+			secondOrderVolume:    30,
+			wantSecondFillVolume: 20,
+		}, {
+			name:         "insufficient balance",
+			action:       model.OrderActionSell,
+			price:        1.5,
+			volume:       101,
+			submitMode:   api.SubmitModeBoth,
+			wantErr:      true,
+			wantNumFills: 0,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			b := makeTestBacktestExchange(t, ticks, MakeVirtualClock(start))
+
+			volumes := []float64{k.volume}
+			if k.secondOrderVolume > 0 {
+				volumes = append(volumes, k.secondOrderVolume)
+			}
+			for _, v := range volumes {
+				_, e := b.AddOrder(&model.Order{
+					Pair:        b.pair,
+					OrderAction: k.action,
+					OrderType:   model.OrderTypeLimit,
+					Price:       model.NumberFromFloat(k.price, 7),
+					Volume:      model.NumberFromFloat(v, 7),
+				}, k.submitMode)
+				assert.Equal(t, k.wantErr, e != nil)
+			}
+
+			fills := b.Fills()
+			if !assert.Equal(t, k.wantNumFills, len(fills)) {
+				return
+			}
+			fillVolume := 0.0
+			for _, f := range fills {
+				fillVolume += f.Volume.AsFloat()
+			}
+			assert.InDelta(t, k.wantFillVolume, fillVolume, 0.0000001)
+			if k.secondOrderVolume > 0 {
+				assert.InDelta(t, k.wantSecondFillVolume, fills[1].Volume.AsFloat(), 0.0000001)
+			}
+
+			if !assert.Equal(t, k.wantNumOpenOrders, len(b.openOrders)) {
+				return
+			}
+			if k.wantNumOpenOrders > 0 {
+				assert.InDelta(t, k.wantOpenVolume, b.openOrders[0].Volume.AsFloat(), 0.0000001)
+			}
+		})
+	}
+}
+
+func TestBacktestExchange_SharedTickVolume(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	ticks := []BacktestTick{
+		{Time: start, Price: 1.0, Volume: 50, Bid: 0.9, Ask: 1.1},
+		{Time: start.Add(time.Minute), Price: 1.0, Volume: 50},
+		{Time: start.Add(2 * time.Minute), Price: 1.0, Volume: 0},
+	}
+	clock := MakeVirtualClock(start)
+	b := makeTestBacktestExchange(t, ticks, clock)
+
+	// both orders rest inside the spread of the first tick and cross the trade in the second tick
+	for _, action := range []model.OrderAction{model.OrderActionSell, model.OrderActionBuy} {
+		_, e := b.AddOrder(&model.Order{
+			Pair:        b.pair,
+			OrderAction: action,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(1.0, 7),
+			Volume:      model.NumberFromFloat(40, 7),
+		}, api.SubmitModeMakerOnly)
+		if !assert.NoError(t, e) {
+			return
+		}
+	}
+	assert.Equal(t, 0, len(b.Fills()))
+
+	// skipping over ticks still replays each of them
+	clock.Set(ticks[2].Time)
+	fills := b.Fills()
+	fillVolume := 0.0
+	for _, f := range fills {
+		fillVolume += f.Volume.AsFloat()
+	}
+	assert.InDelta(t, 50.0, fillVolume, 0.0000001)
+	assert.Equal(t, 2, len(fills))
+}
+
+func TestParseBacktestRecord(t *testing.T) {
+	testCases := []struct {
+		record  []string
+		wantErr bool
+		want    *BacktestTick
+	}{
+		{
+			record: []string{"1609459200000", "0.25", "100"},
+			want:   &BacktestTick{Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Price: 0.25, Volume: 100},
+		}, {
+			record: []string{"2021-01-01T00:00:00Z", "0.25", "100", "0.24", "0.26"},
+			want:   &BacktestTick{Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Price: 0.25, Volume: 100, Bid: 0.24, Ask: 0.26},
+		}, {
+			record:  []string{"2021-01-01", "0.25", "100"},
+			wantErr: true,
+		}, {
+			record:  []string{"1609459200000", "0.25"},
+			wantErr: true,
+		}, {
+			record:  []string{"1609459200000", "-0.25", "100"},
+			wantErr: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.record[0], func(t *testing.T) {
+			tick, e := parseBacktestRecord(k.record)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.True(t, k.want.Time.Equal(tick.Time))
+			assert.Equal(t, k.want.Price, tick.Price)
+			assert.Equal(t, k.want.Volume, tick.Volume)
+			assert.Equal(t, k.want.Bid, tick.Bid)
+			assert.Equal(t, k.want.Ask, tick.Ask)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package plugins
 
 import (
 	"fmt"
-	"time"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/api"
@@ -17,9 +16,11 @@ func makeBuyTwapStrategy(
 	assetBase *hProtocol.Asset,
 	assetQuote *hProtocol.Asset,
 	filterFactory *FilterFactory,
+	priceFeedFactory *PriceFeedFactory,
 	config *sellTwapConfig,
+	clock api.Clock,
 ) (api.Strategy, error) {
-	startPf, e := MakePriceFeed(config.StartAskFeedType, config.StartAskFeedURL)
+	startPf, e := priceFeedFactory.MakePriceFeed(config.StartAskFeedType, config.StartAskFeedURL)
 	if e != nil {
 		return nil, fmt.Errorf("error when making the start priceFeed: %s", e)
 	}
@@ -44,8 +45,9 @@ func makeBuyTwapStrategy(
 		config.DistributeSurplusOverRemainingIntervalsPercentCeiling,
 		config.ExponentialSmoothingFactor,
 		config.MinChildOrderSizePercentOfParent,
-		time.Now().UnixNano(),
+		clock.Now().UnixNano(),
 		true,
+		clock,
 	)
 	if e != nil {
 		return nil, fmt.Errorf("error when making a sellTwapLevelProvider: %s", e)
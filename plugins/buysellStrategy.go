@@ -1,7 +1,9 @@
 package plugins
 
 import (
+	"database/sql"
 	"fmt"
+	"time"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/api"
@@ -22,6 +24,21 @@ type BuySellConfig struct {
 	DataTypeB              string        `valid:"-" toml:"DATA_TYPE_B" json:"data_type_b"`
 	DataFeedBURL           string        `valid:"-" toml:"DATA_FEED_B_URL" json:"data_feed_b_url"`
 	Levels                 []StaticLevel `valid:"-" toml:"LEVELS" json:"levels"`
+
+	// optional params to skew the center price and amounts based on the inventory, leave both skews at 0 to quote symmetrically
+	InventoryTargetBasePercent float64 `valid:"-" toml:"INVENTORY_TARGET_BASE_PERCENT" json:"inventory_target_base_percent"` // share of the total value we want to hold in the base asset, specified as a decimal
+	InventoryPriceSkew         float64 `valid:"-" toml:"INVENTORY_PRICE_SKEW" json:"inventory_price_skew"`                   // how far the center price moves per unit of deviation from the target
+	InventoryAmountSkew        float64 `valid:"-" toml:"INVENTORY_AMOUNT_SKEW" json:"inventory_amount_skew"`                 // how much the amounts on each side change per unit of deviation from the target
+
+	// optional params to adjust the levels based on volatility, leave VOLATILITY_SOURCE empty to use the static levels
+	VolatilitySource        string  `valid:"-" toml:"VOLATILITY_SOURCE" json:"volatility_source"`                 // "trades" or "feed"
+	VolatilityWindowSeconds int64   `valid:"-" toml:"VOLATILITY_WINDOW_SECONDS" json:"volatility_window_seconds"` // lookback window used to estimate volatility
+	MinVolatilitySamples    int     `valid:"-" toml:"MIN_VOLATILITY_SAMPLES" json:"min_volatility_samples"`       // number of price samples needed before volatility is used
+	ReferenceVolatility     float64 `valid:"-" toml:"REFERENCE_VOLATILITY" json:"reference_volatility"`           // volatility over the window at which the configured levels are used as-is
+	MinVolatilityMultiplier float64 `valid:"-" toml:"MIN_VOLATILITY_MULTIPLIER" json:"min_volatility_multiplier"` // lower bound on the factor applied to the spread of each level
+	MaxVolatilityMultiplier float64 `valid:"-" toml:"MAX_VOLATILITY_MULTIPLIER" json:"max_volatility_multiplier"` // upper bound on the factor applied to the spread of each level
+	MinSpread               float64 `valid:"-" toml:"MIN_SPREAD" json:"min_spread"`                               // floor on the spread of each level, specified as a decimal
+	MaxSpread               float64 `valid:"-" toml:"MAX_SPREAD" json:"max_spread"`                               // ceiling on the spread of each level, specified as a decimal (0 disables)
 }
 
 // MakeBuysellConfig factory method
@@ -65,6 +82,10 @@ func makeBuySellStrategy(
 	ieif *IEIF,
 	assetBase *hProtocol.Asset,
 	assetQuote *hProtocol.Asset,
+	marketID string,
+	db *sql.DB,
+	clock api.Clock,
+	priceFeedFactory *PriceFeedFactory,
 	config *BuySellConfig,
 ) (api.Strategy, error) {
 	offsetSell := rateOffset{
@@ -72,7 +93,7 @@ func makeBuySellStrategy(
 		absolute:     config.RateOffset,
 		percentFirst: config.RateOffsetPercentFirst,
 	}
-	sellSideFeedPair, e := MakeFeedPair(
+	sellSideFeedPair, e := priceFeedFactory.MakeFeedPair(
 		config.DataTypeA,
 		config.DataFeedAURL,
 		config.DataTypeB,
@@ -82,19 +103,29 @@ func makeBuySellStrategy(
 		return nil, fmt.Errorf("cannot make the buysell strategy because we could not make the sell side feed pair: %s", e)
 	}
 	orderConstraints := sdex.GetOrderConstraints(pair)
+	sellSideLevelProvider, e := makeBuySellLevelProvider(
+		config,
+		offsetSell,
+		sellSideFeedPair,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		false,
+		marketID,
+		db,
+		clock,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy because we could not make the sell side level provider: %s", e)
+	}
 	sellSideStrategy := makeSellSideStrategy(
 		sdex,
 		orderConstraints,
 		ieif,
 		assetBase,
 		assetQuote,
-		makeStaticSpreadLevelProvider(
-			config.Levels,
-			config.AmountOfABase,
-			offsetSell,
-			sellSideFeedPair,
-			orderConstraints,
-		),
+		sellSideLevelProvider,
 		config.PriceTolerance,
 		config.AmountTolerance,
 		false,
@@ -106,7 +137,7 @@ func makeBuySellStrategy(
 		percentFirst: config.RateOffsetPercentFirst,
 		invert:       true,
 	}
-	buySideFeedPair, e := MakeFeedPair(
+	buySideFeedPair, e := priceFeedFactory.MakeFeedPair(
 		config.DataTypeB,
 		config.DataFeedBURL,
 		config.DataTypeA,
@@ -115,6 +146,22 @@ func makeBuySellStrategy(
 	if e != nil {
 		return nil, fmt.Errorf("cannot make the buysell strategy because we could not make the buy side feed pair: %s", e)
 	}
+	buySideLevelProvider, e := makeBuySellLevelProvider(
+		config,
+		offsetBuy,
+		buySideFeedPair,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		true,
+		marketID,
+		db,
+		clock,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the buysell strategy because we could not make the buy side level provider: %s", e)
+	}
 	// switch sides of base/quote here for buy side
 	buySideStrategy := makeSellSideStrategy(
 		sdex,
@@ -122,13 +169,7 @@ func makeBuySellStrategy(
 		ieif,
 		assetQuote,
 		assetBase,
-		makeStaticSpreadLevelProvider(
-			config.Levels,
-			config.AmountOfABase,
-			offsetBuy,
-			buySideFeedPair,
-			orderConstraints,
-		),
+		buySideLevelProvider,
 		config.PriceTolerance,
 		config.AmountTolerance,
 		true,
@@ -141,3 +182,64 @@ func makeBuySellStrategy(
 		sellSideStrategy,
 	), nil
 }
+
+// makeBuySellLevelProvider makes the level provider for one side, wrapping the static levels with a volatility adjustment when configured
+func makeBuySellLevelProvider(
+	config *BuySellConfig,
+	offset rateOffset,
+	pf *api.FeedPair,
+	orderConstraints *model.OrderConstraints,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	isBuySide bool,
+	marketID string,
+	db *sql.DB,
+	clock api.Clock,
+) (api.LevelProvider, error) {
+	skew, e := makeInventorySkew(
+		ieif,
+		*assetBase,
+		*assetQuote,
+		config.InventoryTargetBasePercent,
+		config.InventoryPriceSkew,
+		config.InventoryAmountSkew,
+		isBuySide,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the inventory skew: %s", e)
+	}
+	static := makeStaticSpreadLevelProvider(
+		config.Levels,
+		config.AmountOfABase,
+		offset,
+		pf,
+		orderConstraints,
+		skew,
+	)
+	if config.VolatilitySource == "" {
+		return static, nil
+	}
+
+	// each side has its own estimator so the samples from the feed are always in the same direction
+	estimator, e := makeVolatilityEstimator(
+		config.VolatilitySource,
+		time.Duration(config.VolatilityWindowSeconds)*time.Second,
+		config.MinVolatilitySamples,
+		db,
+		marketID,
+		clock,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the volatility estimator: %s", e)
+	}
+	return makeVolatilitySpreadLevelProvider(
+		static.(*staticSpreadLevelProvider),
+		estimator,
+		config.ReferenceVolatility,
+		config.MinVolatilityMultiplier,
+		config.MaxVolatilityMultiplier,
+		config.MinSpread,
+		config.MaxSpread,
+	)
+}
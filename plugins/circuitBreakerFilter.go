@@ -0,0 +1,199 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// CircuitBreakerFilterConfig trips the circuit breaker when the reference price moves by more than ThresholdPct within the Window,
+// after which all offers are deleted until the Cooldown has passed
+type CircuitBreakerFilterConfig struct {
+	ThresholdPct float64
+	Window       time.Duration
+	Cooldown     time.Duration
+}
+
+// Validate ensures validity
+func (c *CircuitBreakerFilterConfig) Validate() error {
+	if c.ThresholdPct <= 0 {
+		return fmt.Errorf("invalid threshold: needs to be greater than 0 but was %f", c.ThresholdPct)
+	}
+
+	if c.Window <= 0 {
+		return fmt.Errorf("invalid window: needs to be greater than 0 but was %s", c.Window)
+	}
+
+	if c.Cooldown <= 0 {
+		return fmt.Errorf("invalid cooldown: needs to be greater than 0 but was %s", c.Cooldown)
+	}
+
+	return nil
+}
+
+// String is the stringer method
+func (c *CircuitBreakerFilterConfig) String() string {
+	return fmt.Sprintf("CircuitBreakerFilterConfig[ThresholdPct=%.4f, Window=%s, Cooldown=%s]", c.ThresholdPct, c.Window, c.Cooldown)
+}
+
+type circuitBreakerSample struct {
+	time  time.Time
+	price float64
+}
+
+type circuitBreakerFilter struct {
+	name         string
+	configValue  string
+	baseAsset    hProtocol.Asset
+	quoteAsset   hProtocol.Asset
+	config       *CircuitBreakerFilterConfig
+	pf           api.PriceFeed
+	clock        api.Clock
+	alert        api.Alert
+	samples      []circuitBreakerSample
+	trippedUntil *time.Time
+}
+
+// makeFilterCircuitBreaker makes a submit filter that deletes all offers for a cooldown period when the reference price moves too much
+func makeFilterCircuitBreaker(
+	configValue string,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	pf api.PriceFeed,
+	clock api.Clock,
+	alert api.Alert,
+	config *CircuitBreakerFilterConfig,
+) (SubmitFilter, error) {
+	e := config.Validate()
+	if e != nil {
+		return nil, fmt.Errorf("invalid config: %s", e)
+	}
+
+	return &circuitBreakerFilter{
+		name:         "circuitBreakerFilter",
+		configValue:  configValue,
+		baseAsset:    baseAsset,
+		quoteAsset:   quoteAsset,
+		config:       config,
+		pf:           pf,
+		clock:        clock,
+		alert:        alert,
+		samples:      []circuitBreakerSample{},
+		trippedUntil: nil,
+	}, nil
+}
+
+var _ SubmitFilter = &circuitBreakerFilter{}
+
+func (f *circuitBreakerFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	now := f.clock.Now()
+	// reset before getting the price so the circuit breaker does not stay tripped when the reference price is unavailable
+	if f.trippedUntil != nil && !now.Before(*f.trippedUntil) {
+		f.reset()
+	}
+
+	price, e := f.pf.GetPrice()
+	if e != nil {
+		if f.trippedUntil == nil {
+			return nil, fmt.Errorf("could not get the reference price: %s", e)
+		}
+		log.Printf("circuitBreakerFilter: could not get the reference price during the cooldown: %s\n", e)
+	} else {
+		f.update(now, price)
+	}
+
+	if f.trippedUntil == nil {
+		return ops, nil
+	}
+
+	log.Printf("circuitBreakerFilter: circuit breaker is tripped until %s, deleting all offers\n", f.trippedUntil.Format(time.RFC3339))
+	deleteFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return nil, nil
+	}
+	ops, e = filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, deleteFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+// reset resets the circuit breaker once the cooldown is over
+func (f *circuitBreakerFilter) reset() {
+	log.Printf("circuitBreakerFilter: cooldown is over, resetting the circuit breaker that was tripped until %s\n", f.trippedUntil.Format(time.RFC3339))
+	f.trippedUntil = nil
+	// prices recorded during the cooldown can include the move that tripped the circuit breaker so we start the window over
+	f.samples = []circuitBreakerSample{}
+	f.triggerAlert("circuit breaker reset", map[string]interface{}{})
+}
+
+// update records the price and trips the circuit breaker when the move within the window exceeds the threshold
+func (f *circuitBreakerFilter) update(now time.Time, price float64) {
+	f.samples = append(f.samples, circuitBreakerSample{time: now, price: price})
+	cutoff := now.Add(-f.config.Window)
+	firstInWindow := 0
+	for firstInWindow < len(f.samples) && f.samples[firstInWindow].time.Before(cutoff) {
+		firstInWindow++
+	}
+	f.samples = f.samples[firstInWindow:]
+
+	if f.trippedUntil != nil {
+		return
+	}
+
+	movePct := f.movePct()
+	log.Printf("circuitBreakerFilter: price = %.10f, move within the last %s = %.4f%% (%s)\n", price, f.config.Window, movePct, f.config)
+	if movePct <= f.config.ThresholdPct {
+		return
+	}
+
+	trippedUntil := now.Add(f.config.Cooldown)
+	f.trippedUntil = &trippedUntil
+	log.Printf("circuitBreakerFilter: tripped the circuit breaker until %s because the move (%.4f%%) exceeded the threshold (%.4f%%)\n",
+		trippedUntil.Format(time.RFC3339), movePct, f.config.ThresholdPct)
+	f.triggerAlert("circuit breaker tripped", map[string]interface{}{
+		"price":         price,
+		"move_pct":      movePct,
+		"threshold_pct": f.config.ThresholdPct,
+		"window":        f.config.Window.String(),
+		"tripped_until": trippedUntil.Format(time.RFC3339),
+	})
+}
+
+// movePct is the difference between the highest and lowest prices in the window as a percentage of the lowest price
+func (f *circuitBreakerFilter) movePct() float64 {
+	min := math.Inf(1)
+	max := math.Inf(-1)
+	for _, s := range f.samples {
+		min = math.Min(min, s.price)
+		max = math.Max(max, s.price)
+	}
+	if len(f.samples) < 2 || min <= 0 {
+		return 0
+	}
+	return 100 * (max - min) / min
+}
+
+func (f *circuitBreakerFilter) triggerAlert(event string, details map[string]interface{}) {
+	if f.alert == nil {
+		return
+	}
+
+	details["base_asset"] = utils.Asset2String(f.baseAsset)
+	details["quote_asset"] = utils.Asset2String(f.quoteAsset)
+	description := fmt.Sprintf("%s for %s/%s", event, utils.Asset2CodeString(f.baseAsset), utils.Asset2CodeString(f.quoteAsset))
+	e := f.alert.Trigger(description, details)
+	if e != nil {
+		log.Printf("circuitBreakerFilter: could not trigger alert '%s': %s\n", description, e)
+	}
+}
+
+// String is the Stringer method
+func (f *circuitBreakerFilter) String() string {
+	return f.configValue
+}
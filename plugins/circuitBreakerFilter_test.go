@@ -0,0 +1,179 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingAlert keeps the descriptions of the triggered alerts
+type recordingAlert struct {
+	descriptions []string
+}
+
+func (a *recordingAlert) Trigger(description string, details interface{}) error {
+	a.descriptions = append(a.descriptions, description)
+	return nil
+}
+
+func TestCircuitBreakerFilter(t *testing.T) {
+	config := &CircuitBreakerFilterConfig{
+		ThresholdPct: 5.0,
+		Window:       5 * time.Minute,
+		Cooldown:     10 * time.Minute,
+	}
+	steps := []struct {
+		name        string
+		advance     time.Duration
+		price       float64
+		wantTripped bool
+		wantAlerts  int
+	}{
+		{name: "first price", advance: 0, price: 1.00, wantTripped: false, wantAlerts: 0},
+		{name: "small move", advance: time.Minute, price: 1.04, wantTripped: false, wantAlerts: 0},
+		{name: "large move outside the window", advance: 5 * time.Minute, price: 1.09, wantTripped: false, wantAlerts: 0},
+		{name: "large move within the window", advance: time.Minute, price: 1.15, wantTripped: true, wantAlerts: 1},
+		{name: "feed error during cooldown", advance: time.Minute, price: 0, wantTripped: true, wantAlerts: 1},
+		{name: "during cooldown", advance: 8 * time.Minute, price: 1.16, wantTripped: true, wantAlerts: 1},
+		{name: "cooldown is over", advance: time.Minute, price: 1.15, wantTripped: false, wantAlerts: 2},
+		{name: "trips again", advance: time.Minute, price: 1.00, wantTripped: true, wantAlerts: 3},
+	}
+
+	prices := []float64{}
+	for _, s := range steps {
+		prices = append(prices, s.price)
+	}
+	clock := MakeVirtualClock(time.Unix(0, 0))
+	alert := &recordingAlert{}
+	base := utils.Asset2Asset2(testBaseAsset)
+	quote := utils.Asset2Asset2(testQuoteAsset)
+	f, e := makeFilterCircuitBreaker("circuitBreaker/5.0/5m/10m", base, quote, makeFakePriceFeed(prices...), clock, alert, config)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	for _, s := range steps {
+		clock.Advance(s.advance)
+		ops, e := f.Apply([]txnbuild.Operation{
+			makeSellOpAmtPrice(10.0, 1.2),
+			makeBuyOpAmtPrice(10.0, 0.9),
+		}, nil, nil)
+		if !assert.NoError(t, e, s.name) {
+			return
+		}
+
+		if s.wantTripped {
+			// new offers are dropped when the circuit breaker is tripped
+			assert.Equal(t, 0, len(ops), s.name)
+		} else {
+			assert.Equal(t, 2, len(ops), s.name)
+		}
+		assert.Equal(t, s.wantAlerts, len(alert.descriptions), s.name)
+	}
+	assert.Equal(t, "circuit breaker tripped for XLM/QUOTE", alert.descriptions[0])
+	assert.Equal(t, "circuit breaker reset for XLM/QUOTE", alert.descriptions[1])
+}
+
+func TestCircuitBreakerFilter_FeedError(t *testing.T) {
+	config := &CircuitBreakerFilterConfig{ThresholdPct: 5.0, Window: time.Minute, Cooldown: time.Minute}
+	f, e := makeFilterCircuitBreaker(
+		"circuitBreaker/5.0/1m/1m",
+		utils.Asset2Asset2(testBaseAsset),
+		utils.Asset2Asset2(testQuoteAsset),
+		makeFakePriceFeed(0),
+		MakeVirtualClock(time.Unix(0, 0)),
+		nil,
+		config,
+	)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// the filter cannot decide whether to keep the offers without a price when the circuit breaker is not tripped
+	_, e = f.Apply([]txnbuild.Operation{makeSellOpAmtPrice(10.0, 1.2)}, nil, nil)
+	assert.Error(t, e)
+}
+
+func TestCircuitBreakerFilter_ResetWithoutPrice(t *testing.T) {
+	config := &CircuitBreakerFilterConfig{ThresholdPct: 5.0, Window: 5 * time.Minute, Cooldown: 2 * time.Minute}
+	clock := MakeVirtualClock(time.Unix(0, 0))
+	alert := &recordingAlert{}
+	f, e := makeFilterCircuitBreaker(
+		"circuitBreaker/5.0/5m/2m/fixed/1.0",
+		utils.Asset2Asset2(testBaseAsset),
+		utils.Asset2Asset2(testQuoteAsset),
+		makeFakePriceFeed(1.0, 1.2, 1.0, 0, 1.2),
+		clock,
+		alert,
+		config,
+	)
+	if !assert.NoError(t, e) {
+		return
+	}
+	ops := []txnbuild.Operation{makeSellOpAmtPrice(10.0, 1.2)}
+
+	// trips at 1.2 and records 1.0 during the cooldown
+	for i, wantLen := range []int{1, 0, 0} {
+		filtered, e := f.Apply(ops, nil, nil)
+		if !assert.NoError(t, e) {
+			return
+		}
+		assert.Equal(t, wantLen, len(filtered), i)
+		clock.Advance(time.Minute)
+	}
+	assert.Equal(t, 1, len(alert.descriptions))
+
+	// the cooldown is over so the circuit breaker resets even though the feed fails
+	_, e = f.Apply(ops, nil, nil)
+	assert.Error(t, e)
+	assert.Equal(t, 2, len(alert.descriptions))
+
+	// the prices recorded during the cooldown are cleared so the move from 1.0 to 1.2 does not trip it again
+	clock.Advance(time.Minute)
+	filtered, e := f.Apply(ops, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 1, len(filtered))
+	assert.Equal(t, 2, len(alert.descriptions))
+}
+
+func TestCircuitBreakerFilterConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    *CircuitBreakerFilterConfig
+		wantError bool
+	}{
+		{
+			name:      "valid",
+			config:    &CircuitBreakerFilterConfig{ThresholdPct: 5.0, Window: time.Minute, Cooldown: time.Hour},
+			wantError: false,
+		}, {
+			name:      "zero threshold",
+			config:    &CircuitBreakerFilterConfig{ThresholdPct: 0, Window: time.Minute, Cooldown: time.Hour},
+			wantError: true,
+		}, {
+			name:      "zero window",
+			config:    &CircuitBreakerFilterConfig{ThresholdPct: 5.0, Window: 0, Cooldown: time.Hour},
+			wantError: true,
+		}, {
+			name:      "negative cooldown",
+			config:    &CircuitBreakerFilterConfig{ThresholdPct: 5.0, Window: time.Minute, Cooldown: -time.Hour},
+			wantError: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			e := k.config.Validate()
+			if k.wantError {
+				assert.Error(t, e)
+			} else {
+				assert.NoError(t, e)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package plugins
+
+import (
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// realClock uses the system clock
+type realClock struct{}
+
+// ensure it implements the Clock interface
+var _ api.Clock = realClock{}
+
+// MakeRealClock is a factory method for a clock backed by the system time
+func MakeRealClock() api.Clock {
+	return realClock{}
+}
+
+// Now impl
+func (c realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep impl
+func (c realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// VirtualClock is a clock that only moves forward when it is told to, sleeping on this clock advances it instantly
+type VirtualClock struct {
+	now  time.Time
+	lock *sync.Mutex
+}
+
+// ensure it implements the Clock interface
+var _ api.Clock = &VirtualClock{}
+
+// MakeVirtualClock is a factory method
+func MakeVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{
+		now:  start,
+		lock: &sync.Mutex{},
+	}
+}
+
+// Now impl
+func (c *VirtualClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// Sleep impl, fast-forwards the clock instead of blocking
+func (c *VirtualClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// Advance moves the clock forward by the given duration, negative durations are ignored
+func (c *VirtualClock) Advance(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to the given time, the clock never moves backwards
+func (c *VirtualClock) Set(t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if t.After(c.now) {
+		c.now = t
+	}
+}
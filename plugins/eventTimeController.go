@@ -0,0 +1,164 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// EventTimeController runs the update cycle when the reference price moves by more than a threshold or when one of our offers
+// is filled (an offer that is crossed on SDEX is always filled), no more often than minInterval and no less often than maxInterval
+type EventTimeController struct {
+	minInterval          time.Duration
+	maxInterval          time.Duration
+	pollInterval         time.Duration
+	priceFeed            api.PriceFeed // can be nil
+	priceChangeThreshold float64
+	clock                api.Clock
+
+	// initialized runtime vars
+	mutex *sync.Mutex
+
+	// uninitialized
+	referencePrice float64
+	pendingTrigger string
+}
+
+var _ api.TimeController = &EventTimeController{}
+
+// enforce EventTimeController implementing api.FillHandler so it can be registered with the fill tracker
+var _ api.FillHandler = &EventTimeController{}
+
+// MakeEventTimeController is a factory method
+func MakeEventTimeController(
+	minInterval time.Duration,
+	maxInterval time.Duration,
+	pollInterval time.Duration,
+	priceFeed api.PriceFeed,
+	priceChangeThreshold float64,
+	clock api.Clock,
+) (*EventTimeController, error) {
+	if minInterval < 0 {
+		return nil, fmt.Errorf("minInterval cannot be negative, was %s", minInterval)
+	}
+	if maxInterval < minInterval {
+		return nil, fmt.Errorf("maxInterval (%s) needs to be greater than or equal to minInterval (%s)", maxInterval, minInterval)
+	}
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("pollInterval needs to be greater than 0, was %s", pollInterval)
+	}
+	if priceFeed != nil && priceChangeThreshold <= 0 {
+		return nil, fmt.Errorf("priceChangeThreshold needs to be greater than 0 when using a price feed, was %f", priceChangeThreshold)
+	}
+
+	return &EventTimeController{
+		minInterval:          minInterval,
+		maxInterval:          maxInterval,
+		pollInterval:         pollInterval,
+		priceFeed:            priceFeed,
+		priceChangeThreshold: priceChangeThreshold,
+		clock:                clock,
+		mutex:                &sync.Mutex{},
+	}, nil
+}
+
+// Trigger requests an update cycle as soon as minInterval has elapsed since the last update, it is safe to call from any goroutine
+func (t *EventTimeController) Trigger(reason string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.pendingTrigger == "" {
+		log.Printf("eventTimeController triggered: %s\n", reason)
+		t.pendingTrigger = reason
+	}
+}
+
+// HandleFill impl, triggers an update cycle whenever one of our offers is filled
+func (t *EventTimeController) HandleFill(trade model.Trade) error {
+	t.Trigger(fmt.Sprintf("fill on order %s", trade.OrderID))
+	return nil
+}
+
+// StreamTrades triggers an update cycle whenever horizon streams a new trade for the account, it blocks until the stream ends
+func (t *EventTimeController) StreamTrades(ctx context.Context, client *horizonclient.Client, accountID string) error {
+	tradeReq := horizonclient.TradeRequest{
+		ForAccount: accountID,
+		Cursor:     "now",
+	}
+	return client.StreamTrades(ctx, tradeReq, func(trade hProtocol.Trade) {
+		t.Trigger(fmt.Sprintf("trade %s streamed from horizon", trade.ID))
+	})
+}
+
+// ShouldUpdate impl
+func (t *EventTimeController) ShouldUpdate(lastUpdateTime time.Time, currentUpdateTime time.Time) bool {
+	elapsedSinceUpdate := currentUpdateTime.Sub(lastUpdateTime)
+	if elapsedSinceUpdate < t.minInterval {
+		return false
+	}
+
+	reason := t.takePendingTrigger()
+	if reason == "" && elapsedSinceUpdate >= t.maxInterval {
+		reason = fmt.Sprintf("maxInterval (%s) elapsed", t.maxInterval)
+	}
+
+	if t.priceFeed != nil {
+		price, e := t.priceFeed.GetPrice()
+		if e != nil {
+			// fall back to updating on maxInterval and fills when the feed is unavailable
+			log.Printf("eventTimeController could not fetch price from feed: %s\n", e)
+		} else if t.referencePrice == 0 {
+			t.referencePrice = price
+		} else {
+			change := math.Abs(price/t.referencePrice - 1)
+			if reason == "" && change >= t.priceChangeThreshold {
+				reason = fmt.Sprintf("price moved by %.4f%% from %.8f to %.8f", change*100, t.referencePrice, price)
+			}
+			if reason != "" {
+				// the update cycle will use this price so it becomes the reference for the next update
+				t.referencePrice = price
+			}
+		}
+	}
+
+	shouldUpdate := reason != ""
+	log.Printf("eventTimeController shouldUpdate=%v, elapsedSinceUpdate=%s, reason='%s'\n", shouldUpdate, elapsedSinceUpdate, reason)
+	return shouldUpdate
+}
+
+func (t *EventTimeController) takePendingTrigger() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	reason := t.pendingTrigger
+	t.pendingTrigger = ""
+	return reason
+}
+
+// SleepTime impl
+func (t *EventTimeController) SleepTime(lastUpdateTime time.Time) time.Duration {
+	return t.sleepTimeInternal(lastUpdateTime, t.clock.Now())
+}
+
+func (t *EventTimeController) sleepTimeInternal(lastUpdateTime time.Time, realNow time.Time) time.Duration {
+	elapsedSinceUpdate := realNow.Sub(lastUpdateTime)
+	if elapsedSinceUpdate < t.minInterval {
+		// nothing can trigger an update before minInterval so sleep until then
+		return t.minInterval - elapsedSinceUpdate
+	}
+
+	untilMaxInterval := t.maxInterval - elapsedSinceUpdate
+	if untilMaxInterval > 0 && untilMaxInterval < t.pollInterval {
+		return untilMaxInterval
+	}
+	return t.pollInterval
+}
@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestEventTimeControllerShouldUpdate(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := MakeVirtualClock(start)
+	feed, e := newFixedFeed("1.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+	tc, e := MakeEventTimeController(5*time.Second, time.Minute, time.Second, feed, 0.01, clock)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// the first check sets the reference price
+	assert.False(t, tc.ShouldUpdate(start, start.Add(10*time.Second)))
+
+	// a small move does not trigger an update
+	feed.price = 1.005
+	assert.False(t, tc.ShouldUpdate(start, start.Add(11*time.Second)))
+
+	// a large move triggers an update, but not before minInterval
+	feed.price = 1.02
+	assert.False(t, tc.ShouldUpdate(start, start.Add(4*time.Second)))
+	assert.True(t, tc.ShouldUpdate(start, start.Add(12*time.Second)))
+	// the price at the update becomes the new reference
+	assert.False(t, tc.ShouldUpdate(start, start.Add(13*time.Second)))
+
+	// a fill triggers an update once
+	e = tc.HandleFill(model.Trade{OrderID: "1"})
+	assert.NoError(t, e)
+	assert.True(t, tc.ShouldUpdate(start, start.Add(14*time.Second)))
+	assert.False(t, tc.ShouldUpdate(start, start.Add(15*time.Second)))
+
+	// maxInterval always triggers an update
+	assert.True(t, tc.ShouldUpdate(start, start.Add(time.Minute)))
+}
+
+func TestEventTimeControllerSleepTime(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	tc, e := MakeEventTimeController(5*time.Second, time.Minute, 2*time.Second, nil, 0, MakeVirtualClock(start))
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	testCases := []struct {
+		name          string
+		elapsed       time.Duration
+		wantSleepTime time.Duration
+	}{
+		{name: "before minInterval", elapsed: time.Second, wantSleepTime: 4 * time.Second},
+		{name: "polling", elapsed: 10 * time.Second, wantSleepTime: 2 * time.Second},
+		{name: "close to maxInterval", elapsed: 59 * time.Second, wantSleepTime: time.Second},
+		{name: "after maxInterval", elapsed: 2 * time.Minute, wantSleepTime: 2 * time.Second},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.wantSleepTime, tc.sleepTimeInternal(start, start.Add(k.elapsed)))
+		})
+	}
+}
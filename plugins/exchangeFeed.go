@@ -8,32 +8,47 @@ import (
 	"github.com/stellar/kelp/model"
 )
 
-// encapsulates a priceFeed from a tickerAPI
+// encapsulates a priceFeed from a tickerAPI, or from the orderbook when using a vwap modifier
 type exchangeFeed struct {
-	name      string
-	tickerAPI *api.TickerAPI
-	pairs     []model.TradingPair
-	modifier  string
+	name             string
+	tickerAPI        *api.TickerAPI
+	orderbookFetcher api.OrderbookFetcher
+	pairs            []model.TradingPair
+	modifier         string
+	vwap             *vwapModifier // nil when not using a vwap modifier
 }
 
 // ensure that it implements PriceFeed
 var _ api.PriceFeed = &exchangeFeed{}
 
-func newExchangeFeed(name string, tickerAPI *api.TickerAPI, pair *model.TradingPair, modifier string) (*exchangeFeed, error) {
-	if modifier != "mid" && modifier != "ask" && modifier != "bid" && modifier != "last" {
+func newExchangeFeed(name string, tickerAPI *api.TickerAPI, orderbookFetcher api.OrderbookFetcher, pair *model.TradingPair, modifier string) (*exchangeFeed, error) {
+	var vwap *vwapModifier
+	if isVwapModifier(modifier) {
+		var e error
+		vwap, e = parseVwapModifier(modifier)
+		if e != nil {
+			return nil, fmt.Errorf("unsupported modifier '%s' on exchange type URL: %s", modifier, e)
+		}
+	} else if modifier != "mid" && modifier != "ask" && modifier != "bid" && modifier != "last" {
 		return nil, fmt.Errorf("unsupported modifier '%s' on exchange type URL", modifier)
 	}
 
 	return &exchangeFeed{
-		name:      name,
-		tickerAPI: tickerAPI,
-		pairs:     []model.TradingPair{*pair},
-		modifier:  modifier,
+		name:             name,
+		tickerAPI:        tickerAPI,
+		orderbookFetcher: orderbookFetcher,
+		pairs:            []model.TradingPair{*pair},
+		modifier:         modifier,
+		vwap:             vwap,
 	}, nil
 }
 
 // GetPrice impl
 func (f *exchangeFeed) GetPrice() (float64, error) {
+	if f.vwap != nil {
+		return f.getVwapPrice()
+	}
+
 	tickerAPI := *f.tickerAPI
 	m, e := tickerAPI.GetTickerPrice(f.pairs)
 	if e != nil {
@@ -58,14 +73,38 @@ func (f *exchangeFeed) GetPrice() (float64, error) {
 		price = midPrice
 	}
 
+	if price == nil {
+		return 0, fmt.Errorf("exchange feed (%s) did not return a price for modifier '%s'", f.name, f.modifier)
+	}
+
+	// the last trade price can be nil on tickers that are built from the orderbook
+	lastPriceString := "<nil>"
+	if p.LastPrice != nil {
+		lastPriceString = p.LastPrice.AsString()
+	}
 	log.Printf("(modifier: %s) price from exchange feed (%s): bidPrice=%s, askPrice=%s, midPrice=%s, lastTradePrice=%s; price=%s",
 		f.modifier,
 		f.name,
 		p.BidPrice.AsString(),
 		p.AskPrice.AsString(),
 		midPrice.AsString(),
-		p.LastPrice.AsString(),
+		lastPriceString,
 		price.AsString(),
 	)
 	return price.AsFloat(), nil
 }
+
+func (f *exchangeFeed) getVwapPrice() (float64, error) {
+	ob, e := f.orderbookFetcher.GetOrderBook(&f.pairs[0], vwapOrderbookDepth)
+	if e != nil {
+		return 0, fmt.Errorf("error while getting orderbook from exchange feed: %s", e)
+	}
+
+	price, e := f.vwap.computeVwap(ob)
+	if e != nil {
+		return 0, fmt.Errorf("could not compute vwap price from exchange feed: %s", e)
+	}
+
+	log.Printf("(modifier: %s) price from exchange feed (%s): price=%.10f", f.modifier, f.name, price)
+	return price, nil
+}
@@ -0,0 +1,404 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/stellar/kelp/api"
+)
+
+/*
+the expr function feed evaluates an arithmetic expression over named price feeds and constants, formatted like so:
+	expr(<expression>;<name>=<feed_type>/<feed_url>[;<name>=<feed_type>/<feed_url>...])
+for example:
+	expr((kraken * 1.002) / eur + 0.0001; kraken=exchange/ccxt-kraken/XLM/USD/mid; eur=fiat/http://apilayer.net/api/live?access_key=&currencies=EUR)
+
+the expression supports +, -, *, /, parentheses, unary minus, and the min(...) and max(...) functions with 1 or more arguments
+*/
+
+// exprFunctionName is the name of the function feed that evaluates expressions
+const exprFunctionName = "expr"
+
+// exprSeparator separates the expression from the named feeds and the named feeds from each other
+const exprSeparator = ";"
+
+// exprNode is a node in the parsed expression
+type exprNode interface {
+	eval(prices map[string]float64) (float64, error)
+}
+
+type exprConstant struct {
+	value float64
+}
+
+func (n *exprConstant) eval(prices map[string]float64) (float64, error) {
+	return n.value, nil
+}
+
+type exprFeedRef struct {
+	name string
+}
+
+func (n *exprFeedRef) eval(prices map[string]float64) (float64, error) {
+	price, ok := prices[n.name]
+	if !ok {
+		return 0, fmt.Errorf("no price for feed '%s'", n.name)
+	}
+	return price, nil
+}
+
+type exprNegate struct {
+	operand exprNode
+}
+
+func (n *exprNegate) eval(prices map[string]float64) (float64, error) {
+	v, e := n.operand.eval(prices)
+	if e != nil {
+		return 0, e
+	}
+	return -v, nil
+}
+
+type exprBinaryOp struct {
+	op    byte
+	left  exprNode
+	right exprNode
+}
+
+func (n *exprBinaryOp) eval(prices map[string]float64) (float64, error) {
+	l, e := n.left.eval(prices)
+	if e != nil {
+		return 0, e
+	}
+	r, e := n.right.eval(prices)
+	if e != nil {
+		return 0, e
+	}
+
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero (%.10f / %.10f)", l, r)
+		}
+		return l / r, nil
+	}
+	return 0, fmt.Errorf("unsupported operator '%c'", n.op)
+}
+
+type exprMinMax struct {
+	isMax bool
+	args  []exprNode
+}
+
+func (n *exprMinMax) eval(prices map[string]float64) (float64, error) {
+	result := math.Inf(1)
+	if n.isMax {
+		result = math.Inf(-1)
+	}
+
+	for _, arg := range n.args {
+		v, e := arg.eval(prices)
+		if e != nil {
+			return 0, e
+		}
+		if n.isMax {
+			result = math.Max(result, v)
+		} else {
+			result = math.Min(result, v)
+		}
+	}
+	return result, nil
+}
+
+// exprParser is a recursive descent parser for expressions, which keeps track of the feed names that are referenced
+type exprParser struct {
+	input      string
+	pos        int
+	validNames map[string]bool
+	usedNames  map[string]bool
+}
+
+// parseExpr parses the expression, validating that it only references the passed in feed names
+func parseExpr(input string, validNames map[string]bool) (exprNode, map[string]bool, error) {
+	p := &exprParser{
+		input:      input,
+		validNames: validNames,
+		usedNames:  map[string]bool{},
+	}
+
+	node, e := p.parseSum()
+	if e != nil {
+		return nil, nil, e
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, nil, p.errorf("unexpected '%c'", p.input[p.pos])
+	}
+	return node, p.usedNames, nil
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d in expression '%s'", fmt.Sprintf(format, args...), p.pos, p.input)
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space character, or 0 at the end of the input
+func (p *exprParser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// parseSum := product (('+' | '-') product)*
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, e := p.parseProduct()
+	if e != nil {
+		return nil, e
+	}
+
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+
+		right, e := p.parseProduct()
+		if e != nil {
+			return nil, e
+		}
+		left = &exprBinaryOp{op: op, left: left, right: right}
+	}
+}
+
+// parseProduct := unary (('*' | '/') unary)*
+func (p *exprParser) parseProduct() (exprNode, error) {
+	left, e := p.parseUnary()
+	if e != nil {
+		return nil, e
+	}
+
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		p.pos++
+
+		right, e := p.parseUnary()
+		if e != nil {
+			return nil, e
+		}
+		left = &exprBinaryOp{op: op, left: left, right: right}
+	}
+}
+
+// parseUnary := '-' unary | primary
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peek() == '-' {
+		p.pos++
+		operand, e := p.parseUnary()
+		if e != nil {
+			return nil, e
+		}
+		return &exprNegate{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary := number | name | ('min' | 'max') '(' sum (',' sum)* ')' | '(' sum ')'
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, p.errorf("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		node, e := p.parseSum()
+		if e != nil {
+			return nil, e
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return node, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c == '_' || unicode.IsLetter(rune(c)):
+		return p.parseName()
+	}
+	return nil, p.errorf("unexpected '%c'", c)
+}
+
+func (p *exprParser) parseNumber() (exprNode, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] == '.' || (p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+		p.pos++
+	}
+
+	text := p.input[start:p.pos]
+	value, e := strconv.ParseFloat(text, 64)
+	if e != nil {
+		p.pos = start
+		return nil, p.errorf("invalid number '%s'", text)
+	}
+	return &exprConstant{value: value}, nil
+}
+
+func (p *exprParser) parseName() (exprNode, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] == '_' || unicode.IsLetter(rune(p.input[p.pos])) || unicode.IsDigit(rune(p.input[p.pos]))) {
+		p.pos++
+	}
+	name := p.input[start:p.pos]
+
+	if name == "min" || name == "max" {
+		return p.parseMinMax(name == "max")
+	}
+
+	if !p.validNames[name] {
+		p.pos = start
+		return nil, p.errorf("unknown feed name '%s'", name)
+	}
+	p.usedNames[name] = true
+	return &exprFeedRef{name: name}, nil
+}
+
+func (p *exprParser) parseMinMax(isMax bool) (exprNode, error) {
+	if p.peek() != '(' {
+		return nil, p.errorf("expected '(' after min or max")
+	}
+	p.pos++
+
+	args := []exprNode{}
+	for {
+		arg, e := p.parseSum()
+		if e != nil {
+			return nil, e
+		}
+		args = append(args, arg)
+
+		c := p.peek()
+		p.pos++
+		if c == ')' {
+			return &exprMinMax{isMax: isMax, args: args}, nil
+		} else if c != ',' {
+			p.pos--
+			return nil, p.errorf("expected ',' or ')'")
+		}
+	}
+}
+
+// exprFeed evaluates an expression over named price feeds
+type exprFeed struct {
+	expression string
+	root       exprNode
+	names      []string
+	feeds      []api.PriceFeed
+}
+
+var _ api.PriceFeed = &exprFeed{}
+
+// makeExprFeed makes the expr function feed from the arguments of the function
+func makeExprFeed(args string) (*exprFeed, error) {
+	parts := strings.Split(args, exprSeparator)
+	expression := strings.TrimSpace(parts[0])
+	if expression == "" {
+		return nil, fmt.Errorf("the '%s' price feed function needs an expression, formatted as %s(<expression>%s<name>=<feed_type>/<feed_url>...)", exprFunctionName, exprFunctionName, exprSeparator)
+	}
+
+	names := []string{}
+	feeds := []api.PriceFeed{}
+	validNames := map[string]bool{}
+	for _, feedPart := range parts[1:] {
+		nameAndSpec := strings.SplitN(strings.TrimSpace(feedPart), "=", 2)
+		if len(nameAndSpec) != 2 {
+			return nil, fmt.Errorf("unable to split named feed '%s' in the '%s' price feed function, needs to be formatted as <name>=<feed_type>/<feed_url>", feedPart, exprFunctionName)
+		}
+		name := strings.TrimSpace(nameAndSpec[0])
+		if !isValidExprName(name) {
+			return nil, fmt.Errorf("invalid feed name '%s' in the '%s' price feed function, needs to start with a letter or '_' and contain only letters, digits, and '_' (cannot be 'min' or 'max')", name, exprFunctionName)
+		}
+		if validNames[name] {
+			return nil, fmt.Errorf("feed name '%s' is defined more than once in the '%s' price feed function", name, exprFunctionName)
+		}
+
+		feed, e := MakePriceFeedFromSpec(strings.TrimSpace(nameAndSpec[1]))
+		if e != nil {
+			return nil, fmt.Errorf("error creating price feed '%s': %s", name, e)
+		}
+
+		names = append(names, name)
+		feeds = append(feeds, feed)
+		validNames[name] = true
+	}
+
+	root, usedNames, e := parseExpr(expression, validNames)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse expression in the '%s' price feed function: %s", exprFunctionName, e)
+	}
+	for _, name := range names {
+		if !usedNames[name] {
+			return nil, fmt.Errorf("feed '%s' is defined but not used in the expression '%s' of the '%s' price feed function", name, expression, exprFunctionName)
+		}
+	}
+
+	return &exprFeed{
+		expression: expression,
+		root:       root,
+		names:      names,
+		feeds:      feeds,
+	}, nil
+}
+
+func isValidExprName(name string) bool {
+	if name == "" || name == "min" || name == "max" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || unicode.IsLetter(c) || (i > 0 && unicode.IsDigit(c)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// GetPrice impl
+func (f *exprFeed) GetPrice() (float64, error) {
+	prices, e := fetchFeedPrices(exprFunctionName, f.feeds)
+	if e != nil {
+		return 0.0, e
+	}
+
+	pricesByName := map[string]float64{}
+	for i, name := range f.names {
+		pricesByName[name] = prices[i]
+	}
+
+	price, e := f.root.eval(pricesByName)
+	if e != nil {
+		return 0.0, fmt.Errorf("error evaluating expression '%s': %s", f.expression, e)
+	}
+	if price <= 0.0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		return 0.0, fmt.Errorf("expression '%s' evaluated to an invalid price (%.10f) with prices %v", f.expression, price, pricesByName)
+	}
+	return price, nil
+}
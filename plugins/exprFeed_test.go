@@ -0,0 +1,75 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExprFeed(t *testing.T) {
+	testCases := []struct {
+		url       string
+		wantPrice float64
+	}{
+		{url: "expr((a * 1.002) / b + 0.0001; a=fixed/0.1; b=fixed/0.5)", wantPrice: 0.2005},
+		{url: "expr(a - b * 2; a=fixed/5; b=fixed/1)", wantPrice: 3.0},
+		{url: "expr((a - b) * 2; a=fixed/5; b=fixed/1)", wantPrice: 8.0},
+		{url: "expr(-a + 2 * b;a=fixed/1;b=fixed/1)", wantPrice: 1.0},
+		{url: "expr(min(a, b, 3) + max(a, b); a=fixed/1; b=fixed/2)", wantPrice: 3.0},
+		{url: "expr(1.5)", wantPrice: 1.5},
+		{url: "expr(m * 2; m=function/max(fixed/1.0,fixed/4.0))", wantPrice: 8.0},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.url, func(t *testing.T) {
+			pf, e := makeFunctionPriceFeed(k.url)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			price, e := pf.GetPrice()
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 0.0000001)
+		})
+	}
+}
+
+func TestExprFeedInvalid(t *testing.T) {
+	testCases := []string{
+		"expr(; a=fixed/1)",
+		"expr(a + ; a=fixed/1)",
+		"expr((a + 1; a=fixed/1)",
+		"expr(a + b; a=fixed/1)",
+		"expr(a; a=fixed/1; b=fixed/2)",
+		"expr(a; a=fixed/1; a=fixed/2)",
+		"expr(a; 1a=fixed/1)",
+		"expr(a; min=fixed/1)",
+		"expr(a; a=fixed)",
+		"expr(max a; a=fixed/1)",
+		"expr(1..2)",
+		"expr:1(a; a=fixed/1)",
+	}
+
+	for _, url := range testCases {
+		t.Run(url, func(t *testing.T) {
+			_, e := makeFunctionPriceFeed(url)
+			assert.Error(t, e)
+		})
+	}
+}
+
+func TestExprFeedInvalidPrice(t *testing.T) {
+	for _, url := range []string{"expr(a - b; a=fixed/1; b=fixed/2)", "expr(a / (b - 1); a=fixed/1; b=fixed/1)"} {
+		t.Run(url, func(t *testing.T) {
+			pf, e := makeFunctionPriceFeed(url)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			_, e = pf.GetPrice()
+			assert.Error(t, e)
+		})
+	}
+}
@@ -15,19 +15,21 @@ import (
 
 // strategyFactoryData is a data container that has all the information needed to make a strategy
 type strategyFactoryData struct {
-	sdex            *SDEX
-	exchangeShim    api.ExchangeShim
-	tradeFetcher    api.TradeFetcher
-	ieif            *IEIF
-	tradingPair     *model.TradingPair
-	assetBase       *hProtocol.Asset
-	assetQuote      *hProtocol.Asset
-	marketID        string
-	stratConfigPath string
-	simMode         bool
-	isTradingSdex   bool
-	filterFactory   *FilterFactory
-	db              *sql.DB
+	sdex             *SDEX
+	exchangeShim     api.ExchangeShim
+	tradeFetcher     api.TradeFetcher
+	ieif             *IEIF
+	tradingPair      *model.TradingPair
+	assetBase        *hProtocol.Asset
+	assetQuote       *hProtocol.Asset
+	marketID         string
+	stratConfigPath  string
+	simMode          bool
+	isTradingSdex    bool
+	filterFactory    *FilterFactory
+	priceFeedFactory *PriceFeedFactory
+	db               *sql.DB
+	clock            api.Clock
 }
 
 // StrategyContainer contains the strategy factory method along with some metadata
@@ -57,7 +59,7 @@ var strategies = map[string]StrategyContainer{
 			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
 			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
 			utils.LogConfig(cfg)
-			s, e := makeBuySellStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg)
+			s, e := makeBuySellStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, strategyFactoryData.marketID, strategyFactoryData.db, strategyFactoryData.clock, strategyFactoryData.priceFeedFactory, &cfg)
 			if e != nil {
 				return nil, fmt.Errorf("makeFn failed: %s", e)
 			}
@@ -91,7 +93,7 @@ var strategies = map[string]StrategyContainer{
 			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
 			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
 			utils.LogConfig(cfg)
-			s, e := makeSellStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, &cfg)
+			s, e := makeSellStrategy(strategyFactoryData.sdex, strategyFactoryData.tradingPair, strategyFactoryData.ieif, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, strategyFactoryData.priceFeedFactory, &cfg)
 			if e != nil {
 				return nil, fmt.Errorf("makeFn failed: %s", e)
 			}
@@ -160,7 +162,9 @@ var strategies = map[string]StrategyContainer{
 				strategyFactoryData.assetBase,
 				strategyFactoryData.assetQuote,
 				strategyFactoryData.filterFactory,
+				strategyFactoryData.priceFeedFactory,
 				&cfg,
+				strategyFactoryData.clock,
 			)
 			if e != nil {
 				return nil, fmt.Errorf("makeFn failed: %s", e)
@@ -186,7 +190,9 @@ var strategies = map[string]StrategyContainer{
 				strategyFactoryData.assetBase,
 				strategyFactoryData.assetQuote,
 				strategyFactoryData.filterFactory,
+				strategyFactoryData.priceFeedFactory,
 				&cfg,
+				strategyFactoryData.clock,
 			)
 			if e != nil {
 				return nil, fmt.Errorf("make Fn failed: %s", e)
@@ -194,6 +200,79 @@ var strategies = map[string]StrategyContainer{
 			return s, nil
 		},
 	},
+	"avellaneda": {
+		SortOrder:   8,
+		Description: "Quotes around a reservation price that is skewed by inventory risk and volatility (Avellaneda-Stoikov)",
+		NeedsConfig: true,
+		Complexity:  "Advanced",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg avellanedaConfig
+			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeAvellanedaStrategy(
+				strategyFactoryData.sdex,
+				strategyFactoryData.tradingPair,
+				strategyFactoryData.ieif,
+				strategyFactoryData.assetBase,
+				strategyFactoryData.assetQuote,
+				strategyFactoryData.marketID,
+				strategyFactoryData.db,
+				strategyFactoryData.clock,
+				strategyFactoryData.priceFeedFactory,
+				&cfg,
+			)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
+	"grid": {
+		SortOrder:   9,
+		Description: "Maintains a fixed ladder of prices, buying below the last fill and selling above it",
+		NeedsConfig: true,
+		Complexity:  "Intermediate",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg gridConfig
+			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeGridStrategy(
+				strategyFactoryData.sdex,
+				strategyFactoryData.tradingPair,
+				strategyFactoryData.ieif,
+				strategyFactoryData.assetBase,
+				strategyFactoryData.assetQuote,
+				strategyFactoryData.marketID,
+				strategyFactoryData.db,
+				strategyFactoryData.clock,
+				strategyFactoryData.priceFeedFactory,
+				&cfg,
+			)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
+	"arbitrage": {
+		SortOrder:   10,
+		Description: "Takes crossed prices between SDEX and another exchange and hedges the fills on the other exchange",
+		NeedsConfig: true,
+		Complexity:  "Advanced",
+		makeFn: func(strategyFactoryData strategyFactoryData) (api.Strategy, error) {
+			var cfg arbitrageConfig
+			err := config.Read(strategyFactoryData.stratConfigPath, &cfg)
+			utils.CheckConfigError(cfg, err, strategyFactoryData.stratConfigPath)
+			utils.LogConfig(cfg)
+			s, e := makeArbitrageStrategy(strategyFactoryData.sdex, strategyFactoryData.ieif, strategyFactoryData.tradingPair, strategyFactoryData.assetBase, strategyFactoryData.assetQuote, strategyFactoryData.marketID, &cfg, strategyFactoryData.db, strategyFactoryData.simMode)
+			if e != nil {
+				return nil, fmt.Errorf("makeFn failed: %s", e)
+			}
+			return s, nil
+		},
+	},
 }
 
 // MakeStrategy makes a strategy
@@ -211,7 +290,9 @@ func MakeStrategy(
 	simMode bool,
 	isTradingSdex bool,
 	filterFactory *FilterFactory,
+	priceFeedFactory *PriceFeedFactory,
 	db *sql.DB,
+	clock api.Clock,
 ) (api.Strategy, error) {
 	log.Printf("Making strategy: %s\n", strategy)
 	if s, ok := strategies[strategy]; ok {
@@ -220,19 +301,21 @@ func MakeStrategy(
 		}
 
 		s, e := s.makeFn(strategyFactoryData{
-			sdex:            sdex,
-			exchangeShim:    exchangeShim,
-			tradeFetcher:    tradeFetcher,
-			ieif:            ieif,
-			tradingPair:     tradingPair,
-			assetBase:       assetBase,
-			assetQuote:      assetQuote,
-			marketID:        marketID,
-			stratConfigPath: stratConfigPath,
-			simMode:         simMode,
-			isTradingSdex:   isTradingSdex,
-			filterFactory:   filterFactory,
-			db:              db,
+			sdex:             sdex,
+			exchangeShim:     exchangeShim,
+			tradeFetcher:     tradeFetcher,
+			ieif:             ieif,
+			tradingPair:      tradingPair,
+			assetBase:        assetBase,
+			assetQuote:       assetQuote,
+			marketID:         marketID,
+			stratConfigPath:  stratConfigPath,
+			simMode:          simMode,
+			isTradingSdex:    isTradingSdex,
+			filterFactory:    filterFactory,
+			priceFeedFactory: priceFeedFactory,
+			db:               db,
+			clock:            clock,
 		})
 		if e != nil {
 			return nil, fmt.Errorf("cannot make '%s' strategy: %s", strategy, e)
@@ -0,0 +1,72 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stellar/kelp/api"
+)
+
+// fallbackFeedCount is used to give each fallback feed its own id so the stats of different fallback feeds are counted separately
+var fallbackFeedCount uint64
+
+// fallbackFeed returns the price from the first feed that does not return an error, trying the feeds in order
+type fallbackFeed struct {
+	id    uint64
+	feeds []api.PriceFeed
+
+	// initialized runtime vars
+	mutex           *sync.Mutex
+	lastSourceIndex int
+}
+
+var _ api.PriceFeed = &fallbackFeed{}
+
+// makeFallbackFeed is a factory method
+func makeFallbackFeed(feeds []api.PriceFeed) *fallbackFeed {
+	return &fallbackFeed{
+		id:              atomic.AddUint64(&fallbackFeedCount, 1),
+		feeds:           feeds,
+		mutex:           &sync.Mutex{},
+		lastSourceIndex: -1,
+	}
+}
+
+// GetPrice impl
+func (f *fallbackFeed) GetPrice() (float64, error) {
+	errors := []string{}
+	for i, feed := range f.feeds {
+		price, e := feed.GetPrice()
+		if e == nil && price <= 0.0 {
+			e = fmt.Errorf("price was <= 0.0 (%.10f)", price)
+		}
+		if e != nil {
+			log.Printf("fallback feed %d could not use source at index %d: %s\n", f.id, i, e)
+			recordPriceFeedEvent(fmt.Sprintf("fallback_%d_source_%d_error", f.id, i))
+			errors = append(errors, fmt.Sprintf("index=%d: %s", i, e))
+			continue
+		}
+
+		f.logSourceChange(i, price)
+		recordPriceFeedEvent(fmt.Sprintf("fallback_%d_source_%d_used", f.id, i))
+		return price, nil
+	}
+
+	recordPriceFeedEvent(fmt.Sprintf("fallback_%d_all_sources_failed", f.id))
+	return 0.0, fmt.Errorf("all %d sources of the fallback feed failed: [%s]", len(f.feeds), strings.Join(errors, "; "))
+}
+
+// logSourceChange logs the source that is used whenever it is different from the source used for the previous price
+func (f *fallbackFeed) logSourceChange(sourceIndex int, price float64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if sourceIndex == f.lastSourceIndex {
+		return
+	}
+	log.Printf("fallback feed %d is now using source at index %d (of %d sources), price=%.10f\n", f.id, sourceIndex, len(f.feeds), price)
+	f.lastSourceIndex = sourceIndex
+}
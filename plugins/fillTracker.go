@@ -21,6 +21,7 @@ type FillTracker struct {
 	fillTrackerSleepMillis           uint32
 	fillTrackerDeleteCyclesThreshold int64
 	lastCursor                       interface{}
+	clock                            api.Clock
 
 	// initialized runtime vars
 	fillTrackerDeleteCycles int64
@@ -42,6 +43,7 @@ func MakeFillTracker(
 	fillTrackerSleepMillis uint32,
 	fillTrackerDeleteCyclesThreshold int64,
 	lastCursor interface{},
+	clock api.Clock,
 ) api.FillTracker {
 	return &FillTracker{
 		pair:                             pair,
@@ -50,6 +52,7 @@ func MakeFillTracker(
 		fillTrackerSleepMillis:           fillTrackerSleepMillis,
 		fillTrackerDeleteCyclesThreshold: fillTrackerDeleteCyclesThreshold,
 		lastCursor:                       lastCursor,
+		clock:                            clock,
 		// initialized runtime vars
 		fillTrackerDeleteCycles: 0,
 		lockFill:                &sync.Mutex{},
@@ -168,7 +171,7 @@ func (f *FillTracker) FillTrackSingleIteration() ([]model.Trade, error) {
 }
 
 func (f *FillTracker) sleep() {
-	time.Sleep(time.Duration(f.fillTrackerSleepMillis) * time.Millisecond)
+	f.clock.Sleep(time.Duration(f.fillTrackerSleepMillis) * time.Millisecond)
 }
 
 func handlePanic(ech chan error) {
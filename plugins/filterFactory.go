@@ -6,10 +6,15 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/stellar/go/clients/horizonclient"
 	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/utils"
 )
 
 var filterIDRegex *regexp.Regexp
@@ -23,9 +28,13 @@ func init() {
 }
 
 var filterMap = map[string]func(f *FilterFactory, configInput string) (SubmitFilter, error){
-	"volume":    filterVolume,
-	"price":     filterPrice,
-	"priceFeed": filterPriceFeed,
+	"volume":         filterVolume,
+	"price":          filterPrice,
+	"priceFeed":      filterPriceFeed,
+	"position":       filterPosition,
+	"circuitBreaker": filterCircuitBreaker,
+	"schedule":       filterSchedule,
+	"selfTrade":      filterSelfTrade,
 }
 
 // FilterFactory is a struct that handles creating all the filters
@@ -36,6 +45,10 @@ type FilterFactory struct {
 	BaseAsset      hProtocol.Asset
 	QuoteAsset     hProtocol.Asset
 	DB             *sql.DB
+	IEIF           *IEIF
+	Clock          api.Clock             // defaults to the real clock when nil
+	Alert          api.Alert             // can be nil
+	HorizonClient  *horizonclient.Client // can be nil, such as in backtests
 }
 
 // MakeFilter is the function that makes the required filters
@@ -54,6 +67,14 @@ func (f *FilterFactory) MakeFilter(configInput string) (SubmitFilter, error) {
 	return factoryMethod(f, configInput)
 }
 
+// getClock returns the clock of the factory, or the real clock when it is not set
+func (f *FilterFactory) getClock() api.Clock {
+	if f.Clock == nil {
+		return MakeRealClock()
+	}
+	return f.Clock
+}
+
 func filterVolume(f *FilterFactory, configInput string) (SubmitFilter, error) {
 	config, e := makeVolumeFilterConfig(configInput)
 	if e != nil {
@@ -68,6 +89,7 @@ func filterVolume(f *FilterFactory, configInput string) (SubmitFilter, error) {
 		f.BaseAsset,
 		f.QuoteAsset,
 		f.DB,
+		f.getClock(),
 		config,
 	)
 }
@@ -103,9 +125,11 @@ func makeVolumeFilterConfig(configInput string) (*VolumeFilterConfig, error) {
 	config := &VolumeFilterConfig{mode: mode}
 
 	limitWindowParts := strings.Split(parts[1], ":")
-	if limitWindowParts[0] != "daily" {
-		return nil, fmt.Errorf("invalid input (%s), the second part needs to equal or start with \"daily\"", configInput)
+	window, e := parseVolumeFilterWindow(limitWindowParts[0])
+	if e != nil {
+		return nil, fmt.Errorf("invalid input (%s), the second part needs to equal or start with \"daily\" or \"rolling=<duration>\": %s", configInput, e)
 	}
+	config.window = window
 
 	action, e := queries.ParseDailyVolumeAction(parts[2])
 	if e != nil {
@@ -151,6 +175,25 @@ func makeVolumeFilterConfig(configInput string) (*VolumeFilterConfig, error) {
 	return config, nil
 }
 
+// parseVolumeFilterWindow returns a zero window for "daily" and the duration of the window for "rolling=<duration>", such as "rolling=60m"
+func parseVolumeFilterWindow(windowString string) (time.Duration, error) {
+	if windowString == "daily" {
+		return 0, nil
+	}
+
+	if !strings.HasPrefix(windowString, "rolling=") {
+		return 0, fmt.Errorf("invalid window '%s'", windowString)
+	}
+	window, e := time.ParseDuration(strings.TrimPrefix(windowString, "rolling="))
+	if e != nil {
+		return 0, fmt.Errorf("could not parse duration of rolling window '%s': %s", windowString, e)
+	}
+	if window < volumeFilterMinWindow {
+		return 0, fmt.Errorf("duration of rolling window '%s' needs to be at least %s", windowString, volumeFilterMinWindow)
+	}
+	return window, nil
+}
+
 func addModifierToConfig(config *VolumeFilterConfig, modifierMapping string) error {
 	ids, modifierType, e := parseVolumeFilterModifier(modifierMapping)
 	if e != nil {
@@ -265,3 +308,231 @@ func filterPriceFeed(f *FilterFactory, configInput string) (SubmitFilter, error)
 
 	return filter, nil
 }
+
+func filterPosition(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	config, e := makePositionFilterConfig(configInput)
+	if e != nil {
+		return nil, fmt.Errorf("could not make PositionFilterConfig for configInput (%s): %s", configInput, e)
+	}
+
+	if f.IEIF == nil {
+		return nil, fmt.Errorf("\"position\" filter needs the IEIF to load the balance of the base asset but it was nil")
+	}
+
+	return makeFilterPosition(
+		configInput,
+		f.BaseAsset,
+		f.QuoteAsset,
+		f.IEIF.GetAssetBalance,
+		config,
+	)
+}
+
+func makePositionFilterConfig(configInput string) (*PositionFilterConfig, error) {
+	// parts[0] = "position", parts[1] = limitType, parts[2] = limitUnits, parts[3] = limit, parts[4] = mode
+	parts := strings.Split(configInput, "/")
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("invalid input (%s), needs 5 parts separated by the delimiter (/)", configInput)
+	}
+
+	limitType, e := parsePositionFilterLimitType(parts[1])
+	if e != nil {
+		return nil, fmt.Errorf("could not parse position filter limit type from input (%s): %s", configInput, e)
+	}
+
+	mode, e := parseVolumeFilterMode(parts[4])
+	if e != nil {
+		return nil, fmt.Errorf("could not parse position filter mode from input (%s): %s", configInput, e)
+	}
+	config := &PositionFilterConfig{
+		limitType: limitType,
+		mode:      mode,
+	}
+
+	limit, e := strconv.ParseFloat(parts[3], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the fourth part as a float value from config value (%s): %s", configInput, e)
+	}
+	if parts[2] == "base" {
+		config.LimitInBaseUnits = &limit
+	} else if parts[2] == "quote" {
+		config.LimitInQuoteUnits = &limit
+	} else {
+		return nil, fmt.Errorf("invalid input (%s), the third part needs to be \"base\" or \"quote\"", configInput)
+	}
+
+	if e = config.Validate(); e != nil {
+		return nil, fmt.Errorf("invalid input (%s), did not pass validation: %s", configInput, e)
+	}
+	return config, nil
+}
+
+func filterCircuitBreaker(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	// parts[0] = "circuitBreaker", parts[1] = thresholdPct, parts[2] = window, parts[3] = cooldown,
+	// parts[4] = feedDataType, parts[5] = feedURL which can have more "/" chars
+	parts := strings.Split(configInput, "/")
+	if len(parts) < 6 {
+		return nil, fmt.Errorf("\"circuitBreaker\" filter needs at least 6 parts separated by the '/' delimiter (circuitBreaker/<thresholdPct>/<window>/<cooldown>/<feedDataType>/<feedURL>) but we received %s", configInput)
+	}
+
+	thresholdPct, e := strconv.ParseFloat(parts[1], 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the second part as a float value from config value (%s): %s", configInput, e)
+	}
+	window, e := time.ParseDuration(parts[2])
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the third part as a duration from config value (%s): %s", configInput, e)
+	}
+	cooldown, e := time.ParseDuration(parts[3])
+	if e != nil {
+		return nil, fmt.Errorf("could not parse the fourth part as a duration from config value (%s): %s", configInput, e)
+	}
+	config := &CircuitBreakerFilterConfig{
+		ThresholdPct: thresholdPct,
+		Window:       window,
+		Cooldown:     cooldown,
+	}
+
+	// the price feed is required because the SDEX mid price of the trading pair includes our own offers, which are deleted when the
+	// circuit breaker trips and can leave the orderbook empty
+	feedURL := strings.Join(parts[5:], "/")
+	pf, e := MakePriceFeed(parts[4], feedURL)
+	if e != nil {
+		return nil, fmt.Errorf("could not make price feed for config input string '%s': %s", configInput, e)
+	}
+
+	filter, e := makeFilterCircuitBreaker(configInput, f.BaseAsset, f.QuoteAsset, pf, f.getClock(), f.Alert, config)
+	if e != nil {
+		return nil, fmt.Errorf("could not make circuit breaker filter for config input string '%s': %s", configInput, e)
+	}
+	return filter, nil
+}
+
+func filterSchedule(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	config, e := makeScheduleFilterConfig(configInput)
+	if e != nil {
+		return nil, fmt.Errorf("could not make ScheduleFilterConfig for configInput (%s): %s", configInput, e)
+	}
+
+	return makeFilterSchedule(configInput, f.BaseAsset, f.QuoteAsset, f.getClock(), config)
+}
+
+// makeScheduleFilterConfig parses the config formatted as schedule/<key>=<value>/<key>=<value>... where the key is "tz", "blackout",
+// or the days that the value applies to, such as "Mo" or "Mo-Fr". The time zone is the only value that can have "/" chars.
+func makeScheduleFilterConfig(configInput string) (*ScheduleFilterConfig, error) {
+	parts := strings.Split(configInput, "/")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid input (%s), needs at least 2 parts separated by the delimiter (/)", configInput)
+	}
+
+	// join the parts without a "=" to the previous entry so we can use time zones like "America/New_York"
+	keys := []string{}
+	values := []string{}
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) == 2 {
+			keys = append(keys, kv[0])
+			values = append(values, kv[1])
+		} else if len(values) > 0 {
+			values[len(values)-1] = values[len(values)-1] + "/" + p
+		} else {
+			return nil, fmt.Errorf("invalid input (%s), the part '%s' needs to be formatted as <key>=<value>", configInput, p)
+		}
+	}
+
+	config := &ScheduleFilterConfig{
+		Location:      time.UTC,
+		blackoutDates: map[string]bool{},
+	}
+	// load the time zone first because the blackout dates are in the time zone
+	for i, k := range keys {
+		if k != "tz" {
+			continue
+		}
+		location, e := time.LoadLocation(values[i])
+		if e != nil {
+			return nil, fmt.Errorf("invalid input (%s), could not load time zone '%s': %s", configInput, values[i], e)
+		}
+		config.Location = location
+	}
+
+	for i, k := range keys {
+		if k == "tz" {
+			continue
+		}
+
+		if k == "blackout" {
+			for _, d := range strings.Split(values[i], ",") {
+				date, e := time.ParseInLocation(scheduleBlackoutDateFormat, d, config.Location)
+				if e != nil {
+					return nil, fmt.Errorf("invalid input (%s), could not parse blackout date '%s' as YYYY-MM-DD: %s", configInput, d, e)
+				}
+				config.blackoutDates[date.Format(scheduleBlackoutDateFormat)] = true
+			}
+			continue
+		}
+
+		days, e := parseScheduleDays(k)
+		if e != nil {
+			return nil, fmt.Errorf("invalid input (%s), could not parse days: %s", configInput, e)
+		}
+		ranges, e := parseScheduleTimeRanges(values[i])
+		if e != nil {
+			return nil, fmt.Errorf("invalid input (%s), could not parse time ranges for '%s': %s", configInput, k, e)
+		}
+		for _, d := range days {
+			config.allowedRanges[d] = append(config.allowedRanges[d], ranges...)
+		}
+	}
+
+	if e := config.Validate(); e != nil {
+		return nil, fmt.Errorf("invalid input (%s), did not pass validation: %s", configInput, e)
+	}
+	return config, nil
+}
+
+func filterSelfTrade(f *FilterFactory, configInput string) (SubmitFilter, error) {
+	config, e := makeSelfTradeFilterConfig(configInput)
+	if e != nil {
+		return nil, fmt.Errorf("could not make SelfTradeFilterConfig for configInput (%s): %s", configInput, e)
+	}
+
+	if f.HorizonClient == nil {
+		return nil, fmt.Errorf("\"selfTrade\" filter needs the horizon client to load the offers of the accounts but it was nil")
+	}
+	loadOffersFn := func(account string) ([]hProtocol.Offer, error) {
+		return utils.LoadAllOffers(account, f.HorizonClient)
+	}
+	return makeFilterSelfTrade(configInput, f.BaseAsset, f.QuoteAsset, loadOffersFn, config)
+}
+
+func makeSelfTradeFilterConfig(configInput string) (*SelfTradeFilterConfig, error) {
+	// parts[0] = "selfTrade", parts[1] = mode, parts[2] = comma-separated accounts
+	parts := strings.Split(configInput, "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid input (%s), needs 3 parts separated by the delimiter (/)", configInput)
+	}
+
+	mode, e := parseSelfTradeFilterMode(parts[1])
+	if e != nil {
+		return nil, fmt.Errorf("could not parse self trade filter mode from input (%s): %s", configInput, e)
+	}
+
+	accounts := []string{}
+	for _, a := range strings.Split(parts[2], ",") {
+		account := strings.TrimSpace(a)
+		if _, e := strkey.Decode(strkey.VersionByteAccountID, account); e != nil {
+			return nil, fmt.Errorf("invalid input (%s), '%s' is not a valid account: %s", configInput, account, e)
+		}
+		accounts = append(accounts, account)
+	}
+	config := &SelfTradeFilterConfig{
+		Accounts: utils.Dedupe(accounts),
+		mode:     mode,
+	}
+
+	if e = config.Validate(); e != nil {
+		return nil, fmt.Errorf("invalid input (%s), did not pass validation: %s", configInput, e)
+	}
+	return config, nil
+}
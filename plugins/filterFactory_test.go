@@ -3,6 +3,7 @@ package plugins
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/openlyinc/pointy"
 	"github.com/stellar/kelp/queries"
@@ -195,6 +196,24 @@ func TestMakeVolumeFilterConfig(t *testing.T) {
 				additionalMarketIDs:      []string{"4c19915f47", "db4531d586"},
 				optionalAccountIDs:       []string{"account1", "account2"},
 			},
+		}, {
+			configInput: "volume/rolling=60m/%s/base/5000.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				BaseAssetCapInBaseUnits:  pointy.Float64(5000.0),
+				BaseAssetCapInQuoteUnits: nil,
+				additionalMarketIDs:      nil,
+				optionalAccountIDs:       nil,
+				window:                   60 * time.Minute,
+			},
+		}, {
+			configInput: "volume/rolling=4h:market_ids=[4c19915f47,db4531d586]:account_ids=[account1,account2]/%s/quote/1000.0/%s",
+			wantConfig: &VolumeFilterConfig{
+				BaseAssetCapInBaseUnits:  nil,
+				BaseAssetCapInQuoteUnits: pointy.Float64(1000.0),
+				additionalMarketIDs:      []string{"4c19915f47", "db4531d586"},
+				optionalAccountIDs:       []string{"account1", "account2"},
+				window:                   4 * time.Hour,
+			},
 		},
 	}
 
@@ -235,5 +254,57 @@ func assertVolumeFilterConfigEqual(t *testing.T, want *VolumeFilterConfig, actua
 		assert.Equal(t, want.mode, actual.mode)
 		assert.Equal(t, want.additionalMarketIDs, actual.additionalMarketIDs)
 		assert.Equal(t, want.optionalAccountIDs, actual.optionalAccountIDs)
+		assert.Equal(t, want.window, actual.window)
+	}
+}
+
+func TestParseVolumeFilterWindow(t *testing.T) {
+	testCases := []struct {
+		windowString string
+		wantWindow   time.Duration
+		wantError    bool
+	}{
+		{
+			windowString: "daily",
+			wantWindow:   0,
+		}, {
+			windowString: "rolling=60m",
+			wantWindow:   60 * time.Minute,
+		}, {
+			windowString: "rolling=1m",
+			wantWindow:   time.Minute,
+		}, {
+			windowString: "rolling=1h30m",
+			wantWindow:   90 * time.Minute,
+		}, {
+			windowString: "rolling=30s",
+			wantError:    true,
+		}, {
+			windowString: "rolling=-5m",
+			wantError:    true,
+		}, {
+			windowString: "rolling=abc",
+			wantError:    true,
+		}, {
+			windowString: "rolling",
+			wantError:    true,
+		}, {
+			windowString: "hourly",
+			wantError:    true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.windowString, func(t *testing.T) {
+			window, e := parseVolumeFilterWindow(k.windowString)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantWindow, window)
+		})
 	}
 }
@@ -27,10 +27,23 @@ func (f *functionFeed) GetPrice() (float64, error) {
 }
 
 func makeFunctionPriceFeed(url string) (api.PriceFeed, error) {
-	name, argsString, e := extractFunctionParts(url)
+	nameWithParams, argsString, e := extractFunctionParts(url)
 	if e != nil {
 		return nil, fmt.Errorf("unable to extract function name from URL: %s", e)
 	}
+	name, params := splitFunctionParams(nameWithParams)
+
+	// the expr function names its feeds so it parses its own arguments
+	if name == exprFunctionName {
+		if len(params) != 0 {
+			return nil, fmt.Errorf("the '%s' price feed function does not take any params but found %d params: %v", exprFunctionName, len(params), params)
+		}
+		exprFeed, e := makeExprFeed(argsString)
+		if e != nil {
+			return nil, fmt.Errorf("error when invoking price feed function '%s': %s", name, e)
+		}
+		return exprFeed, nil
+	}
 
 	f, ok := fnFactoryMap[name]
 	if !ok {
@@ -42,7 +55,7 @@ func makeFunctionPriceFeed(url string) (api.PriceFeed, error) {
 		return nil, fmt.Errorf("error when makings feeds array: %s", e)
 	}
 
-	pf, e := f(feeds)
+	pf, e := f(params, feeds)
 	if e != nil {
 		return nil, fmt.Errorf("error when invoking price feed function '%s': %s", name, e)
 	}
@@ -51,7 +64,7 @@ func makeFunctionPriceFeed(url string) (api.PriceFeed, error) {
 }
 
 func extractFunctionParts(url string) (name string, args string, e error) {
-	fnNameRegex, e := regexp.Compile("^([a-zA-Z]+)\\((.*)\\)$")
+	fnNameRegex, e := regexp.Compile("^([a-zA-Z]+(?::[^()]*)?)\\((.*)\\)$")
 	if e != nil {
 		return "", "", fmt.Errorf("unable to make regexp (programmer error)")
 	}
@@ -64,24 +77,39 @@ func extractFunctionParts(url string) (name string, args string, e error) {
 	return submatches[1], submatches[2], nil
 }
 
+// splitFunctionParams splits a function name of the form name:param1:param2 into the name and its params
+func splitFunctionParams(nameWithParams string) (name string, params []string) {
+	parts := strings.Split(nameWithParams, ":")
+	return parts[0], parts[1:]
+}
+
 func makeFeedsArray(feedsStringCSV string) ([]api.PriceFeed, error) {
 	parts := strings.Split(feedsStringCSV, ",")
 	arr := []api.PriceFeed{}
 
 	for _, argPart := range parts {
-		feedSpecParts := strings.SplitN(argPart, "/", 2)
-		if len(feedSpecParts) != 2 {
-			return nil, fmt.Errorf("unable to correctly split arg into a price feed spec: %s", argPart)
-		}
-		priceFeedType := feedSpecParts[0]
-		priceFeedURL := feedSpecParts[1]
-
-		feed, e := MakePriceFeed(priceFeedType, priceFeedURL)
+		feed, e := MakePriceFeedFromSpec(argPart)
 		if e != nil {
-			return nil, fmt.Errorf("error creating a price feed (typ='%s', url='%s'): %s", priceFeedType, priceFeedURL, e)
+			return nil, e
 		}
 		arr = append(arr, feed)
 	}
 
 	return arr, nil
 }
+
+// MakePriceFeedFromSpec makes a PriceFeed from a spec formatted as <feed_type>/<feed_url>, i.e. exchange/ccxt-kraken/XLM/USD/mid
+func MakePriceFeedFromSpec(spec string) (api.PriceFeed, error) {
+	feedSpecParts := strings.SplitN(spec, "/", 2)
+	if len(feedSpecParts) != 2 {
+		return nil, fmt.Errorf("unable to correctly split arg into a price feed spec: %s", spec)
+	}
+	priceFeedType := feedSpecParts[0]
+	priceFeedURL := feedSpecParts[1]
+
+	feed, e := MakePriceFeed(priceFeedType, priceFeedURL)
+	if e != nil {
+		return nil, fmt.Errorf("error creating a price feed (typ='%s', url='%s'): %s", priceFeedType, priceFeedURL, e)
+	}
+	return feed, nil
+}
@@ -24,6 +24,14 @@ func TestExtractFunctionParts(t *testing.T) {
 			inputURL: "max(fixed/0.02,crypto/https://api.coinmarketcap.com/v1/ticker/stellar/)",
 			wantName: "max",
 			wantArgs: "fixed/0.02,crypto/https://api.coinmarketcap.com/v1/ticker/stellar/",
+		}, {
+			inputURL: "weighted:0.75:0.25(fixed/0.02,fixed/0.03)",
+			wantName: "weighted:0.75:0.25",
+			wantArgs: "fixed/0.02,fixed/0.03",
+		}, {
+			inputURL: "outlier:median:5(fixed/0.02,invert(fixed/50.0),fixed/0.03)",
+			wantName: "outlier:median:5",
+			wantArgs: "fixed/0.02,invert(fixed/50.0),fixed/0.03",
 		},
 	}
 
@@ -0,0 +1,315 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+// spacing types supported by the grid strategy
+const (
+	gridSpacingArithmetic = "arithmetic"
+	gridSpacingGeometric  = "geometric"
+)
+
+// makeGridPrices computes numLevels price points between minPrice and maxPrice (both inclusive) in ascending order
+func makeGridPrices(minPrice float64, maxPrice float64, numLevels int, spacing string) ([]float64, error) {
+	if minPrice <= 0 {
+		return nil, fmt.Errorf("minPrice needs to be greater than 0, was %f", minPrice)
+	}
+	if maxPrice <= minPrice {
+		return nil, fmt.Errorf("maxPrice (%f) needs to be greater than minPrice (%f)", maxPrice, minPrice)
+	}
+	if numLevels < 2 {
+		return nil, fmt.Errorf("numLevels needs to be at least 2, was %d", numLevels)
+	}
+
+	prices := []float64{}
+	for i := 0; i < numLevels; i++ {
+		fraction := float64(i) / float64(numLevels-1)
+		switch spacing {
+		case gridSpacingArithmetic:
+			prices = append(prices, minPrice+fraction*(maxPrice-minPrice))
+		case gridSpacingGeometric:
+			prices = append(prices, minPrice*math.Pow(maxPrice/minPrice, fraction))
+		default:
+			return nil, fmt.Errorf("invalid grid spacing '%s', needs to be one of '%s' or '%s'", spacing, gridSpacingArithmetic, gridSpacingGeometric)
+		}
+	}
+	return prices, nil
+}
+
+// nearestGridIndex returns the index of the grid price closest to the given price
+func nearestGridIndex(prices []float64, price float64) int {
+	nearest := 0
+	for i, p := range prices {
+		if math.Abs(p-price) < math.Abs(prices[nearest]-price) {
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+// gridLadder is the fixed ladder of prices along with the anchor, which is the level of the last fill. We keep buy orders at every level below
+// the anchor and sell orders at every level above it, so when a level is fully filled the anchor moves there and the order on the other side
+// is re-armed.
+// The ladder is shared by both sides of the grid strategy and is persisted to the db (when available) whenever the anchor moves.
+type gridLadder struct {
+	prices    []float64
+	minPrice  float64
+	maxPrice  float64
+	numLevels int
+	spacing   string
+	db        *sql.DB
+	marketID  string
+	clock     api.Clock
+
+	// initialized runtime vars
+	mutex         *sync.Mutex
+	anchorIndex   int
+	filledByLevel map[int]float64 // base volume filled so far on each level that has not been fully filled yet
+}
+
+// makeGridLadder is a factory method, it restores the anchor from the db if we have saved state for the same ladder, otherwise it anchors
+// the ladder at the level closest to the startPriceFn
+func makeGridLadder(
+	minPrice float64,
+	maxPrice float64,
+	numLevels int,
+	spacing string,
+	startPriceFn func() (float64, error),
+	db *sql.DB,
+	marketID string,
+	clock api.Clock,
+) (*gridLadder, error) {
+	prices, e := makeGridPrices(minPrice, maxPrice, numLevels, spacing)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make grid prices: %s", e)
+	}
+
+	l := &gridLadder{
+		prices:        prices,
+		minPrice:      minPrice,
+		maxPrice:      maxPrice,
+		numLevels:     numLevels,
+		spacing:       spacing,
+		db:            db,
+		marketID:      marketID,
+		clock:         clock,
+		mutex:         &sync.Mutex{},
+		anchorIndex:   -1,
+		filledByLevel: map[int]float64{},
+	}
+
+	if db != nil {
+		state, e := l.loadState()
+		if e != nil {
+			return nil, fmt.Errorf("unable to load grid state: %s", e)
+		}
+		if state != nil && l.matches(state) {
+			l.anchorIndex = state.AnchorIndex
+			log.Printf("grid: restored anchor index %d (price=%.8f) from the db for marketID '%s'\n", l.anchorIndex, l.prices[l.anchorIndex], marketID)
+			return l, nil
+		}
+		if state != nil {
+			log.Printf("grid: saved state for marketID '%s' does not match the configured grid (state=%+v), rebuilding the grid\n", marketID, *state)
+		}
+	} else {
+		log.Printf("grid: no db configured so the grid state will not be persisted across restarts\n")
+	}
+
+	startPrice, e := startPriceFn()
+	if e != nil {
+		return nil, fmt.Errorf("unable to fetch the start price: %s", e)
+	}
+	e = l.setAnchor(nearestGridIndex(l.prices, startPrice))
+	if e != nil {
+		return nil, fmt.Errorf("unable to set the initial anchor: %s", e)
+	}
+	log.Printf("grid: initialized anchor index %d (price=%.8f) from start price %.8f\n", l.anchorIndex, l.prices[l.anchorIndex], startPrice)
+	return l, nil
+}
+
+// matches returns true if the saved state was for the same ladder that we have configured
+func (l *gridLadder) matches(state *queries.GridState) bool {
+	return state.MinPrice == l.minPrice &&
+		state.MaxPrice == l.maxPrice &&
+		state.NumLevels == l.numLevels &&
+		state.Spacing == l.spacing &&
+		state.AnchorIndex >= 0 &&
+		state.AnchorIndex < l.numLevels
+}
+
+func (l *gridLadder) loadState() (*queries.GridState, error) {
+	q, e := queries.MakeStrategyGridState(l.db, l.marketID)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make StrategyGridState query: %s", e)
+	}
+	result, e := q.QueryRow()
+	if e != nil {
+		return nil, fmt.Errorf("unable to run StrategyGridState query: %s", e)
+	}
+	state, ok := result.(*queries.GridState)
+	if !ok {
+		return nil, fmt.Errorf("unable to convert result of StrategyGridState query to *queries.GridState: %v (type=%T)", result, result)
+	}
+	return state, nil
+}
+
+func (l *gridLadder) saveState() error {
+	if l.db == nil {
+		return nil
+	}
+
+	// pass the values as parameters so the prices are stored exactly and match the configured prices when read back
+	_, e := l.db.Exec(kelpdb.SqlStrategyGridStateUpsert,
+		l.marketID,
+		l.minPrice,
+		l.maxPrice,
+		l.numLevels,
+		l.spacing,
+		l.anchorIndex,
+		l.clock.Now().UTC().Format(postgresdb.TimestampFormatString),
+	)
+	if e != nil {
+		return fmt.Errorf("could not execute sql upsert statement (%s): %s", kelpdb.SqlStrategyGridStateUpsert, e)
+	}
+	return nil
+}
+
+// setAnchor moves the anchor and persists it, the caller should hold the lock if needed
+func (l *gridLadder) setAnchor(index int) error {
+	if index == l.anchorIndex {
+		return nil
+	}
+
+	l.anchorIndex = index
+	// the order at the new anchor is gone so any partial fills recorded against it no longer apply
+	delete(l.filledByLevel, index)
+	return l.saveState()
+}
+
+// pricesAroundAnchor returns the prices below the anchor (closest first) and the prices above the anchor (closest first)
+func (l *gridLadder) pricesAroundAnchor() ([]float64, []float64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	below := []float64{}
+	for i := l.anchorIndex - 1; i >= 0; i-- {
+		below = append(below, l.prices[i])
+	}
+	above := []float64{}
+	for i := l.anchorIndex + 1; i < len(l.prices); i++ {
+		above = append(above, l.prices[i])
+	}
+	return below, above
+}
+
+// onFill records the fill against the level closest to the fill price and moves the anchor there once the level has been fully filled,
+// levelAmount is the amount of base on the level and volumePrecision is used to decide when the level is fully filled
+func (l *gridLadder) onFill(price float64, volume float64, levelAmount float64, volumePrecision int8) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	index := nearestGridIndex(l.prices, price)
+	filled := l.filledByLevel[index] + volume
+	if model.NumberFromFloat(filled, volumePrecision).AsFloat() < model.NumberFromFloat(levelAmount, volumePrecision).AsFloat() {
+		l.filledByLevel[index] = filled
+		log.Printf("grid: level at index %d (price=%.8f) partially filled, filled %.8f of %.8f so the anchor was not moved\n",
+			index, l.prices[index], filled, levelAmount)
+		return nil
+	}
+	delete(l.filledByLevel, index)
+
+	oldIndex := l.anchorIndex
+	e := l.setAnchor(index)
+	if e != nil {
+		return fmt.Errorf("unable to move anchor after fill at price %.8f: %s", price, e)
+	}
+	if oldIndex != l.anchorIndex {
+		log.Printf("grid: moved anchor from index %d (price=%.8f) to index %d (price=%.8f) after fill at price %.8f\n",
+			oldIndex, l.prices[oldIndex], l.anchorIndex, l.prices[l.anchorIndex], price)
+	}
+	return nil
+}
+
+// gridLevelProvider provides levels on one side of the grid
+type gridLevelProvider struct {
+	ladder           *gridLadder
+	amountOfBase     float64
+	isBuySide        bool
+	orderConstraints *model.OrderConstraints
+}
+
+// ensure it implements the LevelProvider and FillHandler interfaces
+var _ api.LevelProvider = &gridLevelProvider{}
+var _ api.FillHandler = &gridLevelProvider{}
+
+// makeGridLevelProvider is a factory method
+func makeGridLevelProvider(
+	ladder *gridLadder,
+	amountOfBase float64,
+	isBuySide bool,
+	orderConstraints *model.OrderConstraints,
+) api.LevelProvider {
+	return &gridLevelProvider{
+		ladder:           ladder,
+		amountOfBase:     amountOfBase,
+		isBuySide:        isBuySide,
+		orderConstraints: orderConstraints,
+	}
+}
+
+// GetLevels impl.
+func (p *gridLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	below, above := p.ladder.pricesAroundAnchor()
+
+	levels := []api.Level{}
+	if p.isBuySide {
+		// prices on the buy side are inverted since levels are always in terms of the asset being sold
+		for _, price := range below {
+			levels = append(levels, api.Level{
+				Price:  *model.NumberFromFloat(1/price, p.orderConstraints.PricePrecision),
+				Amount: *model.NumberFromFloat(p.amountOfBase, p.orderConstraints.VolumePrecision),
+			})
+		}
+		return levels, nil
+	}
+
+	for _, price := range above {
+		levels = append(levels, api.Level{
+			Price:  *model.NumberFromFloat(price, p.orderConstraints.PricePrecision),
+			Amount: *model.NumberFromFloat(p.amountOfBase, p.orderConstraints.VolumePrecision),
+		})
+	}
+	return levels, nil
+}
+
+// GetFillHandlers impl
+func (p *gridLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	return []api.FillHandler{p}, nil
+}
+
+// HandleFill impl, each side only handles the fills of its own orders so a fill is never handled twice
+func (p *gridLevelProvider) HandleFill(trade model.Trade) error {
+	isBuy := trade.OrderAction == model.OrderActionBuy
+	if isBuy != p.isBuySide {
+		return nil
+	}
+	if trade.Price == nil {
+		return fmt.Errorf("trade price was nil for trade: %s", trade)
+	}
+	if trade.Volume == nil {
+		return fmt.Errorf("trade volume was nil for trade: %s", trade)
+	}
+
+	return p.ladder.onFill(trade.Price.AsFloat(), trade.Volume.AsFloat(), p.amountOfBase, p.orderConstraints.VolumePrecision)
+}
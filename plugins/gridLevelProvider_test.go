@@ -0,0 +1,181 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/queries"
+)
+
+func TestMakeGridPrices(t *testing.T) {
+	testCases := []struct {
+		minPrice   float64
+		maxPrice   float64
+		numLevels  int
+		spacing    string
+		wantPrices []float64
+		wantErr    bool
+	}{
+		{minPrice: 1.0, maxPrice: 2.0, numLevels: 5, spacing: gridSpacingArithmetic, wantPrices: []float64{1.0, 1.25, 1.5, 1.75, 2.0}},
+		{minPrice: 1.0, maxPrice: 8.0, numLevels: 4, spacing: gridSpacingGeometric, wantPrices: []float64{1.0, 2.0, 4.0, 8.0}},
+		{minPrice: 1.0, maxPrice: 2.0, numLevels: 2, spacing: gridSpacingArithmetic, wantPrices: []float64{1.0, 2.0}},
+		{minPrice: 1.0, maxPrice: 2.0, numLevels: 1, spacing: gridSpacingArithmetic, wantErr: true},
+		{minPrice: 2.0, maxPrice: 1.0, numLevels: 5, spacing: gridSpacingArithmetic, wantErr: true},
+		{minPrice: 0.0, maxPrice: 1.0, numLevels: 5, spacing: gridSpacingGeometric, wantErr: true},
+		{minPrice: 1.0, maxPrice: 2.0, numLevels: 5, spacing: "random", wantErr: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%.2f_%.2f_%d_%s", k.minPrice, k.maxPrice, k.numLevels, k.spacing), func(t *testing.T) {
+			prices, e := makeGridPrices(k.minPrice, k.maxPrice, k.numLevels, k.spacing)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) || !assert.Equal(t, len(k.wantPrices), len(prices)) {
+				return
+			}
+			for i, want := range k.wantPrices {
+				assert.InDelta(t, want, prices[i], 0.0000001)
+			}
+		})
+	}
+}
+
+func TestGridLevelProvider(t *testing.T) {
+	startPriceFn := func() (float64, error) {
+		return 1.52, nil
+	}
+	ladder, e := makeGridLadder(1.0, 2.0, 5, gridSpacingArithmetic, startPriceFn, nil, "market", MakeVirtualClock(time.Now()))
+	if !assert.NoError(t, e) {
+		return
+	}
+	orderConstraints := model.MakeOrderConstraints(4, 2, 1)
+	buySide := makeGridLevelProvider(ladder, 10, true, orderConstraints).(*gridLevelProvider)
+	sellSide := makeGridLevelProvider(ladder, 10, false, orderConstraints).(*gridLevelProvider)
+
+	testCases := []struct {
+		name           string
+		hasFill        bool
+		fillAction     model.OrderAction
+		fillPrice      float64
+		fillVolume     float64
+		wantAnchor     int
+		wantBuyPrices  []float64
+		wantSellPrices []float64
+	}{
+		{
+			name:           "initial state",
+			wantAnchor:     2,
+			wantBuyPrices:  []float64{1 / 1.25, 1 / 1.0},
+			wantSellPrices: []float64{1.75, 2.0},
+		}, {
+			name:           "sell fill moves the anchor up and re-arms the buy",
+			hasFill:        true,
+			fillAction:     model.OrderActionSell,
+			fillPrice:      1.75,
+			fillVolume:     10,
+			wantAnchor:     3,
+			wantBuyPrices:  []float64{1 / 1.5, 1 / 1.25, 1 / 1.0},
+			wantSellPrices: []float64{2.0},
+		}, {
+			name:           "partial buy fill does not move the anchor",
+			hasFill:        true,
+			fillAction:     model.OrderActionBuy,
+			fillPrice:      1.25,
+			fillVolume:     4,
+			wantAnchor:     3,
+			wantBuyPrices:  []float64{1 / 1.5, 1 / 1.25, 1 / 1.0},
+			wantSellPrices: []float64{2.0},
+		}, {
+			name:           "buy fill is ignored by the sell side and handled by the buy side once the level is fully filled",
+			hasFill:        true,
+			fillAction:     model.OrderActionBuy,
+			fillPrice:      1.25,
+			fillVolume:     6,
+			wantAnchor:     1,
+			wantBuyPrices:  []float64{1 / 1.0},
+			wantSellPrices: []float64{1.5, 1.75, 2.0},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			if k.hasFill {
+				trade := model.Trade{
+					Order: model.Order{
+						OrderAction: k.fillAction,
+						Price:       model.NumberFromFloat(k.fillPrice, 7),
+						Volume:      model.NumberFromFloat(k.fillVolume, 7),
+					},
+				}
+				for _, side := range []*gridLevelProvider{sellSide, buySide} {
+					if !assert.NoError(t, side.HandleFill(trade)) {
+						return
+					}
+				}
+			}
+			assert.Equal(t, k.wantAnchor, ladder.anchorIndex)
+
+			buyLevels, e := buySide.GetLevels(100, 100)
+			if !assert.NoError(t, e) || !assert.Equal(t, len(k.wantBuyPrices), len(buyLevels)) {
+				return
+			}
+			for i, l := range buyLevels {
+				assert.InDelta(t, k.wantBuyPrices[i], l.Price.AsFloat(), 0.0001)
+				assert.Equal(t, 10.0, l.Amount.AsFloat())
+			}
+
+			sellLevels, e := sellSide.GetLevels(100, 100)
+			if !assert.NoError(t, e) || !assert.Equal(t, len(k.wantSellPrices), len(sellLevels)) {
+				return
+			}
+			for i, l := range sellLevels {
+				assert.InDelta(t, k.wantSellPrices[i], l.Price.AsFloat(), 0.0001)
+				assert.Equal(t, 10.0, l.Amount.AsFloat())
+			}
+		})
+	}
+}
+
+func TestGridLadderMatches(t *testing.T) {
+	ladder, e := makeGridLadder(0.1, 0.3, 5, gridSpacingArithmetic, func() (float64, error) { return 0.2, nil }, nil, "market", MakeVirtualClock(time.Now()))
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	testCases := []struct {
+		name      string
+		state     queries.GridState
+		wantMatch bool
+	}{
+		{
+			name:      "same grid",
+			state:     queries.GridState{MinPrice: 0.1, MaxPrice: 0.3, NumLevels: 5, Spacing: gridSpacingArithmetic, AnchorIndex: 2},
+			wantMatch: true,
+		}, {
+			name:      "different max price",
+			state:     queries.GridState{MinPrice: 0.1, MaxPrice: 0.3001, NumLevels: 5, Spacing: gridSpacingArithmetic, AnchorIndex: 2},
+			wantMatch: false,
+		}, {
+			name:      "different number of levels",
+			state:     queries.GridState{MinPrice: 0.1, MaxPrice: 0.3, NumLevels: 6, Spacing: gridSpacingArithmetic, AnchorIndex: 2},
+			wantMatch: false,
+		}, {
+			name:      "anchor outside the grid",
+			state:     queries.GridState{MinPrice: 0.1, MaxPrice: 0.3, NumLevels: 5, Spacing: gridSpacingArithmetic, AnchorIndex: 5},
+			wantMatch: false,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			state := k.state
+			assert.Equal(t, k.wantMatch, ladder.matches(&state))
+		})
+	}
+}
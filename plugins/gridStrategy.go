@@ -0,0 +1,110 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// gridConfig contains the configuration params for this strategy
+type gridConfig struct {
+	PriceTolerance     float64 `valid:"-" toml:"PRICE_TOLERANCE"`
+	AmountTolerance    float64 `valid:"-" toml:"AMOUNT_TOLERANCE"`
+	StartPriceFeedType string  `valid:"-" toml:"START_PRICE_FEED_TYPE"` // only used to anchor the grid when there is no saved state
+	StartPriceFeedURL  string  `valid:"-" toml:"START_PRICE_FEED_URL"`
+	MinPrice           float64 `valid:"-" toml:"MIN_PRICE"`        // lowest price point of the grid
+	MaxPrice           float64 `valid:"-" toml:"MAX_PRICE"`        // highest price point of the grid
+	NumGridLevels      int     `valid:"-" toml:"NUM_GRID_LEVELS"`  // number of price points in the grid, including MIN_PRICE and MAX_PRICE
+	GridSpacing        string  `valid:"-" toml:"GRID_SPACING"`     // "arithmetic" or "geometric"
+	AmountOfABase      float64 `valid:"-" toml:"AMOUNT_OF_A_BASE"` // the size of the order at each price point in units of the base asset
+}
+
+// String impl.
+func (c gridConfig) String() string {
+	return utils.StructString(c, 0, nil)
+}
+
+// makeGridStrategy is a factory method
+func makeGridStrategy(
+	sdex *SDEX,
+	pair *model.TradingPair,
+	ieif *IEIF,
+	assetBase *hProtocol.Asset,
+	assetQuote *hProtocol.Asset,
+	marketID string,
+	db *sql.DB,
+	clock api.Clock,
+	priceFeedFactory *PriceFeedFactory,
+	config *gridConfig,
+) (api.Strategy, error) {
+	if config.AmountOfABase <= 0 {
+		return nil, fmt.Errorf("AMOUNT_OF_A_BASE needs to be greater than 0, was %f", config.AmountOfABase)
+	}
+
+	startPriceFn := func() (float64, error) {
+		pf, e := priceFeedFactory.MakePriceFeed(config.StartPriceFeedType, config.StartPriceFeedURL)
+		if e != nil {
+			return 0, fmt.Errorf("error when making the start priceFeed: %s", e)
+		}
+		return pf.GetPrice()
+	}
+	ladder, e := makeGridLadder(
+		config.MinPrice,
+		config.MaxPrice,
+		config.NumGridLevels,
+		config.GridSpacing,
+		startPriceFn,
+		db,
+		marketID,
+		clock,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the grid: %s", e)
+	}
+
+	orderConstraints := sdex.GetOrderConstraints(pair)
+	sellSideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetBase,
+		assetQuote,
+		makeGridLevelProvider(
+			ladder,
+			config.AmountOfABase,
+			false,
+			orderConstraints,
+		),
+		config.PriceTolerance,
+		config.AmountTolerance,
+		false,
+	)
+	// switch sides of base/quote here for buy side
+	buySideStrategy := makeSellSideStrategy(
+		sdex,
+		orderConstraints,
+		ieif,
+		assetQuote,
+		assetBase,
+		makeGridLevelProvider(
+			ladder,
+			config.AmountOfABase,
+			true,
+			orderConstraints,
+		),
+		config.PriceTolerance,
+		config.AmountTolerance,
+		true,
+	)
+
+	return makeComposeStrategy(
+		assetBase,
+		assetQuote,
+		buySideStrategy,
+		sellSideStrategy,
+	), nil
+}
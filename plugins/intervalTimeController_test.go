@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
 )
 
 func TestShouldUpdate(t *testing.T) {
@@ -58,7 +60,7 @@ func TestShouldUpdate(t *testing.T) {
 	for _, k := range testCases {
 		t.Run(fmt.Sprintf("%s, delayMillis=%d", k.name, k.delayMillis), func(t *testing.T) {
 			delay := time.Millisecond * time.Duration(k.delayMillis)
-			tc := makeIntervalTimeControllerForTest(k.tickInterval, delay)
+			tc := makeIntervalTimeControllerForTest(k.tickInterval, delay, MakeRealClock())
 
 			lastUpdateTime, _ := time.Parse(time.RFC3339, "2020-03-14T15:00:00Z")
 			currentUpdateTime := lastUpdateTime.Add(time.Millisecond * time.Duration(k.millisSinceLastUpdate))
@@ -128,7 +130,7 @@ func TestSleepTime(t *testing.T) {
 		name := fmt.Sprintf("%d. %s", (i + 1), k.name)
 		t.Run(name, func(t *testing.T) {
 			delay := time.Millisecond * time.Duration(k.delayMillis)
-			tc := makeIntervalTimeControllerForTest(time.Duration(5*time.Second), delay)
+			tc := makeIntervalTimeControllerForTest(time.Duration(5*time.Second), delay, MakeRealClock())
 
 			lastUpdateTime, _ := time.Parse(time.RFC3339, "2020-03-14T15:00:00Z")
 			realNow := lastUpdateTime.Add(time.Millisecond * time.Duration(k.millisSinceLastUpdate))
@@ -139,13 +141,61 @@ func TestSleepTime(t *testing.T) {
 	}
 }
 
+func TestSleepTimeVirtualClock(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		delayMillis           int64
+		millisSinceLastUpdate int64
+		wantDuration          time.Duration
+	}{
+		{
+			name:                  "no delay, no time diff",
+			delayMillis:           0,
+			millisSinceLastUpdate: 0,
+			wantDuration:          time.Duration(5000) * time.Millisecond,
+		}, {
+			name:                  "no delay, 4999 ms elapsed",
+			delayMillis:           0,
+			millisSinceLastUpdate: 4999,
+			wantDuration:          time.Duration(1) * time.Millisecond,
+		}, {
+			name:                  "delay, surpassed one update cycle",
+			delayMillis:           1023,
+			millisSinceLastUpdate: 7000,
+			wantDuration:          time.Duration(-977) * time.Millisecond,
+		},
+	}
+
+	for i, k := range testCases {
+		name := fmt.Sprintf("%d. %s", (i + 1), k.name)
+		t.Run(name, func(t *testing.T) {
+			lastUpdateTime, _ := time.Parse(time.RFC3339, "2020-03-14T15:00:00Z")
+			clock := MakeVirtualClock(lastUpdateTime)
+			delay := time.Millisecond * time.Duration(k.delayMillis)
+			tc := makeIntervalTimeControllerForTest(time.Duration(5*time.Second), delay, clock)
+
+			clock.Advance(time.Millisecond * time.Duration(k.millisSinceLastUpdate))
+			gotDuration := tc.SleepTime(lastUpdateTime)
+			assert.Equal(t, k.wantDuration, gotDuration)
+
+			// sleeping on the virtual clock brings us to the next update cycle without blocking
+			clock.Sleep(gotDuration)
+			if k.wantDuration > 0 {
+				assert.Equal(t, k.delayMillis, tc.sleepTimeInternal(lastUpdateTime, clock.Now()).Milliseconds())
+				assert.True(t, tc.ShouldUpdate(lastUpdateTime, clock.Now()))
+			}
+		})
+	}
+}
+
 // factory method takes a deterministic delay for tests
-func makeIntervalTimeControllerForTest(tickInterval time.Duration, delay time.Duration) *IntervalTimeController {
+func makeIntervalTimeControllerForTest(tickInterval time.Duration, delay time.Duration, clock api.Clock) *IntervalTimeController {
 	tickDelayFn := func() time.Duration {
 		return delay
 	}
 	return &IntervalTimeController{
 		tickInterval: tickInterval,
 		tickDelayFn:  tickDelayFn,
+		clock:        clock,
 	}
 }
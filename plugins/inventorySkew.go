@@ -0,0 +1,17 @@
+package plugins
+
+import (
+	"fmt"
+)
+
+// computeInventorySkew returns the deviation of the base asset's share of the total value from the target share, in the range [-1, 1].
+// A positive value means we hold more of the base asset than the target, this is used by the strategies that skew their orders to move
+// the inventory back towards the target
+func computeInventorySkew(baseBalance float64, quoteBalance float64, midPrice float64, targetBasePercent float64) (float64, error) {
+	baseValue := baseBalance * midPrice
+	totalValue := baseValue + quoteBalance
+	if totalValue <= 0 {
+		return 0, fmt.Errorf("total value of balances needs to be positive, was %.10f (base=%.10f, quote=%.10f)", totalValue, baseBalance, quoteBalance)
+	}
+	return (baseValue / totalValue) - targetBasePercent, nil
+}
@@ -0,0 +1,39 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeInventorySkew(t *testing.T) {
+	testCases := []struct {
+		baseBalance       float64
+		quoteBalance      float64
+		midPrice          float64
+		targetBasePercent float64
+		wantInventory     float64
+		wantErr           bool
+	}{
+		{baseBalance: 100, quoteBalance: 50, midPrice: 0.5, targetBasePercent: 0.5, wantInventory: 0.0},
+		{baseBalance: 100, quoteBalance: 0, midPrice: 0.5, targetBasePercent: 0.5, wantInventory: 0.5},
+		{baseBalance: 0, quoteBalance: 50, midPrice: 0.5, targetBasePercent: 0.5, wantInventory: -0.5},
+		{baseBalance: 300, quoteBalance: 50, midPrice: 0.5, targetBasePercent: 0.25, wantInventory: 0.5},
+		{baseBalance: 0, quoteBalance: 0, midPrice: 0.5, targetBasePercent: 0.5, wantErr: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%.2f_%.2f_%.2f_%.2f", k.baseBalance, k.quoteBalance, k.midPrice, k.targetBasePercent), func(t *testing.T) {
+			inventory, e := computeInventorySkew(k.baseBalance, k.quoteBalance, k.midPrice, k.targetBasePercent)
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantInventory, inventory, 0.0000001)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package plugins
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/networking"
+)
+
+/*
+the URL of the json feed is formatted like so: url|jsonPath[|headerName=headerValue...], for example:
+	https://prices.example.com/v1/ticker?pair=XLMUSD|$.data.tickers[0].price|X-API-KEY=abc123
+
+the headerValue supports the same header functions as the exchange headers in the trader.cfg file (i.e. STATIC:value), header values are
+redacted whenever the URL of the feed is logged because they usually contain API keys
+the parts are separated by '|' and there is no escaping, so a '|' in the url (such as in the query string) needs to be percent-encoded as
+%7C, and a header value cannot contain a '|'
+the jsonPath supports the root ($), child keys (.key or ['key']), and array indexes ([0], or [-1] for the last element), the value at the
+path can be a number or a string containing a number
+*/
+
+// redactedHeaderValue replaces the header values when the URL of a json feed is logged
+const redactedHeaderValue = "<redacted>"
+
+// jsonFeedSeparator separates the parts of the URL of a json feed
+const jsonFeedSeparator = "|"
+
+// jsonFeedHeaderRegex matches a header in the URL of a json feed, including json feeds nested in the URL of a function feed
+var jsonFeedHeaderRegex = regexp.MustCompile(`\|([^|=]+)=[^|]*`)
+
+// jsonPathRegex matches one step in a json path
+var jsonPathRegex = regexp.MustCompile(`^(?:\.([a-zA-Z0-9_\-]+)|\['([^']*)'\]|\[(-?[0-9]+)\])`)
+
+// jsonPathStep is one step in a json path, either a key or an index
+type jsonPathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// String is the Stringer method
+func (s jsonPathStep) String() string {
+	if s.isIndex {
+		return fmt.Sprintf("[%d]", s.index)
+	}
+	return fmt.Sprintf("['%s']", s.key)
+}
+
+// jsonFeed fetches a json document from a URL and extracts the price using a json path
+type jsonFeed struct {
+	url      string
+	jsonPath string
+	steps    []jsonPathStep
+	headers  map[string]networking.HeaderFn
+	client   *http.Client
+}
+
+// ensure that it implements PriceFeed
+var _ api.PriceFeed = &jsonFeed{}
+
+// newJSONFeed creates a new json feed from the URL of the feed, the json path and headers are validated here so we fail on startup
+func newJSONFeed(feedURL string) (*jsonFeed, error) {
+	parts := strings.Split(feedURL, jsonFeedSeparator)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid format of json feed URL, needs to be formatted as url%sjsonPath[%sheaderName=headerValue...]: %s", jsonFeedSeparator, jsonFeedSeparator, redactJSONFeedHeaders(feedURL))
+	}
+
+	steps, e := parseJSONPath(parts[1])
+	if e != nil {
+		return nil, fmt.Errorf("invalid json path '%s': %s", parts[1], e)
+	}
+
+	headers := map[string]networking.HeaderFn{}
+	for _, h := range parts[2:] {
+		headerParts := strings.SplitN(h, "=", 2)
+		if len(headerParts) != 2 || headerParts[0] == "" {
+			return nil, fmt.Errorf("invalid format of header '%s' in json feed URL, needs to be formatted as headerName=headerValue", h)
+		}
+
+		headerFn, e := networking.MakeHeaderFn(headerParts[1], nil)
+		if e != nil {
+			return nil, fmt.Errorf("unable to make header function for header '%s' in json feed URL: %s", headerParts[0], e)
+		}
+		headers[headerParts[0]] = headerFn
+	}
+
+	return &jsonFeed{
+		url:      parts[0],
+		jsonPath: parts[1],
+		steps:    steps,
+		headers:  headers,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// String is the Stringer method, the header values are redacted
+func (f *jsonFeed) String() string {
+	headerNames := []string{}
+	for name := range f.headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	parts := []string{f.url, f.jsonPath}
+	for _, name := range headerNames {
+		parts = append(parts, name+"="+redactedHeaderValue)
+	}
+	return fmt.Sprintf("jsonFeed[%s]", strings.Join(parts, jsonFeedSeparator))
+}
+
+// redactJSONFeedHeaders replaces the header values in the URL of a json feed so they are never logged. Everything after the '=' of a
+// header up to the next '|' is redacted, which can include the rest of a function feed URL that the json feed is nested in
+func redactJSONFeedHeaders(feedURL string) string {
+	return jsonFeedHeaderRegex.ReplaceAllString(feedURL, jsonFeedSeparator+"$1="+redactedHeaderValue)
+}
+
+// GetPrice impl
+func (f *jsonFeed) GetPrice() (float64, error) {
+	var response interface{}
+	e := networking.JSONRequestDynamicHeaders(f.client, "GET", f.url, "", f.headers, &response, "")
+	if e != nil {
+		return 0.0, fmt.Errorf("error fetching json from URL '%s': %s", f.url, e)
+	}
+
+	value, e := extractJSONPath(response, f.steps)
+	if e != nil {
+		return 0.0, fmt.Errorf("could not extract json path '%s' from response of URL '%s': %s", f.jsonPath, f.url, e)
+	}
+	return value, nil
+}
+
+// parseJSONPath parses a json path into its steps
+func parseJSONPath(jsonPath string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(jsonPath, "$") {
+		return nil, fmt.Errorf("json path needs to start with '$'")
+	}
+
+	steps := []jsonPathStep{}
+	remaining := jsonPath[1:]
+	for remaining != "" {
+		submatches := jsonPathRegex.FindStringSubmatch(remaining)
+		if submatches == nil {
+			return nil, fmt.Errorf("could not parse json path at '%s', supported steps are .key, ['key'], and [index]", remaining)
+		}
+
+		if submatches[1] != "" {
+			steps = append(steps, jsonPathStep{key: submatches[1]})
+		} else if submatches[3] != "" {
+			index, e := strconv.Atoi(submatches[3])
+			if e != nil {
+				return nil, fmt.Errorf("could not parse index '%s': %s", submatches[3], e)
+			}
+			steps = append(steps, jsonPathStep{index: index, isIndex: true})
+		} else {
+			steps = append(steps, jsonPathStep{key: submatches[2]})
+		}
+		remaining = remaining[len(submatches[0]):]
+	}
+	return steps, nil
+}
+
+// extractJSONPath walks the steps through the decoded json and parses the value at the end of the path as a number
+func extractJSONPath(data interface{}, steps []jsonPathStep) (float64, error) {
+	current := data
+	for i, step := range steps {
+		if step.isIndex {
+			arr, ok := current.([]interface{})
+			if !ok {
+				return 0.0, fmt.Errorf("expected an array at step %d (%s) but found %T", i, step, current)
+			}
+
+			index := step.index
+			if index < 0 {
+				index += len(arr)
+			}
+			if index < 0 || index >= len(arr) {
+				return 0.0, fmt.Errorf("index at step %d (%s) is out of range for array of length %d", i, step, len(arr))
+			}
+			current = arr[index]
+			continue
+		}
+
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return 0.0, fmt.Errorf("expected an object at step %d (%s) but found %T", i, step, current)
+		}
+		current, ok = obj[step.key]
+		if !ok {
+			return 0.0, fmt.Errorf("key at step %d (%s) does not exist", i, step)
+		}
+	}
+
+	switch v := current.(type) {
+	case float64:
+		return v, nil
+	case string:
+		price, e := strconv.ParseFloat(v, 64)
+		if e != nil {
+			return 0.0, fmt.Errorf("could not parse string value '%s' as a number: %s", v, e)
+		}
+		return price, nil
+	}
+	return 0.0, fmt.Errorf("expected a number or a string at the end of the json path but found %T (%v)", current, current)
+}
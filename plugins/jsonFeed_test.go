@@ -0,0 +1,92 @@
+package plugins
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractJSONPath(t *testing.T) {
+	doc := `{"result": {"XXLMZUSD": {"c": ["0.1234", "100"]}}, "data": [{"price": 1.5}, {"price": 2.5}], "odd key": "3"}`
+	var data interface{}
+	e := json.Unmarshal([]byte(doc), &data)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	testCases := []struct {
+		jsonPath  string
+		wantPrice float64
+		wantError bool
+	}{
+		{jsonPath: "$.result.XXLMZUSD.c[0]", wantPrice: 0.1234},
+		{jsonPath: "$.data[1].price", wantPrice: 2.5},
+		{jsonPath: "$.data[-2].price", wantPrice: 1.5},
+		{jsonPath: "$['odd key']", wantPrice: 3.0},
+		{jsonPath: "$.data[2].price", wantError: true},
+		{jsonPath: "$.result.missing", wantError: true},
+		{jsonPath: "$.data.price", wantError: true},
+		{jsonPath: "$.result", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.jsonPath, func(t *testing.T) {
+			steps, e := parseJSONPath(k.jsonPath)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			price, e := extractJSONPath(data, steps)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantPrice, price)
+		})
+	}
+}
+
+func TestParseJSONPathInvalid(t *testing.T) {
+	for _, jsonPath := range []string{"data.price", "$.data[", "$..price", "$.data[x]"} {
+		t.Run(jsonPath, func(t *testing.T) {
+			_, e := parseJSONPath(jsonPath)
+			assert.Error(t, e)
+		})
+	}
+}
+
+func TestRedactJSONFeedHeaders(t *testing.T) {
+	testCases := []struct {
+		feedURL string
+		want    string
+	}{
+		{
+			feedURL: "https://example.com/ticker?pair=XLMUSD|$.price",
+			want:    "https://example.com/ticker?pair=XLMUSD|$.price",
+		}, {
+			feedURL: "https://example.com/ticker?pair=XLMUSD|$.price|X-API-KEY=STATIC:abc123|X-Other=STATIC:def=456",
+			want:    "https://example.com/ticker?pair=XLMUSD|$.price|X-API-KEY=<redacted>|X-Other=<redacted>",
+		}, {
+			feedURL: "max(json/https://example.com/a|$.price|X-API-KEY=STATIC:abc123,fixed/0.1)",
+			want:    "max(json/https://example.com/a|$.price|X-API-KEY=<redacted>",
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.want, func(t *testing.T) {
+			assert.Equal(t, k.want, redactJSONFeedHeaders(k.feedURL))
+		})
+	}
+}
+
+func TestJSONFeedString(t *testing.T) {
+	f, e := newJSONFeed("https://example.com/ticker|$.price|X-API-KEY=STATIC:abc123")
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "jsonFeed[https://example.com/ticker|$.price|X-API-KEY=<redacted>]", f.String())
+}
@@ -170,13 +170,14 @@ func MakeCliProps(
 
 // updateProps holds the properties for the update Amplitude event.
 type updateProps struct {
-	Success                      bool    `json:"success"`
-	MillisForUpdate              int64   `json:"millis_for_update"`
-	SecondsSinceLastUpdateMetric float64 `json:"seconds_since_last_update_metric"` // helps understand total runtime of bot when summing this field across events
-	NumPruneOps                  int     `json:"num_prune_ops"`
-	NumUpdateOpsDelete           int     `json:"num_update_ops_delete"`
-	NumUpdateOpsUpdate           int     `json:"num_update_ops_update"`
-	NumUpdateOpsCreate           int     `json:"num_update_ops_create"`
+	Success                      bool              `json:"success"`
+	MillisForUpdate              int64             `json:"millis_for_update"`
+	SecondsSinceLastUpdateMetric float64           `json:"seconds_since_last_update_metric"` // helps understand total runtime of bot when summing this field across events
+	NumPruneOps                  int               `json:"num_prune_ops"`
+	NumUpdateOpsDelete           int               `json:"num_update_ops_delete"`
+	NumUpdateOpsUpdate           int               `json:"num_update_ops_update"`
+	NumUpdateOpsCreate           int               `json:"num_update_ops_create"`
+	PriceFeedEvents              map[string]uint64 `json:"price_feed_events,omitempty"`
 }
 
 // deleteProps holds the properties for the delete Amplitude event.
@@ -302,6 +303,7 @@ func (mt *MetricsTracker) SendUpdateEvent(now time.Time, updateResult UpdateLoop
 		NumUpdateOpsDelete:           updateResult.NumUpdateOpsDelete,
 		NumUpdateOpsUpdate:           updateResult.NumUpdateOpsUpdate,
 		NumUpdateOpsCreate:           updateResult.NumUpdateOpsCreate,
+		PriceFeedEvents:              takePriceFeedStats(),
 	}
 
 	e := mt.sendEvent(updateEventName, updateProps, now)
@@ -5,18 +5,13 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"strings"
 	"sync"
 
-	"github.com/nikhilsaraf/go-tools/multithreading"
-
 	"github.com/stellar/kelp/stellargohorizonclientv300/build"
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/kelp/api"
-	"github.com/stellar/kelp/kelpdb"
 	"github.com/stellar/kelp/model"
-	"github.com/stellar/kelp/queries"
 	"github.com/stellar/kelp/support/toml"
 	"github.com/stellar/kelp/support/utils"
 )
@@ -80,27 +75,24 @@ func makeAssetSurplus() *assetSurplus {
 
 // mirrorStrategy is a strategy to mirror the orderbook of a given exchange
 type mirrorStrategy struct {
-	sdex                                  *SDEX
-	ieif                                  *IEIF
-	baseAsset                             *hProtocol.Asset
-	quoteAsset                            *hProtocol.Asset
-	primaryConstraints                    *model.OrderConstraints
-	marketID                              string
-	backingPair                           *model.TradingPair
-	backingConstraints                    *model.OrderConstraints
-	backingMarketID                       string
-	backingFillTracker                    api.FillTracker
-	strategyMirrorTradeTriggerExistsQuery *queries.StrategyMirrorTradeTriggerExists
-	orderbookDepth                        int
-	perLevelSpread                        float64
-	bidVolumeDivideBy                     float64
-	askVolumeDivideBy                     float64
-	maybeMaxOrderBaseCap                  *float64 // using a nil value makes it clear whether this value exists or not
-	exchange                              api.Exchange
-	offsetTrades                          bool
-	mutex                                 *sync.Mutex
-	baseSurplus                           map[model.OrderAction]*assetSurplus // baseSurplus keeps track of any surplus we have of the base asset that needs to be offset on the backing exchange
-	db                                    *sql.DB
+	sdex                 *SDEX
+	ieif                 *IEIF
+	baseAsset            *hProtocol.Asset
+	quoteAsset           *hProtocol.Asset
+	primaryConstraints   *model.OrderConstraints
+	backingPair          *model.TradingPair
+	backingConstraints   *model.OrderConstraints
+	backingFillTracker   api.FillTracker
+	tradeTriggers        *tradeTriggers
+	orderbookDepth       int
+	perLevelSpread       float64
+	bidVolumeDivideBy    float64
+	askVolumeDivideBy    float64
+	maybeMaxOrderBaseCap *float64 // using a nil value makes it clear whether this value exists or not
+	exchange             api.Exchange
+	offsetTrades         bool
+	mutex                *sync.Mutex
+	baseSurplus          map[model.OrderAction]*assetSurplus // baseSurplus keeps track of any surplus we have of the base asset that needs to be offset on the backing exchange
 
 	// uninitialized
 	sellOnPrimaryBalanceCoordinator *balanceCoordinator
@@ -177,7 +169,6 @@ func makeMirrorStrategy(
 
 	var exchange api.Exchange
 	var e error
-	var strategyMirrorTradeTriggerExistsQuery *queries.StrategyMirrorTradeTriggerExists
 	if config.OffsetTrades {
 		if db == nil {
 			return nil, fmt.Errorf("db should not be nil when OffsetTrades is enabled")
@@ -207,11 +198,6 @@ func makeMirrorStrategy(
 			utils.PrintErrorHintf("BACKING_DB_OVERRIDE__ACCOUNT_ID needs to be set in the mirror strategy config file when OFFSET_TRADES is enabled so we can assign an account_id to trades that are fetched from the backing exchange before writing them in the db")
 			return nil, fmt.Errorf("invalid mirror strategy config file, need to set BACKING_DB_OVERRIDE__ACCOUNT_ID")
 		}
-
-		strategyMirrorTradeTriggerExistsQuery, e = queries.MakeStrategyMirrorTradeTriggerExists(db, marketID)
-		if e != nil {
-			return nil, fmt.Errorf("unable to create strategyMirrorTradeTriggerExistsQuery: %s", e)
-		}
 	} else {
 		exchange, e = MakeExchange(config.Exchange, simMode)
 		if e != nil {
@@ -230,27 +216,21 @@ func makeMirrorStrategy(
 	// make fill tracker for backing exchange
 	var backingFillTracker api.FillTracker
 	if config.OffsetTrades {
-		var backingLastCursor interface{}
-		if config.BackingFillTrackerLastTradeCursorOverride == "" {
-			// loads cursor by fetching from exchange
-			backingLastCursor, e = exchange.GetLatestTradeCursor()
-			if e != nil {
-				return nil, fmt.Errorf("could not get last trade cursor from backing exchange in mirrorStrategy: %s", e)
-			}
-			log.Printf("set backingLastCursor from where to start tracking fills for backing exchange in mirror strategy (no override specified): %v\n", backingLastCursor)
-		} else {
-			// loads cursor from config file
-			backingLastCursor = config.BackingFillTrackerLastTradeCursorOverride
-			log.Printf("set backingLastCursor from where to start tracking fills for backing exchange in mirror strategy (used override value): %v\n", backingLastCursor)
-		}
-		backingFillTracker = MakeFillTracker(backingPair, multithreading.MakeThreadTracker(), exchange, 0, 0, backingLastCursor)
-		backingFillTracker.RegisterHandler(MakeFillLogger())
-		backingAssetDisplayFn := model.MakePassthroughAssetDisplayFn()
 		if config.Exchange == "sdex" {
 			return nil, fmt.Errorf("we cannot mirror trades from SDEX for now (programmer: need to create sdexAssetMap to inject into the backingAssetDisplayFn)")
 		}
-		fillDBWriter := MakeFillDBWriter(db, backingAssetDisplayFn, config.Exchange, config.BackingDbOverrideAccountID)
-		backingFillTracker.RegisterHandler(fillDBWriter)
+		backingFillTracker, e = makeBackingFillTracker(
+			"mirror",
+			exchange,
+			config.Exchange,
+			backingPair,
+			config.BackingFillTrackerLastTradeCursorOverride,
+			db,
+			config.BackingDbOverrideAccountID,
+		)
+		if e != nil {
+			return nil, e
+		}
 	}
 
 	// update precision overrides
@@ -302,14 +282,20 @@ func makeMirrorStrategy(
 		}
 	}
 
+	var triggers *tradeTriggers
+	if config.OffsetTrades {
+		triggers, e = makeTradeTriggers(db, marketID, backingMarketID)
+		if e != nil {
+			return nil, e
+		}
+	}
+
 	// trigger fill tracking on backing exchange at creation time
 	if backingFillTracker != nil {
-		trades, e := backingFillTracker.FillTrackSingleIteration()
+		e = trackBackingFills(backingFillTracker, "factory method")
 		if e != nil {
-			return nil, fmt.Errorf("unable to track a single iteration of fills from the backing exchange in factory method: %s", e)
+			return nil, e
 		}
-
-		log.Printf("found %d trades on first load from backing exchange\n", len(trades))
 	} else {
 		log.Printf("backingFillTracker was nil so not loading trades at creation time\n")
 	}
@@ -319,30 +305,27 @@ func makeMirrorStrategy(
 	}
 
 	return &mirrorStrategy{
-		sdex:                                  sdex,
-		ieif:                                  ieif,
-		baseAsset:                             baseAsset,
-		quoteAsset:                            quoteAsset,
-		primaryConstraints:                    primaryConstraints,
-		marketID:                              marketID,
-		backingPair:                           backingPair,
-		backingConstraints:                    backingConstraints,
-		backingMarketID:                       backingMarketID,
-		backingFillTracker:                    backingFillTracker,
-		strategyMirrorTradeTriggerExistsQuery: strategyMirrorTradeTriggerExistsQuery,
-		orderbookDepth:                        config.OrderbookDepth,
-		perLevelSpread:                        config.PerLevelSpread,
-		bidVolumeDivideBy:                     bidVolumeDivideBy,
-		askVolumeDivideBy:                     askVolumeDivideBy,
-		maybeMaxOrderBaseCap:                  config.MaxOrderBaseCap,
-		exchange:                              exchange,
-		offsetTrades:                          config.OffsetTrades,
-		mutex:                                 &sync.Mutex{},
+		sdex:                 sdex,
+		ieif:                 ieif,
+		baseAsset:            baseAsset,
+		quoteAsset:           quoteAsset,
+		primaryConstraints:   primaryConstraints,
+		backingPair:          backingPair,
+		backingConstraints:   backingConstraints,
+		backingFillTracker:   backingFillTracker,
+		tradeTriggers:        triggers,
+		orderbookDepth:       config.OrderbookDepth,
+		perLevelSpread:       config.PerLevelSpread,
+		bidVolumeDivideBy:    bidVolumeDivideBy,
+		askVolumeDivideBy:    askVolumeDivideBy,
+		maybeMaxOrderBaseCap: config.MaxOrderBaseCap,
+		exchange:             exchange,
+		offsetTrades:         config.OffsetTrades,
+		mutex:                &sync.Mutex{},
 		baseSurplus: map[model.OrderAction]*assetSurplus{
 			model.OrderActionBuy:  makeAssetSurplus(),
 			model.OrderActionSell: makeAssetSurplus(),
 		},
-		db: db,
 	}, nil
 }
 
@@ -796,13 +779,9 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 	defer s.mutex.Unlock()
 
 	// first check if this trade has already been handled
-	queryResult, e := s.strategyMirrorTradeTriggerExistsQuery.QueryRow(trade.TransactionID.String())
+	rowExists, e := s.tradeTriggers.exists(trade.TransactionID.String())
 	if e != nil {
-		return fmt.Errorf("unable to fetch trade trigger for transactionID '%s': %s", trade.TransactionID.String(), e)
-	}
-	rowExists, ok := queryResult.(bool)
-	if !ok {
-		return fmt.Errorf("unable to convert result of strategyMirrorTradeTriggerExistsQuery to bool: %v (type=%T)", queryResult, queryResult)
+		return e
 	}
 	if rowExists {
 		log.Printf("trade with txid '%s' was previously handled because we have a row in the strategy_mirror_trade_triggers table with this txid, not handling again and returning\n", trade.TransactionID.String())
@@ -841,18 +820,9 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 		newOrder.Volume.Multiply(*newOrder.Price).AsFloat(),
 		newOrder.Price.AsFloat())
 
-	// when offsetting trades we always submit as a taker order so use api.SubmitModeBoth
-	transactionID, e := s.exchange.AddOrder(&newOrder, api.SubmitModeBoth)
-	if e != nil {
-		return fmt.Errorf("error when offsetting trade (newOrder=%s): %s", newOrder, e)
-	}
-	if transactionID == nil {
-		return fmt.Errorf("error when offsetting trade (newOrder=%s): transactionID was <nil>", newOrder)
-	}
-	// insert into the db immediately after placing order on backing exchange
-	e = s.insertTradeTrigger(trade.TransactionID.String(), transactionID.String())
+	transactionID, e := s.tradeTriggers.placeBackingOrder(s.exchange, &newOrder, trade.TransactionID.String())
 	if e != nil {
-		return fmt.Errorf("error when inserting trade trigger with txID=%s (newOrder=%s) (PK dupes not allowed): %s", transactionID.String(), newOrder, e)
+		return fmt.Errorf("error when offsetting trade: %s", e)
 	}
 
 	// update the baseSurplus on success
@@ -874,35 +844,7 @@ func (s *mirrorStrategy) HandleFill(trade model.Trade) error {
 		transactionID)
 
 	// trigger fill tracking on backing exchange
-	trades, e := s.backingFillTracker.FillTrackSingleIteration()
-	if e != nil {
-		return fmt.Errorf("unable to track a single iteration of fills from the backing exchange: %s", e)
-	}
-	log.Printf("found %d trades on load from backing exchange in HandleFill\n", len(trades))
-
-	return nil
-}
-
-func (s *mirrorStrategy) insertTradeTrigger(primaryTxID string, backingTxID string) error {
-	sqlInsert := fmt.Sprintf(kelpdb.SqlStrategyMirrorTradeTriggersInsertTemplate,
-		s.marketID,
-		primaryTxID,
-		s.backingMarketID,
-		backingTxID,
-	)
-	_, e := s.db.Exec(sqlInsert)
-	if e != nil {
-		if strings.Contains(e.Error(), "duplicate key value violates unique constraint \"strategy_mirror_trade_triggers_pkey\"") {
-			log.Printf("trying to reinsert trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s) to db, ignore and continue\n", s.marketID, primaryTxID, s.backingMarketID, backingTxID)
-			return nil
-		}
-
-		// return an error on any other errors
-		return fmt.Errorf("could not execute sql insert values statement (%s): %s", sqlInsert, e)
-	}
-
-	log.Printf("wrote trade trigger (market_id=%s, txid=%s, backing_market_id=%s, backing_txid=%s) to db\n", s.marketID, primaryTxID, s.backingMarketID, backingTxID)
-	return nil
+	return trackBackingFills(s.backingFillTracker, "HandleFill")
 }
 
 // balanceCoordinator coordinates the balances from the backing exchange with orders placed on the primary exchange
@@ -0,0 +1,91 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// MultiFillTracker tracks fills for the fill trackers of multiple markets on a single background thread
+type MultiFillTracker struct {
+	fillTrackers                     []api.FillTracker
+	fillTrackerSleepMillis           uint32
+	fillTrackerDeleteCyclesThreshold int64
+	clock                            api.Clock
+
+	// initialized runtime vars
+	fillTrackerDeleteCycles int64
+}
+
+// MakeMultiFillTracker is a factory method
+func MakeMultiFillTracker(
+	fillTrackers []api.FillTracker,
+	fillTrackerSleepMillis uint32,
+	fillTrackerDeleteCyclesThreshold int64,
+	clock api.Clock,
+) *MultiFillTracker {
+	return &MultiFillTracker{
+		fillTrackers:                     fillTrackers,
+		fillTrackerSleepMillis:           fillTrackerSleepMillis,
+		fillTrackerDeleteCyclesThreshold: fillTrackerDeleteCyclesThreshold,
+		clock:                            clock,
+		// initialized runtime vars
+		fillTrackerDeleteCycles: 0,
+	}
+}
+
+// NumHandlers returns the total number of handlers across all fill trackers
+func (f *MultiFillTracker) NumHandlers() int {
+	n := 0
+	for _, ft := range f.fillTrackers {
+		n += int(ft.NumHandlers())
+	}
+	return n
+}
+
+// TrackFills runs a single iteration of each fill tracker in turn and then sleeps, it should be executed in a new thread
+func (f *MultiFillTracker) TrackFills() error {
+	for {
+		e := f.trackFillsSingleIteration()
+		if e != nil {
+			eMsg := fmt.Sprintf("error when running an iteration of the multi-market fill tracker: %s", e)
+			if f.countError() {
+				return fmt.Errorf(eMsg)
+			}
+			log.Printf("%s\n", eMsg)
+		} else {
+			f.fillTrackerDeleteCycles = 0
+		}
+
+		f.clock.Sleep(time.Duration(f.fillTrackerSleepMillis) * time.Millisecond)
+	}
+}
+
+func (f *MultiFillTracker) trackFillsSingleIteration() error {
+	for _, ft := range f.fillTrackers {
+		_, e := ft.FillTrackSingleIteration()
+		if e != nil {
+			return fmt.Errorf("error tracking fills for market %s: %s", ft.GetPair(), e)
+		}
+	}
+	return nil
+}
+
+// countError updates the error count and returns true if the error limit has been exceeded
+func (f *MultiFillTracker) countError() bool {
+	if f.fillTrackerDeleteCyclesThreshold < 0 {
+		log.Printf("not deleting any offers because fillTrackerDeleteCyclesThreshold is negative\n")
+		return false
+	}
+
+	f.fillTrackerDeleteCycles++
+	if f.fillTrackerDeleteCycles <= f.fillTrackerDeleteCyclesThreshold {
+		log.Printf("not deleting any offers, fillTrackerDeleteCycles (=%d) needs to exceed fillTrackerDeleteCyclesThreshold (=%d)\n", f.fillTrackerDeleteCycles, f.fillTrackerDeleteCyclesThreshold)
+		return false
+	}
+
+	log.Printf("deleting all offers, num. continuous fill tracking cycles with errors (including this one): %d; (fillTrackerDeleteCyclesThreshold to be exceeded=%d)\n", f.fillTrackerDeleteCycles, f.fillTrackerDeleteCyclesThreshold)
+	return true
+}
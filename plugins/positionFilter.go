@@ -0,0 +1,209 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+type positionFilterLimitType string
+
+// type of positionFilterLimitType
+const (
+	positionFilterLimitMax positionFilterLimitType = "max"
+	positionFilterLimitMin positionFilterLimitType = "min"
+)
+
+// String is the Stringer method
+func (t positionFilterLimitType) String() string {
+	return string(t)
+}
+
+func parsePositionFilterLimitType(limitType string) (positionFilterLimitType, error) {
+	if limitType == string(positionFilterLimitMax) {
+		return positionFilterLimitMax, nil
+	} else if limitType == string(positionFilterLimitMin) {
+		return positionFilterLimitMin, nil
+	}
+	return positionFilterLimitMax, fmt.Errorf("invalid limit type '%s'", limitType)
+}
+
+// PositionFilterConfig limits the net position of the base asset, which is the balance of the base asset plus the amount bought by our buy
+// offers (for the "max" limit type) or minus the amount sold by our sell offers (for the "min" limit type) if they were all filled.
+// The "max" limit type only affects buy offers and the "min" limit type only affects sell offers.
+type PositionFilterConfig struct {
+	LimitInBaseUnits  *float64
+	LimitInQuoteUnits *float64
+	limitType         positionFilterLimitType
+	mode              volumeFilterMode
+}
+
+// Validate ensures validity
+func (c *PositionFilterConfig) Validate() error {
+	if c.LimitInBaseUnits != nil && c.LimitInQuoteUnits != nil {
+		return fmt.Errorf("invalid limits: only one limit can be non-nil, but both are non-nil")
+	}
+
+	if c.LimitInBaseUnits == nil && c.LimitInQuoteUnits == nil {
+		return fmt.Errorf("invalid limits: only one limit can be non-nil, but both are nil")
+	}
+
+	if (c.LimitInBaseUnits != nil && *c.LimitInBaseUnits < 0) || (c.LimitInQuoteUnits != nil && *c.LimitInQuoteUnits < 0) {
+		return fmt.Errorf("invalid limits: limit cannot be negative")
+	}
+
+	if _, e := parsePositionFilterLimitType(string(c.limitType)); e != nil {
+		return fmt.Errorf("could not parse limit type: %s", e)
+	}
+
+	if _, e := parseVolumeFilterMode(string(c.mode)); e != nil {
+		return fmt.Errorf("could not parse mode: %s", e)
+	}
+
+	return nil
+}
+
+// String is the stringer method
+func (c *PositionFilterConfig) String() string {
+	return fmt.Sprintf("PositionFilterConfig[LimitInBaseUnits=%s, LimitInQuoteUnits=%s, limitType=%s, mode=%s]",
+		utils.CheckedFloatPtr(c.LimitInBaseUnits), utils.CheckedFloatPtr(c.LimitInQuoteUnits), c.limitType, c.mode)
+}
+
+type positionFilter struct {
+	name        string
+	configValue string
+	baseAsset   hProtocol.Asset
+	quoteAsset  hProtocol.Asset
+	config      *PositionFilterConfig
+	balanceFn   func(asset hProtocol.Asset) (*api.Balance, error)
+}
+
+// makeFilterPosition makes a submit filter that limits orders placed based on the net position of the base asset
+func makeFilterPosition(
+	configValue string,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	balanceFn func(asset hProtocol.Asset) (*api.Balance, error),
+	config *PositionFilterConfig,
+) (SubmitFilter, error) {
+	e := config.Validate()
+	if e != nil {
+		return nil, fmt.Errorf("invalid config: %s", e)
+	}
+
+	return &positionFilter{
+		name:        "positionFilter",
+		configValue: configValue,
+		baseAsset:   baseAsset,
+		quoteAsset:  quoteAsset,
+		config:      config,
+		balanceFn:   balanceFn,
+	}, nil
+}
+
+var _ SubmitFilter = &positionFilter{}
+
+func (f *positionFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	balance, e := f.balanceFn(f.baseAsset)
+	if e != nil {
+		return nil, fmt.Errorf("could not load balance of base asset (%s): %s", utils.Asset2String(f.baseAsset), e)
+	}
+	log.Printf("positionFilter: balance = %.8f %s (%s)\n", balance.Balance, utils.Asset2String(f.baseAsset), f.config)
+
+	// committed accumulates the base units bought (for the max limit type) or sold (for the min limit type) by the offers that we keep
+	committed := 0.0
+	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return positionFilterFn(f.config, balance.Balance, &committed, op, f.baseAsset, f.quoteAsset)
+	}
+	ops, e = filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+func positionFilterFn(
+	config *PositionFilterConfig,
+	balance float64,
+	committed *float64,
+	op *txnbuild.ManageSellOffer,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+) (*txnbuild.ManageSellOffer, error) {
+	isSell, e := utils.IsSelling(baseAsset, quoteAsset, op.Selling, op.Buying)
+	if e != nil {
+		return nil, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+	}
+
+	// the max limit is reached by buying and the min limit is reached by selling
+	isMax := config.limitType == positionFilterLimitMax
+	if isSell == isMax {
+		return op, nil
+	}
+
+	offerPrice, e := strconv.ParseFloat(op.Price, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
+	}
+	offerAmount, e := strconv.ParseFloat(op.Amount, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert amount (%s) to float: %s", op.Amount, e)
+	}
+	// A "buy" op has amount = sellAmount * sellPrice, and price = 1/sellPrice
+	// So, we adjust the offer variables by "undoing" those adjustments so the amount is in base units and the price is in quote units
+	if !isSell {
+		offerAmount = offerAmount * offerPrice
+		offerPrice = 1 / offerPrice
+	}
+
+	// the limit in quote units is converted to base units using the price of the offer
+	limit := 0.0
+	if config.LimitInBaseUnits != nil {
+		limit = *config.LimitInBaseUnits
+	} else {
+		limit = *config.LimitInQuoteUnits / offerPrice
+	}
+
+	// remaining is the amount of the base asset that can still be bought or sold before we reach the limit
+	remaining := (balance - *committed) - limit
+	if isMax {
+		remaining = limit - (balance + *committed)
+	}
+
+	if offerAmount <= remaining {
+		*committed += offerAmount
+		log.Printf("positionFilter: isSell=%v, offerPrice=%.10f, offerAmount (%.10f) <= remaining (%.10f); keep=true", isSell, offerPrice, offerAmount, remaining)
+		return op, nil
+	}
+
+	if config.mode == volumeFilterModeIgnore {
+		log.Printf("positionFilter: isSell=%v, offerPrice=%.10f, offerAmount (%.10f) > remaining (%.10f); mode=%s, keep=false", isSell, offerPrice, offerAmount, remaining, config.mode)
+		return nil, nil
+	}
+
+	if remaining <= 0 {
+		log.Printf("positionFilter: isSell=%v, offerPrice=%.10f, remaining (%.10f) <= 0; keep=false", isSell, offerPrice, remaining)
+		return nil, nil
+	}
+	*committed += remaining
+
+	// convert the amount back to the units of the op for buy ops, see volumeFilterFn
+	newOpAmount := remaining
+	if !isSell {
+		newOpAmount = newOpAmount * offerPrice
+	}
+	op.Amount = fmt.Sprintf("%.7f", newOpAmount)
+
+	log.Printf("positionFilter: isSell=%v, offerPrice=%.10f, newOpAmount=%s; keep=true", isSell, offerPrice, op.Amount)
+	return op, nil
+}
+
+// String is the Stringer method
+func (f *positionFilter) String() string {
+	return f.configValue
+}
@@ -0,0 +1,266 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/openlyinc/pointy"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionFilterFn(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        *PositionFilterConfig
+		balance       float64
+		committed     float64
+		isSell        bool
+		inputPrice    float64
+		inputAmount   float64
+		wantAmount    *float64
+		wantCommitted float64
+	}{
+		{
+			name:          "max; sell ops are not affected",
+			config:        &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMax, mode: volumeFilterModeExact},
+			balance:       100,
+			committed:     0,
+			isSell:        true,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    pointy.Float64(5.0),
+			wantCommitted: 0,
+		}, {
+			name:          "max; projected < limit",
+			config:        &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMax, mode: volumeFilterModeExact},
+			balance:       3,
+			committed:     1,
+			isSell:        false,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    pointy.Float64(5.0),
+			wantCommitted: 6,
+		}, {
+			name:          "max; projected = limit",
+			config:        &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMax, mode: volumeFilterModeExact},
+			balance:       3,
+			committed:     2,
+			isSell:        false,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    pointy.Float64(5.0),
+			wantCommitted: 7,
+		}, {
+			name:          "max; projected > limit; exact",
+			config:        &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMax, mode: volumeFilterModeExact},
+			balance:       5,
+			committed:     2,
+			isSell:        false,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    pointy.Float64(3.0),
+			wantCommitted: 5,
+		}, {
+			name:          "max; projected > limit; ignore",
+			config:        &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMax, mode: volumeFilterModeIgnore},
+			balance:       5,
+			committed:     2,
+			isSell:        false,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    nil,
+			wantCommitted: 2,
+		}, {
+			name:          "max; balance already above limit",
+			config:        &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMax, mode: volumeFilterModeExact},
+			balance:       12,
+			committed:     0,
+			isSell:        false,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    nil,
+			wantCommitted: 0,
+		}, {
+			name:          "max; quote limit; projected > limit; exact",
+			config:        &PositionFilterConfig{LimitInQuoteUnits: pointy.Float64(20), limitType: positionFilterLimitMax, mode: volumeFilterModeExact},
+			balance:       7,
+			committed:     0,
+			isSell:        false,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    pointy.Float64(3.0),
+			wantCommitted: 3,
+		}, {
+			name:          "min; buy ops are not affected",
+			config:        &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMin, mode: volumeFilterModeExact},
+			balance:       0,
+			committed:     0,
+			isSell:        false,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    pointy.Float64(5.0),
+			wantCommitted: 0,
+		}, {
+			name:          "min; projected > limit",
+			config:        &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMin, mode: volumeFilterModeExact},
+			balance:       20,
+			committed:     2,
+			isSell:        true,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    pointy.Float64(5.0),
+			wantCommitted: 7,
+		}, {
+			name:          "min; projected < limit; exact",
+			config:        &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMin, mode: volumeFilterModeExact},
+			balance:       20,
+			committed:     7,
+			isSell:        true,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    pointy.Float64(3.0),
+			wantCommitted: 10,
+		}, {
+			name:          "min; projected < limit; ignore",
+			config:        &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMin, mode: volumeFilterModeIgnore},
+			balance:       20,
+			committed:     7,
+			isSell:        true,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    nil,
+			wantCommitted: 7,
+		}, {
+			name:          "min; quote limit; projected < limit; exact",
+			config:        &PositionFilterConfig{LimitInQuoteUnits: pointy.Float64(20), limitType: positionFilterLimitMin, mode: volumeFilterModeExact},
+			balance:       12,
+			committed:     0,
+			isSell:        true,
+			inputPrice:    2.0,
+			inputAmount:   5.0,
+			wantAmount:    pointy.Float64(2.0),
+			wantCommitted: 2,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			inputOp := makeSellOpAmtPrice(k.inputAmount, k.inputPrice)
+			if !k.isSell {
+				inputOp = makeBuyOpAmtPrice(k.inputAmount, k.inputPrice)
+			}
+			// the buy op is in units of the quote asset so we convert the wanted amount in the same way
+			wantOpAmount := k.wantAmount
+			if k.wantAmount != nil && !k.isSell {
+				wantOpAmount = pointy.Float64(*k.wantAmount * k.inputPrice)
+			}
+
+			base := utils.Asset2Asset2(testBaseAsset)
+			quote := utils.Asset2Asset2(testQuoteAsset)
+			committed := k.committed
+			actual, e := positionFilterFn(k.config, k.balance, &committed, inputOp, base, quote)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantCommitted, committed, 0.0000001)
+
+			if wantOpAmount == nil {
+				assert.Nil(t, actual)
+				return
+			}
+			if !assert.NotNil(t, actual) {
+				return
+			}
+			actualAmount, e := strconv.ParseFloat(actual.Amount, 64)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, *wantOpAmount, actualAmount, 0.000001)
+		})
+	}
+}
+
+func TestPositionFilterApply(t *testing.T) {
+	balanceFn := func(asset hProtocol.Asset) (*api.Balance, error) {
+		return &api.Balance{Balance: 4}, nil
+	}
+	config := &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(10), limitType: positionFilterLimitMax, mode: volumeFilterModeExact}
+	f, e := makeFilterPosition("position/max/base/10/exact", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), balanceFn, config)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// the limit leaves room to buy 6 units of base across all the buy ops
+	ops, e := f.Apply(opsToInterfaces(
+		makeBuyOpAmtPrice(4.0, 2.0),
+		makeSellOpAmtPrice(100.0, 3.0),
+		makeBuyOpAmtPrice(4.0, 1.0),
+		makeBuyOpAmtPrice(4.0, 0.5),
+	), nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 3, len(ops)) {
+		return
+	}
+	assert.Equal(t, fmt.Sprintf("%.7f", 8.0), ops[0].(*txnbuild.ManageSellOffer).Amount)
+	assert.Equal(t, fmt.Sprintf("%.7f", 100.0), ops[1].(*txnbuild.ManageSellOffer).Amount)
+	assert.Equal(t, fmt.Sprintf("%.7f", 2.0), ops[2].(*txnbuild.ManageSellOffer).Amount)
+}
+
+func opsToInterfaces(ops ...*txnbuild.ManageSellOffer) []txnbuild.Operation {
+	result := []txnbuild.Operation{}
+	for _, op := range ops {
+		result = append(result, op)
+	}
+	return result
+}
+
+func TestMakePositionFilterConfig(t *testing.T) {
+	testCases := []struct {
+		configInput string
+		wantConfig  *PositionFilterConfig
+	}{
+		{
+			configInput: "position/max/base/1000/exact",
+			wantConfig:  &PositionFilterConfig{LimitInBaseUnits: pointy.Float64(1000), limitType: positionFilterLimitMax, mode: volumeFilterModeExact},
+		}, {
+			configInput: "position/min/quote/50.5/ignore",
+			wantConfig:  &PositionFilterConfig{LimitInQuoteUnits: pointy.Float64(50.5), limitType: positionFilterLimitMin, mode: volumeFilterModeIgnore},
+		}, {
+			configInput: "position/max/base/1000",
+			wantConfig:  nil,
+		}, {
+			configInput: "position/middle/base/1000/exact",
+			wantConfig:  nil,
+		}, {
+			configInput: "position/max/asset/1000/exact",
+			wantConfig:  nil,
+		}, {
+			configInput: "position/max/base/-1/exact",
+			wantConfig:  nil,
+		}, {
+			configInput: "position/max/base/1000/partial",
+			wantConfig:  nil,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.configInput, func(t *testing.T) {
+			actual, e := makePositionFilterConfig(k.configInput)
+			if k.wantConfig == nil {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantConfig, actual)
+		})
+	}
+}
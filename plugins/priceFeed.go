@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
 
@@ -33,8 +34,28 @@ func SetPrivateSdexHack(api *horizonclient.Client, ieif *IEIF, network string) e
 	return nil
 }
 
-// MakePriceFeed makes a PriceFeed
+// privatePriceFeedDBHackVar is the db used by the "ema" and "twap" price feed functions to persist their samples
+var privatePriceFeedDBHackVar *sql.DB
+
+// SetPrivatePriceFeedDBHack sets the db that is used by price feeds to persist their state across restarts
+func SetPrivatePriceFeedDBHack(db *sql.DB) error {
+	if privatePriceFeedDBHackVar != nil {
+		return fmt.Errorf("privatePriceFeedDBHack is already set")
+	}
+
+	privatePriceFeedDBHackVar = db
+	return nil
+}
+
+// MakePriceFeed makes a PriceFeed, feeds with the same type and URL share a single instance from the process-wide price feed registry
 func MakePriceFeed(feedType string, url string) (api.PriceFeed, error) {
+	return priceFeedRegistryVar.getOrMake(feedType, url, func() (api.PriceFeed, error) {
+		return makePriceFeed(feedType, url)
+	})
+}
+
+// makePriceFeed makes a new instance of a PriceFeed that is not shared
+func makePriceFeed(feedType string, url string) (api.PriceFeed, error) {
 	switch feedType {
 	case "crypto":
 		return newCMCFeed(url), nil
@@ -44,53 +65,165 @@ func MakePriceFeed(feedType string, url string) (api.PriceFeed, error) {
 		return newFiatFeedOxr(url), nil
 	case "fixed":
 		return newFixedFeed(url)
+	case "json":
+		jsonFeed, e := newJSONFeed(url)
+		if e != nil {
+			return nil, fmt.Errorf("error occurred while making the json price feed: %s", e)
+		}
+		return jsonFeed, nil
 	case "exchange":
-		// [0] = exchangeType, [1] = base, [2] = quote, [3] = modifier (optional)
-		urlParts := strings.Split(url, "/")
-		if len(urlParts) < 3 || len(urlParts) > 4 {
-			return nil, fmt.Errorf("invalid format of exchange type URL, needs either 3 or 4 parts after splitting URL by '/', has %d: %s", len(urlParts), url)
+		feedURL, e := parseExchangeFeedURL(url)
+		if e != nil {
+			return nil, e
 		}
 
-		// LOH-2 - support backward-compatible case of defaulting to "mid" price when left unspecified
-		exchangeModifier := "mid"
-		if len(urlParts) == 4 {
-			exchangeModifier = urlParts[3]
+		// websocket exchanges use the ccxt exchange to convert assets and as the REST fallback
+		exchangeName := feedURL.exchangeName
+		if isWsExchange(feedURL.exchangeName) {
+			exchangeName, e = wsRestExchangeName(feedURL.exchangeName)
+			if e != nil {
+				return nil, fmt.Errorf("cannot make priceFeed: %s", e)
+			}
 		}
 
-		exchange, e := MakeExchange(urlParts[0], true)
+		exchange, e := MakeExchange(exchangeName, true)
 		if e != nil {
-			return nil, fmt.Errorf("cannot make priceFeed because of an error when making the '%s' exchange: %s", urlParts[0], e)
+			return nil, fmt.Errorf("cannot make priceFeed because of an error when making the '%s' exchange: %s", exchangeName, e)
 		}
-		baseAsset, e := exchange.GetAssetConverter().FromString(urlParts[1])
+		tradingPair, e := feedURL.tradingPair(exchange.GetAssetConverter())
 		if e != nil {
-			return nil, fmt.Errorf("cannot make priceFeed because of an error when converting the base asset: %s", e)
+			return nil, fmt.Errorf("cannot make priceFeed: %s", e)
 		}
-		quoteAsset, e := exchange.GetAssetConverter().FromString(urlParts[2])
-		if e != nil {
-			return nil, fmt.Errorf("cannot make priceFeed because of an error when converting the quote asset: %s", e)
-		}
-		tradingPair := model.TradingPair{
-			Base:  baseAsset,
-			Quote: quoteAsset,
+		if isWsExchange(feedURL.exchangeName) {
+			wsSource, e := getOrStartWsTickerSource(feedURL.exchangeName, tradingPair, exchange)
+			if e != nil {
+				return nil, fmt.Errorf("cannot make priceFeed because of an error when starting the websocket: %s", e)
+			}
+			// only the "last" modifier needs a trade on the websocket, the other modifiers are served from the local orderbook
+			tickerAPI := api.TickerAPI(wsSource)
+			if feedURL.modifier != "last" {
+				tickerAPI = &wsBookTicker{source: wsSource}
+			}
+			return newExchangeFeed(url, &tickerAPI, wsSource, tradingPair, feedURL.modifier)
 		}
 		tickerAPI := api.TickerAPI(exchange)
-		return newExchangeFeed(url, &tickerAPI, &tradingPair, exchangeModifier)
+		return newExchangeFeed(url, &tickerAPI, exchange, tradingPair, feedURL.modifier)
 	case "sdex":
 		sdex, e := makeSDEXFeed(url)
 		if e != nil {
 			return nil, fmt.Errorf("error occurred while making the SDEX price feed: %s", e)
 		}
 		return sdex, nil
+	case "sdex-trades":
+		sdexTrades, e := makeSDEXTradesFeed(url)
+		if e != nil {
+			return nil, fmt.Errorf("error occurred while making the sdex-trades price feed: %s", e)
+		}
+		return sdexTrades, nil
 	case "function":
 		fnFeed, e := makeFunctionPriceFeed(url)
 		if e != nil {
-			return nil, fmt.Errorf("error while making function feed for URL '%s': %s", url, e)
+			return nil, fmt.Errorf("error while making function feed for URL '%s': %s", redactJSONFeedHeaders(url), e)
 		}
 		return fnFeed, nil
 	}
 	return nil, fmt.Errorf("unable to make price feed for feedType=%s and url=%s", feedType, url)
 }
 
+// exchangeFeedURL is the parsed URL of an "exchange" price feed
+type exchangeFeedURL struct {
+	exchangeName string
+	base         string
+	quote        string
+	modifier     string
+}
+
+// parseExchangeFeedURL parses the URL of an "exchange" price feed, which has the format exchangeName/base/quote[/modifier]
+func parseExchangeFeedURL(url string) (*exchangeFeedURL, error) {
+	// [0] = exchangeType, [1] = base, [2] = quote, [3] = modifier (optional)
+	urlParts := strings.Split(url, "/")
+	if len(urlParts) < 3 || len(urlParts) > 4 {
+		return nil, fmt.Errorf("invalid format of exchange type URL, needs either 3 or 4 parts after splitting URL by '/', has %d: %s", len(urlParts), url)
+	}
+
+	// LOH-2 - support backward-compatible case of defaulting to "mid" price when left unspecified
+	exchangeModifier := "mid"
+	if len(urlParts) == 4 {
+		exchangeModifier = urlParts[3]
+	}
+
+	return &exchangeFeedURL{
+		exchangeName: urlParts[0],
+		base:         urlParts[1],
+		quote:        urlParts[2],
+		modifier:     exchangeModifier,
+	}, nil
+}
+
+// tradingPair converts the base and quote of the URL to a trading pair using the converter of the exchange
+func (u *exchangeFeedURL) tradingPair(converter model.AssetConverterInterface) (*model.TradingPair, error) {
+	baseAsset, e := converter.FromString(u.base)
+	if e != nil {
+		return nil, fmt.Errorf("error when converting the base asset: %s", e)
+	}
+	quoteAsset, e := converter.FromString(u.quote)
+	if e != nil {
+		return nil, fmt.Errorf("error when converting the quote asset: %s", e)
+	}
+	return &model.TradingPair{
+		Base:  baseAsset,
+		Quote: quoteAsset,
+	}, nil
+}
+
+// PriceFeedFactory makes the price feeds used by strategies. An exchange can be injected so that "exchange" feeds on the market of that
+// exchange are served by the injected instance, such as the simulated exchange of a backtest, instead of connecting to the real exchange
+type PriceFeedFactory struct {
+	ExchangeName string             // name of the exchange in "exchange" feed URLs that is served by Exchange
+	TradingPair  *model.TradingPair // market of the exchange that is served by Exchange
+	Exchange     api.Exchange       // can be nil, in which case all feeds are made with MakePriceFeed
+}
+
+// MakePriceFeed makes a PriceFeed, using the injected exchange when the feed is on the injected market
+func (f *PriceFeedFactory) MakePriceFeed(feedType string, url string) (api.PriceFeed, error) {
+	if f.Exchange == nil || feedType != "exchange" {
+		return MakePriceFeed(feedType, url)
+	}
+
+	feedURL, e := parseExchangeFeedURL(url)
+	if e != nil {
+		return nil, e
+	}
+	if feedURL.exchangeName != f.ExchangeName {
+		return MakePriceFeed(feedType, url)
+	}
+	tradingPair, e := feedURL.tradingPair(f.Exchange.GetAssetConverter())
+	if e != nil || *tradingPair != *f.TradingPair {
+		return MakePriceFeed(feedType, url)
+	}
+
+	tickerAPI := api.TickerAPI(f.Exchange)
+	return newExchangeFeed(url, &tickerAPI, f.Exchange, f.TradingPair, feedURL.modifier)
+}
+
+// MakeFeedPair makes a FeedPair using MakePriceFeed on this factory
+func (f *PriceFeedFactory) MakeFeedPair(dataTypeA, dataFeedAUrl, dataTypeB, dataFeedBUrl string) (*api.FeedPair, error) {
+	feedA, e := f.MakePriceFeed(dataTypeA, dataFeedAUrl)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make a feed pair because of an error when making priceFeed A: %s", e)
+	}
+
+	feedB, e := f.MakePriceFeed(dataTypeB, dataFeedBUrl)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make a feed pair because of an error when making priceFeed B: %s", e)
+	}
+
+	return &api.FeedPair{
+		FeedA: feedA,
+		FeedB: feedB,
+	}, nil
+}
+
 // MakeFeedPair is the factory method that we expose
 func MakeFeedPair(dataTypeA, dataFeedAUrl, dataTypeB, dataFeedBUrl string) (*api.FeedPair, error) {
 	feedA, e := MakePriceFeed(dataTypeA, dataFeedAUrl)
@@ -1,44 +1,89 @@
 package plugins
 
 import (
+	"database/sql"
 	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/stellar/kelp/api"
 )
 
-type fnFactory func(feeds []api.PriceFeed) (api.PriceFeed, error)
+// fnFactory makes a function feed from the params in the function name (i.e. name:param1:param2) and the feeds passed as arguments
+type fnFactory func(params []string, feeds []api.PriceFeed) (api.PriceFeed, error)
 
 var fnFactoryMap = map[string]fnFactory{
-	"max":    max,
-	"invert": invert,
+	"max":      max,
+	"invert":   invert,
+	"min":      min,
+	"mean":     mean,
+	"median":   median,
+	"weighted": weighted,
+	"product":  product,
+	"ratio":    ratio,
+	"outlier":  outlier,
+	"fallback": fallback,
+	"sanity":   sanity,
+	"ema":      ema,
+	"twap":     twap,
 }
 
-func max(feeds []api.PriceFeed) (api.PriceFeed, error) {
+// feedKeyRegex restricts the keys used to persist the samples of price feeds to safe characters
+var feedKeyRegex = regexp.MustCompile("^[a-zA-Z0-9_.-]+$")
+
+// aggregatorFnMap lists the functions that can be used to aggregate the remaining prices in the 'outlier' function
+var aggregatorFnMap = map[string]func(prices []float64) float64{
+	"max":    computeMax,
+	"min":    computeMin,
+	"mean":   computeMean,
+	"median": computeMedian,
+}
+
+func max(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	return makeAggregateFeed("max", computeMax, params, feeds)
+}
+
+func min(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	return makeAggregateFeed("min", computeMin, params, feeds)
+}
+
+func mean(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	return makeAggregateFeed("mean", computeMean, params, feeds)
+}
+
+func median(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	return makeAggregateFeed("median", computeMedian, params, feeds)
+}
+
+func product(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	return makeAggregateFeed("product", computeProduct, params, feeds)
+}
+
+// makeAggregateFeed makes a function feed that aggregates the prices of 2 or more feeds using the passed in function
+func makeAggregateFeed(fnName string, aggregateFn func(prices []float64) float64, params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	if len(params) != 0 {
+		return nil, fmt.Errorf("the '%s' price feed function does not take any params but found %d params: %v", fnName, len(params), params)
+	}
 	if len(feeds) < 2 {
-		return nil, fmt.Errorf("need to provide at least 2 price feeds to the 'max' price feed function but found only %d price feeds", len(feeds))
+		return nil, fmt.Errorf("need to provide at least 2 price feeds to the '%s' price feed function but found only %d price feeds", fnName, len(feeds))
 	}
 
 	return makeFunctionFeed(func() (float64, error) {
-		max := -1.0
-		for i, f := range feeds {
-			innerPrice, e := f.GetPrice()
-			if e != nil {
-				return 0.0, fmt.Errorf("error fetching price from feed (index=%d) in 'max' function feed: %s", i, e)
-			}
-
-			if innerPrice <= 0.0 {
-				return 0.0, fmt.Errorf("inner price of feed at index %d was <= 0.0 (%.10f)", i, innerPrice)
-			}
-
-			if innerPrice > max {
-				max = innerPrice
-			}
-		}
-		return max, nil
+		prices, e := fetchFeedPrices(fnName, feeds)
+		if e != nil {
+			return 0.0, e
+		}
+		return aggregateFn(prices), nil
 	}), nil
 }
 
-func invert(feeds []api.PriceFeed) (api.PriceFeed, error) {
+func invert(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	if len(params) != 0 {
+		return nil, fmt.Errorf("the 'invert' price feed function does not take any params but found %d params: %v", len(params), params)
+	}
 	if len(feeds) != 1 {
 		return nil, fmt.Errorf("need to provide exactly 1 price feed to the 'invert' function but found %d price feeds", len(feeds))
 	}
@@ -56,3 +101,277 @@ func invert(feeds []api.PriceFeed) (api.PriceFeed, error) {
 		return 1 / innerPrice, nil
 	}), nil
 }
+
+// ratio divides the price of the first feed by the price of the second feed
+func ratio(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	if len(params) != 0 {
+		return nil, fmt.Errorf("the 'ratio' price feed function does not take any params but found %d params: %v", len(params), params)
+	}
+	if len(feeds) != 2 {
+		return nil, fmt.Errorf("need to provide exactly 2 price feeds to the 'ratio' function but found %d price feeds", len(feeds))
+	}
+
+	return makeFunctionFeed(func() (float64, error) {
+		prices, e := fetchFeedPrices("ratio", feeds)
+		if e != nil {
+			return 0.0, e
+		}
+		return prices[0] / prices[1], nil
+	}), nil
+}
+
+// weighted takes one weight per feed as params, i.e. weighted:0.5:0.3:0.2(feed1,feed2,feed3), the weights are normalized to add up to 1
+func weighted(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	if len(feeds) < 2 {
+		return nil, fmt.Errorf("need to provide at least 2 price feeds to the 'weighted' price feed function but found only %d price feeds", len(feeds))
+	}
+	if len(params) != len(feeds) {
+		return nil, fmt.Errorf("need to provide exactly 1 weight per price feed to the 'weighted' price feed function but found %d weights for %d price feeds", len(params), len(feeds))
+	}
+
+	weights := []float64{}
+	for i, p := range params {
+		w, e := strconv.ParseFloat(p, 64)
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse weight at index %d ('%s') in the 'weighted' price feed function: %s", i, p, e)
+		}
+		if w <= 0.0 {
+			return nil, fmt.Errorf("weight at index %d in the 'weighted' price feed function needs to be greater than 0 but was %f", i, w)
+		}
+		weights = append(weights, w)
+	}
+
+	return makeFunctionFeed(func() (float64, error) {
+		prices, e := fetchFeedPrices("weighted", feeds)
+		if e != nil {
+			return 0.0, e
+		}
+		return computeWeightedMean(prices, weights), nil
+	}), nil
+}
+
+// outlier drops the feeds whose price deviates from the median by more than maxDeviationPct percent and aggregates the rest, i.e.
+// outlier:median:5(feed1,feed2,feed3) or outlier:mean:5:3(feed1,feed2,feed3,feed4) where the optional last param is the minimum number of
+// feeds that need to remain after dropping outliers (defaults to 2)
+func outlier(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	if len(params) < 2 || len(params) > 3 {
+		return nil, fmt.Errorf("the 'outlier' price feed function needs to be formatted as outlier:<aggregator>:<maxDeviationPct>[:<minFeeds>] but found %d params: %v", len(params), params)
+	}
+
+	aggregateFn, ok := aggregatorFnMap[params[0]]
+	if !ok {
+		return nil, fmt.Errorf("invalid aggregator '%s' in the 'outlier' price feed function, needs to be one of max, min, mean, or median", params[0])
+	}
+
+	maxDeviationPct, e := strconv.ParseFloat(params[1], 64)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse maxDeviationPct ('%s') in the 'outlier' price feed function: %s", params[1], e)
+	}
+	if maxDeviationPct <= 0.0 {
+		return nil, fmt.Errorf("maxDeviationPct in the 'outlier' price feed function needs to be greater than 0 but was %f", maxDeviationPct)
+	}
+
+	minFeeds := 2
+	if len(params) == 3 {
+		minFeeds, e = strconv.Atoi(params[2])
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse minFeeds ('%s') in the 'outlier' price feed function: %s", params[2], e)
+		}
+		if minFeeds < 1 {
+			return nil, fmt.Errorf("minFeeds in the 'outlier' price feed function needs to be at least 1 but was %d", minFeeds)
+		}
+	}
+
+	if len(feeds) < minFeeds || len(feeds) < 3 {
+		return nil, fmt.Errorf("need to provide at least 3 price feeds and at least minFeeds (%d) price feeds to the 'outlier' price feed function but found only %d price feeds", minFeeds, len(feeds))
+	}
+
+	return makeFunctionFeed(func() (float64, error) {
+		prices, e := fetchFeedPrices("outlier", feeds)
+		if e != nil {
+			return 0.0, e
+		}
+
+		remaining := rejectOutliers(prices, maxDeviationPct)
+		if len(remaining) < minFeeds {
+			return 0.0, fmt.Errorf("only %d of %d price feeds were within %.4f%% of the median price (%.10f) in the 'outlier' price feed function but need at least %d, prices=%v",
+				len(remaining), len(prices), maxDeviationPct, computeMedian(prices), minFeeds, prices)
+		}
+		return aggregateFn(remaining), nil
+	}), nil
+}
+
+// fallback uses the price from the first feed that does not fail, i.e. fallback(feed1,feed2,feed3)
+func fallback(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	if len(params) != 0 {
+		return nil, fmt.Errorf("the 'fallback' price feed function does not take any params but found %d params: %v", len(params), params)
+	}
+	if len(feeds) < 2 {
+		return nil, fmt.Errorf("need to provide at least 2 price feeds to the 'fallback' price feed function but found only %d price feeds", len(feeds))
+	}
+
+	return makeFallbackFeed(feeds), nil
+}
+
+// sanity rejects prices that jump by more than maxJumpPct percent from the last accepted price and serves the last accepted price while the
+// feed fails for up to ttlSeconds, i.e. sanity:<ttlSeconds>:<maxJumpPct>(feed)
+func sanity(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	if len(params) != 2 {
+		return nil, fmt.Errorf("the 'sanity' price feed function needs to be formatted as sanity:<ttlSeconds>:<maxJumpPct> but found %d params: %v", len(params), params)
+	}
+	if len(feeds) != 1 {
+		return nil, fmt.Errorf("need to provide exactly 1 price feed to the 'sanity' function but found %d price feeds", len(feeds))
+	}
+
+	ttlSeconds, e := strconv.ParseFloat(params[0], 64)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse ttlSeconds ('%s') in the 'sanity' price feed function: %s", params[0], e)
+	}
+	if ttlSeconds <= 0.0 {
+		return nil, fmt.Errorf("ttlSeconds in the 'sanity' price feed function needs to be greater than 0 but was %f", ttlSeconds)
+	}
+
+	maxJumpPct, e := strconv.ParseFloat(params[1], 64)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse maxJumpPct ('%s') in the 'sanity' price feed function: %s", params[1], e)
+	}
+	if maxJumpPct <= 0.0 {
+		return nil, fmt.Errorf("maxJumpPct in the 'sanity' price feed function needs to be greater than 0 but was %f", maxJumpPct)
+	}
+
+	ttl := time.Duration(ttlSeconds * float64(time.Second))
+	return makeSanityFeed(feeds[0], ttl, maxJumpPct, MakeRealClock()), nil
+}
+
+// ema returns the exponential moving average of the feed over the window, i.e. ema:<windowSeconds>[:<persistKey>](feed)
+func ema(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	return makeSmoothedFeedFromParams(smoothingEMA, params, feeds)
+}
+
+// twap returns the time-weighted average price of the feed over the window, i.e. twap:<windowSeconds>[:<persistKey>](feed)
+func twap(params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	return makeSmoothedFeedFromParams(smoothingTWAP, params, feeds)
+}
+
+// makeSmoothedFeedFromParams persists the samples in the db when the optional persistKey is provided so restarts do not reset the average
+func makeSmoothedFeedFromParams(smoothing string, params []string, feeds []api.PriceFeed) (api.PriceFeed, error) {
+	if len(params) < 1 || len(params) > 2 {
+		return nil, fmt.Errorf("the '%s' price feed function needs to be formatted as %s:<windowSeconds>[:<persistKey>] but found %d params: %v", smoothing, smoothing, len(params), params)
+	}
+	if len(feeds) != 1 {
+		return nil, fmt.Errorf("need to provide exactly 1 price feed to the '%s' function but found %d price feeds", smoothing, len(feeds))
+	}
+
+	windowSeconds, e := strconv.ParseFloat(params[0], 64)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse windowSeconds ('%s') in the '%s' price feed function: %s", params[0], smoothing, e)
+	}
+	if windowSeconds <= 0.0 {
+		return nil, fmt.Errorf("windowSeconds in the '%s' price feed function needs to be greater than 0 but was %f", smoothing, windowSeconds)
+	}
+
+	var db *sql.DB
+	feedKey := ""
+	if len(params) == 2 {
+		feedKey = params[1]
+		if !feedKeyRegex.MatchString(feedKey) {
+			return nil, fmt.Errorf("persistKey ('%s') in the '%s' price feed function can only contain letters, digits, '_', '.', and '-'", feedKey, smoothing)
+		}
+		if privatePriceFeedDBHackVar == nil {
+			return nil, fmt.Errorf("persistKey ('%s') in the '%s' price feed function needs the POSTGRES_DB to be set in the trader.cfg file", feedKey, smoothing)
+		}
+		db = privatePriceFeedDBHackVar
+		// use a different key for each type of smoothing since the samples are different
+		feedKey = smoothing + ":" + feedKey
+	}
+
+	window := time.Duration(windowSeconds * float64(time.Second))
+	f, e := makeSmoothedFeed(feeds[0], smoothing, window, MakeRealClock(), db, feedKey)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make %s feed: %s", smoothing, e)
+	}
+	return f, nil
+}
+
+// fetchFeedPrices fetches the price from every feed, all of which need to be positive
+func fetchFeedPrices(fnName string, feeds []api.PriceFeed) ([]float64, error) {
+	prices := []float64{}
+	for i, f := range feeds {
+		innerPrice, e := f.GetPrice()
+		if e != nil {
+			return nil, fmt.Errorf("error fetching price from feed (index=%d) in '%s' function feed: %s", i, fnName, e)
+		}
+
+		if innerPrice <= 0.0 {
+			return nil, fmt.Errorf("inner price of feed at index %d was <= 0.0 (%.10f)", i, innerPrice)
+		}
+		prices = append(prices, innerPrice)
+	}
+	return prices, nil
+}
+
+// rejectOutliers returns the prices that are within maxDeviationPct percent of the median price
+func rejectOutliers(prices []float64, maxDeviationPct float64) []float64 {
+	med := computeMedian(prices)
+	remaining := []float64{}
+	for _, p := range prices {
+		if math.Abs(p-med)/med*100 <= maxDeviationPct {
+			remaining = append(remaining, p)
+		}
+	}
+	return remaining
+}
+
+func computeMax(prices []float64) float64 {
+	max := prices[0]
+	for _, p := range prices[1:] {
+		max = math.Max(max, p)
+	}
+	return max
+}
+
+func computeMin(prices []float64) float64 {
+	min := prices[0]
+	for _, p := range prices[1:] {
+		min = math.Min(min, p)
+	}
+	return min
+}
+
+func computeMean(prices []float64) float64 {
+	sum := 0.0
+	for _, p := range prices {
+		sum += p
+	}
+	return sum / float64(len(prices))
+}
+
+func computeMedian(prices []float64) float64 {
+	sorted := append([]float64{}, prices...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func computeProduct(prices []float64) float64 {
+	product := 1.0
+	for _, p := range prices {
+		product *= p
+	}
+	return product
+}
+
+// computeWeightedMean returns the mean of the prices weighted by the weights, the weights do not need to add up to 1
+func computeWeightedMean(prices []float64, weights []float64) float64 {
+	sum := 0.0
+	totalWeight := 0.0
+	for i, p := range prices {
+		sum += p * weights[i]
+		totalWeight += weights[i]
+	}
+	return sum / totalWeight
+}
@@ -0,0 +1,170 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/api"
+)
+
+func TestPriceFeedFunctions(t *testing.T) {
+	testCases := []struct {
+		url       string
+		wantPrice float64
+	}{
+		{url: "max(fixed/1.0,fixed/3.0,fixed/2.0)", wantPrice: 3.0},
+		{url: "min(fixed/2.0,fixed/1.0,fixed/3.0)", wantPrice: 1.0},
+		{url: "mean(fixed/1.0,fixed/2.0,fixed/6.0)", wantPrice: 3.0},
+		{url: "median(fixed/1.0,fixed/2.0,fixed/6.0)", wantPrice: 2.0},
+		{url: "median(fixed/1.0,fixed/2.0,fixed/6.0,fixed/4.0)", wantPrice: 3.0},
+		{url: "weighted:3:1(fixed/1.0,fixed/5.0)", wantPrice: 2.0},
+		{url: "weighted:0.5:0.25:0.25(fixed/1.0,fixed/2.0,fixed/4.0)", wantPrice: 2.0},
+		{url: "product(fixed/2.0,fixed/0.5,fixed/3.0)", wantPrice: 3.0},
+		{url: "ratio(fixed/3.0,fixed/2.0)", wantPrice: 1.5},
+		{url: "invert(fixed/4.0)", wantPrice: 0.25},
+		{url: "outlier:mean:5(fixed/1.0,fixed/1.02,fixed/1.5)", wantPrice: 1.01},
+		{url: "outlier:max:5(fixed/1.0,fixed/1.02,fixed/0.5,fixed/1.04)", wantPrice: 1.04},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.url, func(t *testing.T) {
+			pf, e := makeFunctionPriceFeed(k.url)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			price, e := pf.GetPrice()
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 0.0000001)
+		})
+	}
+}
+
+func TestPriceFeedFunctionsInvalid(t *testing.T) {
+	testCases := []string{
+		"median(fixed/1.0)",
+		"mean:5(fixed/1.0,fixed/2.0)",
+		"ratio(fixed/1.0,fixed/2.0,fixed/3.0)",
+		"weighted:0.5(fixed/1.0,fixed/2.0)",
+		"weighted:0.5:-0.5(fixed/1.0,fixed/2.0)",
+		"outlier:median(fixed/1.0,fixed/2.0,fixed/3.0)",
+		"outlier:sum:5(fixed/1.0,fixed/2.0,fixed/3.0)",
+		"outlier:median:5(fixed/1.0,fixed/2.0)",
+		"outlier:median:5:4(fixed/1.0,fixed/2.0,fixed/3.0)",
+	}
+
+	for _, url := range testCases {
+		t.Run(url, func(t *testing.T) {
+			_, e := makeFunctionPriceFeed(url)
+			assert.Error(t, e)
+		})
+	}
+}
+
+func TestOutlierTooFewFeedsRemaining(t *testing.T) {
+	feeds := []*fixedFeed{}
+	for _, p := range []string{"1.0", "1.01", "1.02"} {
+		f, e := newFixedFeed(p)
+		if !assert.NoError(t, e) {
+			return
+		}
+		feeds = append(feeds, f)
+	}
+
+	pf, e := outlier([]string{"median", "5", "2"}, []api.PriceFeed{feeds[0], feeds[1], feeds[2]})
+	if !assert.NoError(t, e) {
+		return
+	}
+	price, e := pf.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.InDelta(t, 1.01, price, 0.0000001)
+
+	// only the feed at the median remains when the other two feeds diverge
+	feeds[0].price = 0.5
+	feeds[2].price = 2.0
+	_, e = pf.GetPrice()
+	assert.Error(t, e)
+}
+
+func TestFallback(t *testing.T) {
+	primaryErr := fmt.Errorf("primary is down")
+	primary := makeFunctionFeed(func() (float64, error) {
+		if primaryErr != nil {
+			return 0.0, primaryErr
+		}
+		return 1.0, nil
+	})
+	secondary, e := newFixedFeed("2.0")
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	pf, e := fallback([]string{}, []api.PriceFeed{primary, secondary})
+	if !assert.NoError(t, e) {
+		return
+	}
+	price, e := pf.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 2.0, price)
+
+	// the price is not usable when all sources fail
+	secondary.price = 0.0
+	_, e = pf.GetPrice()
+	assert.Error(t, e)
+
+	// the primary is used as soon as it recovers
+	primaryErr = nil
+	price, e = pf.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 1.0, price)
+}
+
+func TestFallbackStatsPerFeed(t *testing.T) {
+	pf1 := makeFallbackFeed([]api.PriceFeed{makeFakePriceFeed(0.0), makeFakePriceFeed(1.0)})
+	pf2 := makeFallbackFeed([]api.PriceFeed{makeFakePriceFeed(1.0), makeFakePriceFeed(2.0)})
+
+	takePriceFeedStats()
+	_, e := pf1.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+	_, e = pf2.GetPrice()
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	stats := takePriceFeedStats()
+	assert.Equal(t, map[string]uint64{
+		fmt.Sprintf("fallback_%d_source_0_error", pf1.id): 1,
+		fmt.Sprintf("fallback_%d_source_1_used", pf1.id):  1,
+		fmt.Sprintf("fallback_%d_source_0_used", pf2.id):  1,
+	}, stats)
+}
+
+func TestComputeWeightedMean(t *testing.T) {
+	testCases := []struct {
+		prices    []float64
+		weights   []float64
+		wantPrice float64
+	}{
+		{prices: []float64{1.0, 5.0}, weights: []float64{0.75, 0.25}, wantPrice: 2.0},
+		{prices: []float64{1.0, 5.0}, weights: []float64{3, 1}, wantPrice: 2.0},
+		{prices: []float64{2.0, 4.0}, weights: []float64{1, 1}, wantPrice: 3.0},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%v_%v", k.prices, k.weights), func(t *testing.T) {
+			assert.InDelta(t, k.wantPrice, computeWeightedMean(k.prices, k.weights), 0.0000001)
+		})
+	}
+}
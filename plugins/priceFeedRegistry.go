@@ -0,0 +1,210 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// cachedPriceFeed wraps a price feed that is shared by everyone who makes a feed with the same type and URL, it caches successful
+// results for the TTL of the registry and tracks the hits, misses, and latency of the feed
+type cachedPriceFeed struct {
+	name     string
+	feedType string
+	feed     api.PriceFeed
+	registry *priceFeedRegistry
+
+	// mutex is held while fetching so concurrent callers wait for the result of a single fetch instead of fetching again
+	mutex       *sync.Mutex
+	price       float64
+	fetchedTime time.Time
+	hasPrice    bool
+	stats       PriceFeedCacheStats
+}
+
+// ensure that it implements PriceFeed
+var _ api.PriceFeed = &cachedPriceFeed{}
+
+// PriceFeedCacheStats are the counts and latency of a shared price feed since the stats were last taken
+type PriceFeedCacheStats struct {
+	Feed         string
+	Hits         uint64
+	Misses       uint64
+	Errors       uint64
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+}
+
+// AvgLatency is the average latency of the misses
+func (s PriceFeedCacheStats) AvgLatency() time.Duration {
+	if s.Misses == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Misses)
+}
+
+// String is the Stringer method
+func (s PriceFeedCacheStats) String() string {
+	return fmt.Sprintf("%s: hits=%d, misses=%d, errors=%d, avgLatency=%s, maxLatency=%s", s.Feed, s.Hits, s.Misses, s.Errors, s.AvgLatency(), s.MaxLatency)
+}
+
+// GetPrice impl
+func (f *cachedPriceFeed) GetPrice() (float64, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	ttl := f.registry.getTTL()
+	now := f.registry.clock.Now()
+	if f.hasPrice && ttl > 0 && now.Sub(f.fetchedTime) < ttl {
+		f.stats.Hits++
+		recordPriceFeedEvent("price_feed_cache_hit")
+		return f.price, nil
+	}
+
+	price, e := f.feed.GetPrice()
+	latency := f.registry.clock.Now().Sub(now)
+	f.stats.Misses++
+	f.stats.TotalLatency += latency
+	if latency > f.stats.MaxLatency {
+		f.stats.MaxLatency = latency
+	}
+	recordPriceFeedEvent("price_feed_cache_miss")
+	recordPriceFeedEvent(fmt.Sprintf("price_feed_cache_miss_%s", f.feedType))
+	if e != nil {
+		// errors are not cached so the next call tries to fetch the price again
+		f.stats.Errors++
+		return 0, e
+	}
+
+	f.price = price
+	f.fetchedTime = now
+	f.hasPrice = true
+	return price, nil
+}
+
+// takeStats returns the stats since the last call and resets them
+func (f *cachedPriceFeed) takeStats() PriceFeedCacheStats {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	stats := f.stats
+	f.stats = PriceFeedCacheStats{Feed: f.name}
+	return stats
+}
+
+// priceFeedRegistry deduplicates price feeds by their type and URL so the strategies, filters, and dollar value feeds of a bot share
+// a single instance of each feed, and share the fetched price for the TTL
+type priceFeedRegistry struct {
+	mutex *sync.Mutex
+	ttl   time.Duration
+	clock api.Clock
+	feeds map[string]*cachedPriceFeed
+}
+
+func makePriceFeedRegistry(clock api.Clock) *priceFeedRegistry {
+	return &priceFeedRegistry{
+		mutex: &sync.Mutex{},
+		ttl:   0,
+		clock: clock,
+		feeds: map[string]*cachedPriceFeed{},
+	}
+}
+
+// priceFeedRegistryVar is the process-wide registry used by MakePriceFeed
+var priceFeedRegistryVar = makePriceFeedRegistry(MakeRealClock())
+
+// SetPriceFeedCacheTTL sets how long the price fetched from a feed is shared before it is fetched again, a TTL of 0 disables caching
+// of prices but feeds with the same type and URL still share a single instance
+func SetPriceFeedCacheTTL(ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("the price feed cache TTL cannot be negative but was %s", ttl)
+	}
+
+	priceFeedRegistryVar.mutex.Lock()
+	defer priceFeedRegistryVar.mutex.Unlock()
+
+	priceFeedRegistryVar.ttl = ttl
+	return nil
+}
+
+// TakePriceFeedCacheStats returns the stats of every registered price feed since the last call, sorted by the feed, and resets them
+func TakePriceFeedCacheStats() []PriceFeedCacheStats {
+	return priceFeedRegistryVar.takeStats()
+}
+
+// LogPriceFeedCacheStats logs the stats of the registered price feeds that were used since the last call and resets them
+func LogPriceFeedCacheStats() {
+	for _, stats := range TakePriceFeedCacheStats() {
+		if stats.Hits == 0 && stats.Misses == 0 {
+			continue
+		}
+		log.Printf("price feed cache stats - %s\n", stats)
+	}
+}
+
+func (r *priceFeedRegistry) getTTL() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.ttl
+}
+
+// getOrMake returns the shared feed for the type and URL, making it with makeFn if it does not exist yet. Errors from makeFn are
+// returned and not registered
+func (r *priceFeedRegistry) getOrMake(feedType string, url string, makeFn func() (api.PriceFeed, error)) (api.PriceFeed, error) {
+	key := fmt.Sprintf("%s/%s", feedType, url)
+
+	r.mutex.Lock()
+	cached, ok := r.feeds[key]
+	r.mutex.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	// make the feed without holding the lock because function feeds make their inner feeds through the registry
+	feed, e := makeFn()
+	if e != nil {
+		return nil, e
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if cached, ok := r.feeds[key]; ok {
+		// another caller registered the same feed while we were making it
+		return cached, nil
+	}
+	// the name is logged with the stats so it cannot contain the header values of json feeds
+	name := fmt.Sprintf("%s/%s", feedType, redactJSONFeedHeaders(url))
+	cached = &cachedPriceFeed{
+		name:     name,
+		feedType: feedType,
+		feed:     feed,
+		registry: r,
+		mutex:    &sync.Mutex{},
+		stats:    PriceFeedCacheStats{Feed: name},
+	}
+	r.feeds[key] = cached
+	return cached, nil
+}
+
+func (r *priceFeedRegistry) takeStats() []PriceFeedCacheStats {
+	r.mutex.Lock()
+	feeds := []*cachedPriceFeed{}
+	for _, f := range r.feeds {
+		feeds = append(feeds, f)
+	}
+	r.mutex.Unlock()
+
+	statsList := []PriceFeedCacheStats{}
+	for _, f := range feeds {
+		statsList = append(statsList, f.takeStats())
+	}
+	sort.Slice(statsList, func(i, j int) bool {
+		return statsList[i].Feed < statsList[j].Feed
+	})
+	return statsList
+}
@@ -0,0 +1,181 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePriceFeed returns the scripted prices in order and keeps returning the last one once it runs out, a scripted price <= 0 returns an
+// error and setting err makes every call fail. It is shared by the tests of this package that need a price source they can control.
+type fakePriceFeed struct {
+	prices []float64
+	err    error
+	calls  int
+
+	// uninitialized
+	next int
+}
+
+func makeFakePriceFeed(prices ...float64) *fakePriceFeed {
+	return &fakePriceFeed{prices: prices}
+}
+
+// setPrice replaces the scripted prices with a single price that is returned on every call
+func (f *fakePriceFeed) setPrice(price float64) {
+	f.prices = []float64{price}
+	f.next = 0
+}
+
+func (f *fakePriceFeed) GetPrice() (float64, error) {
+	f.calls++
+	if f.err != nil {
+		return 0, f.err
+	}
+	if len(f.prices) == 0 {
+		return 0, fmt.Errorf("no prices were scripted")
+	}
+
+	price := f.prices[f.next]
+	if f.next < len(f.prices)-1 {
+		f.next++
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("feed failed")
+	}
+	return price, nil
+}
+
+func TestPriceFeedRegistry_Dedup(t *testing.T) {
+	r := makePriceFeedRegistry(MakeVirtualClock(time.Unix(0, 0)))
+	numMakes := 0
+	makeFn := func() (api.PriceFeed, error) {
+		numMakes++
+		return makeFakePriceFeed(1.0), nil
+	}
+
+	feedA, e := r.getOrMake("fixed", "1.0", makeFn)
+	if !assert.NoError(t, e) {
+		return
+	}
+	feedB, e := r.getOrMake("fixed", "1.0", makeFn)
+	if !assert.NoError(t, e) {
+		return
+	}
+	feedC, e := r.getOrMake("fixed", "2.0", makeFn)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	assert.Equal(t, 2, numMakes)
+	assert.True(t, feedA == feedB)
+	assert.False(t, feedA == feedC)
+
+	_, e = r.getOrMake("fixed", "3.0", func() (api.PriceFeed, error) {
+		return nil, fmt.Errorf("make error")
+	})
+	assert.Error(t, e)
+	assert.Equal(t, 2, len(r.feeds))
+}
+
+func TestPriceFeedRegistry_TTL(t *testing.T) {
+	clock := MakeVirtualClock(time.Unix(0, 0))
+	r := makePriceFeedRegistry(clock)
+	r.ttl = 10 * time.Second
+	inner := makeFakePriceFeed(1.0)
+	feed, e := r.getOrMake("fixed", "1.0", func() (api.PriceFeed, error) {
+		return inner, nil
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// first call is a miss, second call within the ttl is a hit
+	price, e := feed.GetPrice()
+	assert.NoError(t, e)
+	assert.Equal(t, 1.0, price)
+	inner.setPrice(2.0)
+	clock.Advance(5 * time.Second)
+	price, e = feed.GetPrice()
+	assert.NoError(t, e)
+	assert.Equal(t, 1.0, price)
+	assert.Equal(t, 1, inner.calls)
+
+	// expired
+	clock.Advance(5 * time.Second)
+	price, e = feed.GetPrice()
+	assert.NoError(t, e)
+	assert.Equal(t, 2.0, price)
+	assert.Equal(t, 2, inner.calls)
+
+	// errors are not cached
+	clock.Advance(10 * time.Second)
+	inner.err = fmt.Errorf("fetch error")
+	_, e = feed.GetPrice()
+	assert.Error(t, e)
+	inner.err = nil
+	inner.setPrice(3.0)
+	price, e = feed.GetPrice()
+	assert.NoError(t, e)
+	assert.Equal(t, 3.0, price)
+	assert.Equal(t, 4, inner.calls)
+
+	statsList := r.takeStats()
+	if !assert.Equal(t, 1, len(statsList)) {
+		return
+	}
+	assert.Equal(t, "fixed/1.0", statsList[0].Feed)
+	assert.Equal(t, uint64(1), statsList[0].Hits)
+	assert.Equal(t, uint64(4), statsList[0].Misses)
+	assert.Equal(t, uint64(1), statsList[0].Errors)
+
+	// stats are reset after they are taken
+	statsList = r.takeStats()
+	assert.Equal(t, uint64(0), statsList[0].Hits)
+	assert.Equal(t, uint64(0), statsList[0].Misses)
+}
+
+func TestPriceFeedRegistry_NoTTL(t *testing.T) {
+	r := makePriceFeedRegistry(MakeVirtualClock(time.Unix(0, 0)))
+	inner := makeFakePriceFeed(1.0)
+	feed, e := r.getOrMake("fixed", "1.0", func() (api.PriceFeed, error) {
+		return inner, nil
+	})
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	for i := 0; i < 3; i++ {
+		_, e = feed.GetPrice()
+		assert.NoError(t, e)
+	}
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestPriceFeedRegistry_RedactsJSONHeaders(t *testing.T) {
+	r := makePriceFeedRegistry(MakeVirtualClock(time.Unix(0, 0)))
+	makeFn := func() (api.PriceFeed, error) {
+		return makeFakePriceFeed(1.0), nil
+	}
+	feedA, e := r.getOrMake("json", "https://example.com/ticker|$.price|X-API-KEY=STATIC:abc123", makeFn)
+	if !assert.NoError(t, e) {
+		return
+	}
+	// feeds with different header values are not shared even though they are logged with the same name
+	feedB, e := r.getOrMake("json", "https://example.com/ticker|$.price|X-API-KEY=STATIC:def456", makeFn)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.True(t, feedA != feedB)
+
+	statsList := r.takeStats()
+	if !assert.Equal(t, 2, len(statsList)) {
+		return
+	}
+	for _, stats := range statsList {
+		assert.Equal(t, "json/https://example.com/ticker|$.price|X-API-KEY=<redacted>", stats.Feed)
+	}
+}
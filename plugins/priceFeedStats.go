@@ -0,0 +1,37 @@
+package plugins
+
+import (
+	"sync"
+)
+
+// priceFeedStats counts events from the price feeds (such as which source of a fallback feed was used) between update events
+type priceFeedStats struct {
+	mutex  *sync.Mutex
+	counts map[string]uint64
+}
+
+var priceFeedStatsVar = &priceFeedStats{
+	mutex:  &sync.Mutex{},
+	counts: map[string]uint64{},
+}
+
+// recordPriceFeedEvent increments the count of the event, it is safe to call from any goroutine
+func recordPriceFeedEvent(event string) {
+	priceFeedStatsVar.mutex.Lock()
+	defer priceFeedStatsVar.mutex.Unlock()
+
+	priceFeedStatsVar.counts[event]++
+}
+
+// takePriceFeedStats returns the counts recorded since the last call and resets them, returns nil if there are no counts
+func takePriceFeedStats() map[string]uint64 {
+	priceFeedStatsVar.mutex.Lock()
+	defer priceFeedStatsVar.mutex.Unlock()
+
+	if len(priceFeedStatsVar.counts) == 0 {
+		return nil
+	}
+	counts := priceFeedStatsVar.counts
+	priceFeedStatsVar.counts = map[string]uint64{}
+	return counts
+}
@@ -164,3 +164,25 @@ func TestMakePriceFeed_CryptoFeed_Success(t *testing.T) {
 	assert.Equal(t, expected, price)
 	assert.NoError(t, err)
 }
+
+// uses mock call
+func TestMakePriceFeed_JSONFeed_Success(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-KEY") != "abc123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte(`{"data": {"tickers": [{"price": "0.25"}, {"price": 0.5}]}}`))
+		require.NoError(t, err)
+	}))
+	defer ts.Close()
+
+	priceFeed, err := MakePriceFeed("json", ts.URL+"|$.data.tickers[-1].price|X-API-KEY=abc123")
+	require.NoError(t, err)
+
+	price, err := priceFeed.GetPrice()
+	assert.Equal(t, 0.5, price)
+	assert.NoError(t, err)
+}
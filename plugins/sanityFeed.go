@@ -0,0 +1,91 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// sanityFeed rejects prices from the inner feed that jump too far from the last accepted price and bridges short outages of the inner feed
+// with the last accepted price. Once the inner feed has been failing for longer than the ttl the price is stale and an error is returned
+// (which a fallback feed can use to move on to the next source) instead of an old price. A price that does not change is never stale by
+// itself, since pegged or stable sources legitimately return the same price for a long time.
+type sanityFeed struct {
+	feed       api.PriceFeed
+	ttl        time.Duration
+	maxJumpPct float64
+	clock      api.Clock
+
+	// initialized runtime vars
+	mutex *sync.Mutex
+
+	// uninitialized
+	lastAcceptedPrice float64
+	lastAcceptedTime  time.Time
+}
+
+var _ api.PriceFeed = &sanityFeed{}
+
+// makeSanityFeed is a factory method
+func makeSanityFeed(feed api.PriceFeed, ttl time.Duration, maxJumpPct float64, clock api.Clock) *sanityFeed {
+	return &sanityFeed{
+		feed:       feed,
+		ttl:        ttl,
+		maxJumpPct: maxJumpPct,
+		clock:      clock,
+		mutex:      &sync.Mutex{},
+	}
+}
+
+// GetPrice impl
+func (f *sanityFeed) GetPrice() (float64, error) {
+	price, e := f.feed.GetPrice()
+	if e == nil && price <= 0.0 {
+		e = fmt.Errorf("inner price of sanity feed was <= 0.0 (%.10f)", price)
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	now := f.clock.Now()
+
+	if e != nil {
+		recordPriceFeedEvent("sanity_error")
+		if f.lastAcceptedTime.IsZero() || now.Sub(f.lastAcceptedTime) > f.ttl {
+			recordPriceFeedEvent("sanity_rejected_stale")
+			return 0.0, fmt.Errorf("error fetching price from inner feed of sanity feed and there is no accepted price within the ttl (%s): %s", f.ttl, e)
+		}
+		log.Printf("sanity feed serving the last accepted price %.10f from %s because of an error fetching the price from the inner feed: %s\n",
+			f.lastAcceptedPrice, f.lastAcceptedTime, e)
+		recordPriceFeedEvent("sanity_served_last_price")
+		return f.lastAcceptedPrice, nil
+	}
+
+	if f.lastAcceptedTime.IsZero() {
+		f.accept(price, now)
+		return price, nil
+	}
+
+	jumpPct := math.Abs(price/f.lastAcceptedPrice-1) * 100
+	if jumpPct > f.maxJumpPct {
+		// a price that stays away from the last accepted price for longer than the ttl is a real move and not a bad tick
+		if now.Sub(f.lastAcceptedTime) <= f.ttl {
+			recordPriceFeedEvent("sanity_rejected_jump")
+			return 0.0, fmt.Errorf("price from inner feed of sanity feed jumped by %.4f%% from %.10f to %.10f which is more than the maxJumpPct (%.4f%%)",
+				jumpPct, f.lastAcceptedPrice, price, f.maxJumpPct)
+		}
+		log.Printf("sanity feed accepting price %.10f that jumped by %.4f%% from %.10f because the last accepted price is older than the ttl (%s)\n",
+			price, jumpPct, f.lastAcceptedPrice, f.ttl)
+	}
+
+	f.accept(price, now)
+	return price, nil
+}
+
+func (f *sanityFeed) accept(price float64, now time.Time) {
+	f.lastAcceptedPrice = price
+	f.lastAcceptedTime = now
+}
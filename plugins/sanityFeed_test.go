@@ -0,0 +1,56 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanityFeed(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := MakeVirtualClock(start)
+	inner := makeFakePriceFeed(1.0)
+	f := makeSanityFeed(inner, time.Minute, 5, clock)
+
+	testCases := []struct {
+		name       string
+		elapsed    time.Duration
+		price      float64
+		innerError bool
+		wantPrice  float64
+		wantError  bool
+	}{
+		{name: "first price is accepted", elapsed: 0, price: 1.0, wantPrice: 1.0},
+		{name: "small move is accepted", elapsed: 10 * time.Second, price: 1.02, wantPrice: 1.02},
+		{name: "last accepted price is served when the inner feed fails", elapsed: 20 * time.Second, innerError: true, wantPrice: 1.02},
+		{name: "jump is rejected", elapsed: 30 * time.Second, price: 1.5, wantError: true},
+		{name: "unchanged price is not stale", elapsed: 5 * time.Minute, price: 1.02, wantPrice: 1.02},
+		{name: "last accepted price is served again", elapsed: 5*time.Minute + 10*time.Second, innerError: true, wantPrice: 1.02},
+		{name: "failing inner feed is stale after the ttl", elapsed: 7 * time.Minute, innerError: true, wantError: true},
+		{name: "jump is accepted once last accepted price is older than ttl", elapsed: 8 * time.Minute, price: 1.5, wantPrice: 1.5},
+		{name: "price is accepted after the jump", elapsed: 8*time.Minute + time.Second, price: 1.51, wantPrice: 1.51},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			clock.Set(start.Add(k.elapsed))
+			inner.setPrice(k.price)
+			inner.err = nil
+			if k.innerError {
+				inner.err = fmt.Errorf("feed failed")
+			}
+
+			price, e := f.GetPrice()
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantPrice, price)
+		})
+	}
+}
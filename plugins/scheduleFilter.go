@@ -0,0 +1,222 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
+)
+
+// scheduleDayKeys are the keys used for the days of the week, the same as the keys of DayOfWeekFilterConfig.
+// The index of each key is the time.Weekday of the day, so the keys begin with Sunday
+var scheduleDayKeys = [7]string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"}
+
+// scheduleBlackoutDateFormat is the format of the blackout dates
+const scheduleBlackoutDateFormat = "2006-01-02"
+
+// scheduleTimeRange is a range of time within a day, where the start is included and the end is excluded
+type scheduleTimeRange struct {
+	start time.Duration // offset from midnight
+	end   time.Duration // offset from midnight
+}
+
+// String is the Stringer method
+func (r scheduleTimeRange) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", int(r.start.Hours()), int(r.start.Minutes())%60, int(r.end.Hours()), int(r.end.Minutes())%60)
+}
+
+func (r scheduleTimeRange) contains(offset time.Duration) bool {
+	return offset >= r.start && offset < r.end
+}
+
+// ScheduleFilterConfig lists the time ranges when we are allowed to have offers for each day of the week in the time zone of the Location,
+// and the dates when we are not allowed to have any offers
+type ScheduleFilterConfig struct {
+	Location *time.Location
+	// indexed by time.Weekday, a day without any time ranges is not allowed at all
+	allowedRanges [7][]scheduleTimeRange
+	blackoutDates map[string]bool // formatted as scheduleBlackoutDateFormat
+}
+
+// Validate ensures validity
+func (c *ScheduleFilterConfig) Validate() error {
+	if c.Location == nil {
+		return fmt.Errorf("invalid location: location cannot be nil")
+	}
+
+	hasRanges := false
+	for _, ranges := range c.allowedRanges {
+		hasRanges = hasRanges || len(ranges) > 0
+	}
+	if !hasRanges {
+		return fmt.Errorf("invalid schedule: needs at least one allowed time range")
+	}
+
+	return nil
+}
+
+// String is the stringer method
+func (c *ScheduleFilterConfig) String() string {
+	days := []string{}
+	for i, ranges := range c.allowedRanges {
+		if len(ranges) > 0 {
+			days = append(days, fmt.Sprintf("%s=%v", scheduleDayKeys[i], ranges))
+		}
+	}
+	blackoutDates := []string{}
+	for d := range c.blackoutDates {
+		blackoutDates = append(blackoutDates, d)
+	}
+	sort.Strings(blackoutDates)
+	return fmt.Sprintf("ScheduleFilterConfig[Location=%s, allowedRanges=%v, blackoutDates=%v]", c.Location, days, blackoutDates)
+}
+
+// isAllowed returns whether we are allowed to have offers at the time along with the reason when we are not allowed
+func (c *ScheduleFilterConfig) isAllowed(t time.Time) (bool, string) {
+	local := t.In(c.Location)
+	date := local.Format(scheduleBlackoutDateFormat)
+	if c.blackoutDates[date] {
+		return false, fmt.Sprintf("%s is a blackout date", date)
+	}
+
+	// use the wall clock time so the time ranges are not shifted on days when daylight saving time starts or ends
+	offset := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+	for _, r := range c.allowedRanges[local.Weekday()] {
+		if r.contains(offset) {
+			return true, ""
+		}
+	}
+	return false, fmt.Sprintf("%s is outside the allowed time ranges for %s", local.Format(time.RFC3339), local.Weekday())
+}
+
+type scheduleFilter struct {
+	name        string
+	configValue string
+	baseAsset   hProtocol.Asset
+	quoteAsset  hProtocol.Asset
+	config      *ScheduleFilterConfig
+	clock       api.Clock
+}
+
+// makeFilterSchedule makes a submit filter that deletes all offers outside the allowed time ranges
+func makeFilterSchedule(
+	configValue string,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	clock api.Clock,
+	config *ScheduleFilterConfig,
+) (SubmitFilter, error) {
+	e := config.Validate()
+	if e != nil {
+		return nil, fmt.Errorf("invalid config: %s", e)
+	}
+
+	return &scheduleFilter{
+		name:        "scheduleFilter",
+		configValue: configValue,
+		baseAsset:   baseAsset,
+		quoteAsset:  quoteAsset,
+		config:      config,
+		clock:       clock,
+	}, nil
+}
+
+var _ SubmitFilter = &scheduleFilter{}
+
+func (f *scheduleFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	allowed, reason := f.config.isAllowed(f.clock.Now())
+	if allowed {
+		return ops, nil
+	}
+
+	log.Printf("scheduleFilter: deleting all offers because %s (%s)\n", reason, f.config)
+	deleteFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return nil, nil
+	}
+	ops, e := filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, deleteFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+// String is the Stringer method
+func (f *scheduleFilter) String() string {
+	return f.configValue
+}
+
+// parseScheduleDays parses a single day key such as "Mo" or a range of days such as "Mo-Fr", which can wrap around the end of the week
+func parseScheduleDays(daysString string) ([]time.Weekday, error) {
+	dayParts := strings.Split(daysString, "-")
+	if len(dayParts) > 2 {
+		return nil, fmt.Errorf("invalid days '%s', needs to be a day or a range of days like 'Mo-Fr'", daysString)
+	}
+
+	indices := []int{}
+	for _, d := range dayParts {
+		idx := -1
+		for i, key := range scheduleDayKeys {
+			if key == d {
+				idx = i
+			}
+		}
+		if idx == -1 {
+			return nil, fmt.Errorf("invalid day '%s', needs to be one of %v", d, scheduleDayKeys)
+		}
+		indices = append(indices, idx)
+	}
+
+	if len(indices) == 1 {
+		return []time.Weekday{time.Weekday(indices[0])}, nil
+	}
+	days := []time.Weekday{}
+	for i := indices[0]; ; i = (i + 1) % 7 {
+		days = append(days, time.Weekday(i))
+		if i == indices[1] {
+			break
+		}
+	}
+	return days, nil
+}
+
+// parseScheduleTimeRanges parses comma-separated time ranges formatted as HH:MM-HH:MM, where the end can be 24:00
+func parseScheduleTimeRanges(rangesString string) ([]scheduleTimeRange, error) {
+	ranges := []scheduleTimeRange{}
+	for _, rangeString := range strings.Split(rangesString, ",") {
+		rangeParts := strings.Split(rangeString, "-")
+		if len(rangeParts) != 2 {
+			return nil, fmt.Errorf("invalid time range '%s', needs to be formatted as HH:MM-HH:MM", rangeString)
+		}
+
+		start, e := parseScheduleTimeOfDay(rangeParts[0])
+		if e != nil {
+			return nil, fmt.Errorf("invalid start of time range '%s': %s", rangeString, e)
+		}
+		end, e := parseScheduleTimeOfDay(rangeParts[1])
+		if e != nil {
+			return nil, fmt.Errorf("invalid end of time range '%s': %s", rangeString, e)
+		}
+		if end <= start {
+			return nil, fmt.Errorf("invalid time range '%s', the end needs to be after the start (split ranges that cross midnight across both days)", rangeString)
+		}
+		ranges = append(ranges, scheduleTimeRange{start: start, end: end})
+	}
+	return ranges, nil
+}
+
+func parseScheduleTimeOfDay(timeString string) (time.Duration, error) {
+	if timeString == "24:00" {
+		return 24 * time.Hour, nil
+	}
+
+	t, e := time.Parse("15:04", timeString)
+	if e != nil {
+		return 0, fmt.Errorf("could not parse time of day '%s' as HH:MM: %s", timeString, e)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
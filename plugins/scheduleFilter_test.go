@@ -0,0 +1,129 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleFilterConfigIsAllowed(t *testing.T) {
+	config, e := makeScheduleFilterConfig("schedule/tz=America/New_York/Mo-Fr=09:00-12:00,13:00-17:00/Sa=10:00-24:00/blackout=2021-12-24,2021-12-31")
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	testCases := []struct {
+		name        string
+		time        string
+		wantAllowed bool
+	}{
+		// 2021-12-20 is a Monday
+		{name: "monday morning", time: "2021-12-20T09:00:00-05:00", wantAllowed: true},
+		{name: "monday before opening", time: "2021-12-20T08:59:59-05:00", wantAllowed: false},
+		{name: "monday lunch break", time: "2021-12-20T12:30:00-05:00", wantAllowed: false},
+		{name: "monday afternoon", time: "2021-12-20T16:59:00-05:00", wantAllowed: true},
+		{name: "monday closing", time: "2021-12-20T17:00:00-05:00", wantAllowed: false},
+		{name: "monday afternoon in UTC", time: "2021-12-20T21:00:00Z", wantAllowed: true},
+		{name: "monday night in UTC", time: "2021-12-21T01:00:00Z", wantAllowed: false},
+		{name: "friday blackout date", time: "2021-12-24T10:00:00-05:00", wantAllowed: false},
+		{name: "saturday before midnight", time: "2021-12-25T23:59:59-05:00", wantAllowed: true},
+		{name: "sunday", time: "2021-12-26T10:00:00-05:00", wantAllowed: false},
+		// daylight saving time starts on 2021-03-14 so the offset changes to -04:00
+		{name: "monday morning after daylight saving time starts", time: "2021-03-15T09:30:00-04:00", wantAllowed: true},
+		{name: "monday before opening after daylight saving time starts", time: "2021-03-15T08:30:00-04:00", wantAllowed: false},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			now, e := time.Parse(time.RFC3339, k.time)
+			if !assert.NoError(t, e) {
+				return
+			}
+			allowed, reason := config.isAllowed(now)
+			assert.Equal(t, k.wantAllowed, allowed, reason)
+		})
+	}
+}
+
+func TestScheduleFilterApply(t *testing.T) {
+	config, e := makeScheduleFilterConfig("schedule/Mo-Fr=00:00-24:00")
+	if !assert.NoError(t, e) {
+		return
+	}
+	// 1970-01-01 is a Thursday
+	clock := MakeVirtualClock(time.Unix(0, 0).UTC())
+	f, e := makeFilterSchedule("schedule/Mo-Fr=00:00-24:00", utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), clock, config)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	ops := []txnbuild.Operation{
+		makeSellOpAmtPrice(10.0, 1.2),
+		makeBuyOpAmtPrice(10.0, 0.9),
+	}
+	filtered, e := f.Apply(ops, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 2, len(filtered))
+
+	// saturday
+	clock.Advance(2 * 24 * time.Hour)
+	filtered, e = f.Apply(ops, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0, len(filtered))
+}
+
+func TestMakeScheduleFilterConfig(t *testing.T) {
+	testCases := []struct {
+		configInput string
+		wantError   bool
+	}{
+		{configInput: "schedule/Mo=09:00-17:00", wantError: false},
+		{configInput: "schedule/tz=Europe/London/Fr-Mo=09:00-17:00", wantError: false},
+		{configInput: "schedule/tz=America/Argentina/Buenos_Aires/Mo=09:00-17:00/blackout=2022-01-01", wantError: false},
+		{configInput: "schedule", wantError: true},
+		{configInput: "schedule/blackout=2022-01-01", wantError: true},
+		{configInput: "schedule/tz=Mars/Olympus_Mons/Mo=09:00-17:00", wantError: true},
+		{configInput: "schedule/Monday=09:00-17:00", wantError: true},
+		{configInput: "schedule/Mo-We-Fr=09:00-17:00", wantError: true},
+		{configInput: "schedule/Mo=17:00-09:00", wantError: true},
+		{configInput: "schedule/Mo=09:00-25:00", wantError: true},
+		{configInput: "schedule/Mo=09:00", wantError: true},
+		{configInput: "schedule/Mo=09:00-17:00/blackout=01-01-2022", wantError: true},
+		{configInput: "schedule/America/New_York/Mo=09:00-17:00", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.configInput, func(t *testing.T) {
+			_, e := makeScheduleFilterConfig(k.configInput)
+			if k.wantError {
+				assert.Error(t, e)
+			} else {
+				assert.NoError(t, e)
+			}
+		})
+	}
+}
+
+func TestParseScheduleDays(t *testing.T) {
+	days, e := parseScheduleDays("Mo-Fr")
+	if assert.NoError(t, e) {
+		assert.Equal(t, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, days)
+	}
+
+	days, e = parseScheduleDays("Fr-Mo")
+	if assert.NoError(t, e) {
+		assert.Equal(t, []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}, days)
+	}
+
+	days, e = parseScheduleDays("Su")
+	if assert.NoError(t, e) {
+		assert.Equal(t, []time.Weekday{time.Sunday}, days)
+	}
+}
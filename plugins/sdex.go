@@ -54,10 +54,17 @@ type SDEX struct {
 	tradingOnSdex                 bool
 
 	// uninitialized
-	seqNum             uint64
-	reloadSeqNum       bool
+	seq                *sequenceNumber
 	ieif               *IEIF
 	ocOverridesHandler *OrderConstraintsOverridesHandler
+	streamCache        *SdexStreamCache
+}
+
+// sequenceNumber tracks the sequence number of the source account, it is shared by all SDEX instances that submit from the same account
+type sequenceNumber struct {
+	mutex  *sync.Mutex
+	value  uint64
+	reload bool
 }
 
 // enforce SDEX implements api.Constrainable
@@ -123,11 +130,30 @@ func MakeSDEX(
 		sdex.SourceSeed = sdex.TradingSeed
 		log.Println("No Source Account Set")
 	}
-	sdex.reloadSeqNum = true
+	sdex.seq = &sequenceNumber{
+		mutex:  &sync.Mutex{},
+		reload: true,
+	}
 
 	return sdex
 }
 
+// ForPair returns an SDEX instance bound to the passed in pair that submits from the same account as this instance, sharing the
+// sequence number, IEIF and order constraint overrides so multiple markets can be traded from a single account
+func (sdex *SDEX) ForPair(pair *model.TradingPair, assetMap map[model.Asset]hProtocol.Asset) *SDEX {
+	pairSdex := *sdex
+	pairSdex.pair = pair
+	pairSdex.assetMap = assetMap
+	// the stream cache is bound to the pair so it cannot be shared
+	pairSdex.streamCache = nil
+	return &pairSdex
+}
+
+// SetStreamCache serves the orderbook and trade history of this instance from the passed in cache
+func (sdex *SDEX) SetStreamCache(streamCache *SdexStreamCache) {
+	sdex.streamCache = streamCache
+}
+
 // IEIF exoses the ieif var
 func (sdex *SDEX) IEIF() *IEIF {
 	return sdex.ieif
@@ -159,24 +185,36 @@ func (sdex *SDEX) GetAssetConverter() model.AssetConverterInterface {
 	return model.Display
 }
 
-func (sdex *SDEX) incrementSeqNum() {
-	if sdex.reloadSeqNum {
+// incrementSeqNum increments the sequence number and returns the new value
+func (sdex *SDEX) incrementSeqNum() uint64 {
+	sdex.seq.mutex.Lock()
+	defer sdex.seq.mutex.Unlock()
+
+	if sdex.seq.reload {
 		log.Println("reloading sequence number")
 		acctReq := horizonclient.AccountRequest{AccountID: sdex.SourceAccount}
 		accountDetail, err := sdex.API.AccountDetail(acctReq)
 		if err != nil {
 			log.Printf("error loading account detail: %s\n", err)
-			return
+			return sdex.seq.value
 		}
 		seqNum, err := accountDetail.GetSequenceNumber()
 		if err != nil {
 			log.Printf("error getting seq num: %s\n", err)
-			return
+			return sdex.seq.value
 		}
-		sdex.seqNum = uint64(seqNum)
-		sdex.reloadSeqNum = false
+		sdex.seq.value = uint64(seqNum)
+		sdex.seq.reload = false
 	}
-	sdex.seqNum++
+	sdex.seq.value++
+	return sdex.seq.value
+}
+
+// markSeqNumForReload forces the sequence number to be reloaded from the network before the next transaction
+func (sdex *SDEX) markSeqNumForReload() {
+	sdex.seq.mutex.Lock()
+	defer sdex.seq.mutex.Unlock()
+	sdex.seq.reload = true
 }
 
 // GetOrderConstraints impl
@@ -378,14 +416,14 @@ func (sdex *SDEX) submitOps(opsOld []build.TransactionMutator, asyncCallback fun
 		return fmt.Errorf("SubmitOps error when computing op fee: %s", e)
 	}
 
-	sdex.incrementSeqNum()
+	seqNum := sdex.incrementSeqNum()
 	tx, e := txnbuild.NewTransaction(
 		txnbuild.TransactionParams{
 			// sequence number is decremented here because Transaction.Build will increment sequence number
 			// I have not tested with not decrementing here and setting IncrementSequenceNum=false so leaving this way
 			SourceAccount: &txnbuild.SimpleAccount{
 				AccountID: sdex.SourceAccount,
-				Sequence:  int64(sdex.seqNum - 1),
+				Sequence:  int64(seqNum - 1),
 			},
 			BaseFee: int64(opFee),
 			// If IncrementSequenceNum is true, NewTransaction() will call `sourceAccount.IncrementSequenceNumber()`
@@ -461,7 +499,7 @@ func (sdex *SDEX) submit(txeB64 string, asyncCallback func(hash string, e error)
 			}
 			if rcs.TransactionCode == "tx_bad_seq" {
 				log.Println("(async) error: tx_bad_seq, setting flag to reload seq number")
-				sdex.reloadSeqNum = true
+				sdex.markSeqNumForReload()
 			}
 			log.Println("(async) error: result code details: tx code =", rcs.TransactionCode, ", opcodes =", rcs.OperationCodes)
 		} else {
@@ -518,6 +556,14 @@ var _ api.FillTrackable = &SDEX{}
 
 // GetTradeHistory fetches trades for the trading account bound to this instance of SDEX
 func (sdex *SDEX) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	if sdex.streamCache != nil {
+		return sdex.streamCache.GetTradeHistory(pair, maybeCursorStart, maybeCursorEnd)
+	}
+	return sdex.fetchTradeHistory(pair, maybeCursorStart, maybeCursorEnd)
+}
+
+// fetchTradeHistory fetches trades for the trading account from horizon
+func (sdex *SDEX) fetchTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
 	if pair != *sdex.pair {
 		return nil, fmt.Errorf("passed in pair (%s) did not match sdex.pair (%s)", pair.String(), sdex.pair.String())
 	}
@@ -853,12 +899,10 @@ func (sdex *SDEX) tradesPage2TradeHistoryResult(baseAsset hProtocol.Asset, quote
 			continue
 		}
 
-		vol, e := model.NumberFromString(t.BaseAmount, sdexOrderConstraints.VolumePrecision)
+		price, vol, e := tradeRecord2PriceVolume(t)
 		if e != nil {
-			return nil, false, fmt.Errorf("could not convert baseAmount to model.Number: %s", e)
+			return nil, false, e
 		}
-		floatPrice, _ := big.NewRat(t.Price.N, t.Price.D).Float64()
-		price := model.NumberFromFloat(floatPrice, sdexOrderConstraints.PricePrecision)
 
 		trades = append(trades, model.Trade{
 			Order: model.Order{
@@ -889,6 +933,17 @@ func (sdex *SDEX) tradesPage2TradeHistoryResult(baseAsset hProtocol.Asset, quote
 	}, false, nil
 }
 
+// tradeRecord2PriceVolume converts the price and the base amount of a trade record from horizon
+func tradeRecord2PriceVolume(t hProtocol.Trade) (*model.Number, *model.Number, error) {
+	vol, e := model.NumberFromString(t.BaseAmount, sdexOrderConstraints.VolumePrecision)
+	if e != nil {
+		return nil, nil, fmt.Errorf("could not convert baseAmount to model.Number: %s", e)
+	}
+	floatPrice, _ := big.NewRat(t.Price.N, t.Price.D).Float64()
+	price := model.NumberFromFloat(floatPrice, sdexOrderConstraints.PricePrecision)
+	return price, vol, nil
+}
+
 // GetLatestTradeCursor impl.
 func (sdex *SDEX) GetLatestTradeCursor() (interface{}, error) {
 	baseAsset, quoteAsset, e := sdex.Assets()
@@ -956,6 +1011,14 @@ func (sdex *SDEX) checkAssetExists(asset hProtocol.Asset) error {
 
 // GetOrderBook gets the SDEX orderbook
 func (sdex *SDEX) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	if sdex.streamCache != nil {
+		return sdex.streamCache.GetOrderBook(pair, maxCount)
+	}
+	return sdex.fetchOrderBook(pair, maxCount)
+}
+
+// fetchOrderBook fetches the SDEX orderbook from horizon
+func (sdex *SDEX) fetchOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
 	if pair != sdex.pair {
 		return nil, fmt.Errorf("unregistered trading pair (%s) cannot be converted to horizon.Assets, instance's pair: %s", pair.String(), sdex.pair.String())
 	}
@@ -980,6 +1043,11 @@ func (sdex *SDEX) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.
 	}
 
 	ts := model.MakeTimestamp(time.Now().UnixNano() / int64(time.Millisecond))
+	return sdex.transformOrderBookSummary(pair, ob, ts, maxCount)
+}
+
+// transformOrderBookSummary converts the orderbook returned by horizon to a model.OrderBook
+func (sdex *SDEX) transformOrderBookSummary(pair *model.TradingPair, ob hProtocol.OrderBookSummary, ts *model.Timestamp, maxCount int32) (*model.OrderBook, error) {
 	transformedBids, e := sdex.transformHorizonOrders(pair, ob.Bids, model.OrderActionBuy, ts, maxCount)
 	if e != nil {
 		return nil, fmt.Errorf("could not transform bid side of SDEX orderbook: %s", e)
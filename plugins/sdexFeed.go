@@ -17,14 +17,32 @@ type sdexFeed struct {
 	sdex       *SDEX
 	assetBase  *hProtocol.Asset
 	assetQuote *hProtocol.Asset
+	vwap       *vwapModifier // nil when using the mid price
 }
 
 // ensure that it implements PriceFeed
 var _ api.PriceFeed = &sdexFeed{}
 
-// makeSDEXFeed creates a price feed from buysell's url fields
+// makeSDEXFeed creates a price feed from buysell's url fields, formatted as CODE:ISSUER/CODE:ISSUER[/modifier]
+// where the modifier is either "mid" (default) or a vwap modifier
 func makeSDEXFeed(url string) (*sdexFeed, error) {
 	urlParts := strings.Split(url, "/")
+	if len(urlParts) < 2 || len(urlParts) > 3 {
+		return nil, fmt.Errorf("invalid format of sdex type URL, needs either 2 or 3 parts after splitting URL by '/', has %d: %s", len(urlParts), url)
+	}
+
+	var vwap *vwapModifier
+	if len(urlParts) == 3 && urlParts[2] != "mid" {
+		if !isVwapModifier(urlParts[2]) {
+			return nil, fmt.Errorf("unsupported modifier '%s' on sdex type URL", urlParts[2])
+		}
+
+		var e error
+		vwap, e = parseVwapModifier(urlParts[2])
+		if e != nil {
+			return nil, fmt.Errorf("unsupported modifier '%s' on sdex type URL: %s", urlParts[2], e)
+		}
+	}
 
 	baseAsset, e := parseHorizonAsset(urlParts[0])
 	if e != nil {
@@ -44,20 +62,7 @@ func makeSDEXFeed(url string) (*sdexFeed, error) {
 		tradingPair.Quote: *quoteAsset,
 	}
 
-	var api *horizonclient.Client
-	var ieif *IEIF
-	var network string
-	if privateSdexHackVar != nil {
-		api = privateSdexHackVar.API
-		ieif = privateSdexHackVar.Ieif
-		network = privateSdexHackVar.Network
-	} else {
-		// use production network by default
-		api = horizonclient.DefaultPublicNetClient
-		ieif = MakeIEIF(true)
-		network = sdkNetwork.PublicNetworkPassphrase
-	}
-
+	api, ieif, network := sdexFeedHorizonConfig()
 	sdex := MakeSDEX(
 		api,
 		ieif,
@@ -80,9 +85,19 @@ func makeSDEXFeed(url string) (*sdexFeed, error) {
 		sdex:       sdex,
 		assetBase:  baseAsset,
 		assetQuote: quoteAsset,
+		vwap:       vwap,
 	}, nil
 }
 
+// sdexFeedHorizonConfig returns the horizon client, IEIF, and network used by the SDEX price feeds
+func sdexFeedHorizonConfig() (*horizonclient.Client, *IEIF, string) {
+	if privateSdexHackVar != nil {
+		return privateSdexHackVar.API, privateSdexHackVar.Ieif, privateSdexHackVar.Network
+	}
+	// use production network by default
+	return horizonclient.DefaultPublicNetClient, MakeIEIF(true), sdkNetwork.PublicNetworkPassphrase
+}
+
 func parseHorizonAsset(assetString string) (*hProtocol.Asset, error) {
 	parts := strings.Split(assetString, ":")
 	code := parts[0]
@@ -96,8 +111,21 @@ func parseHorizonAsset(assetString string) (*hProtocol.Asset, error) {
 	return asset, e
 }
 
-// GetPrice returns the SDEX mid price for the trading pair
+// GetPrice returns the SDEX mid price for the trading pair, or the vwap price when using a vwap modifier
 func (s *sdexFeed) GetPrice() (float64, error) {
+	if s.vwap != nil {
+		orderBook, e := s.sdex.GetOrderBook(s.sdex.pair, vwapOrderbookDepth)
+		if e != nil {
+			return 0, fmt.Errorf("unable to get sdex orderbook: %s", e)
+		}
+
+		price, e := s.vwap.computeVwap(orderBook)
+		if e != nil {
+			return 0, fmt.Errorf("unable to get sdex vwap price: %s", e)
+		}
+		return price, nil
+	}
+
 	orderBook, e := s.sdex.GetOrderBook(s.sdex.pair, 1)
 	if e != nil {
 		return 0, fmt.Errorf("unable to get sdex price: %s", e)
@@ -0,0 +1,203 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// SdexStreamCache keeps the SDEX orderbook for the pair and the state of the trading account's trades current using horizon's streaming
+// endpoints so we do not need to poll horizon on every update cycle. It falls back to polling horizon whenever a stream is disconnected,
+// and for the orderbook also when the last event on the stream is older than staleAfter.
+type SdexStreamCache struct {
+	sdex             *SDEX
+	reconnectDelay   time.Duration
+	staleAfter       time.Duration
+	clock            api.Clock
+	pollOrderBook    func(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error)
+	pollTradeHistory func(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error)
+
+	// initialized runtime vars
+	mutex *sync.Mutex
+
+	// uninitialized
+	orderbook     *hProtocol.OrderBookSummary // nil when the orderbook stream is disconnected
+	orderbookTime time.Time                   // time of the last event on the orderbook stream
+	isTradesLive  bool                        // true when the trades stream is connected
+	hasNewTrades  bool                        // true when there may be trades that have not been fetched yet
+}
+
+// enforce SdexStreamCache implementing the interfaces it serves
+var _ api.OrderbookFetcher = &SdexStreamCache{}
+var _ api.FillTrackable = &SdexStreamCache{}
+
+// MakeSdexStreamCache is a factory method
+func MakeSdexStreamCache(sdex *SDEX, reconnectDelay time.Duration, staleAfter time.Duration, clock api.Clock) *SdexStreamCache {
+	return &SdexStreamCache{
+		sdex:             sdex,
+		reconnectDelay:   reconnectDelay,
+		staleAfter:       staleAfter,
+		clock:            clock,
+		pollOrderBook:    sdex.fetchOrderBook,
+		pollTradeHistory: sdex.fetchTradeHistory,
+		mutex:            &sync.Mutex{},
+	}
+}
+
+// Start subscribes to the orderbook and trades streams in the background, reconnecting whenever a stream ends, until ctx is done
+func (c *SdexStreamCache) Start(ctx context.Context) error {
+	baseAsset, quoteAsset, e := c.sdex.Assets()
+	if e != nil {
+		return fmt.Errorf("could not get assets for the stream cache: %s", e)
+	}
+
+	go c.keepStreaming(ctx, "orderbook", func() error {
+		return c.streamOrderBook(ctx, baseAsset, quoteAsset)
+	}, func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		c.orderbook = nil
+	})
+	go c.keepStreaming(ctx, "trades", func() error {
+		return c.streamTrades(ctx, baseAsset, quoteAsset)
+	}, func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		c.isTradesLive = false
+	})
+	return nil
+}
+
+func (c *SdexStreamCache) keepStreaming(ctx context.Context, name string, stream func() error, onDisconnect func()) {
+	for {
+		e := stream()
+		onDisconnect()
+		if ctx.Err() != nil {
+			log.Printf("stopped horizon %s stream: %s\n", name, ctx.Err())
+			return
+		}
+
+		log.Printf("horizon %s stream disconnected, polling horizon until we reconnect in %s: %v\n", name, c.reconnectDelay, e)
+		c.clock.Sleep(c.reconnectDelay)
+	}
+}
+
+func (c *SdexStreamCache) streamOrderBook(ctx context.Context, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) error {
+	obReq := horizonclient.OrderBookRequest{
+		SellingAssetType:   horizonclient.AssetType(baseAsset.Type),
+		SellingAssetCode:   baseAsset.Code,
+		SellingAssetIssuer: baseAsset.Issuer,
+		BuyingAssetType:    horizonclient.AssetType(quoteAsset.Type),
+		BuyingAssetCode:    quoteAsset.Code,
+		BuyingAssetIssuer:  quoteAsset.Issuer,
+	}
+	// horizon sends the current orderbook as soon as we connect and then again every time it changes
+	return c.sdex.API.StreamOrderBooks(ctx, obReq, func(ob hProtocol.OrderBookSummary) {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		c.orderbook = &ob
+		c.orderbookTime = c.clock.Now()
+	})
+}
+
+func (c *SdexStreamCache) streamTrades(ctx context.Context, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) error {
+	// start streaming from the latest trade of the account so we do not miss any trade that happens while we connect
+	latestReq := horizonclient.TradeRequest{
+		ForAccount: c.sdex.TradingAccount,
+		Order:      horizonclient.OrderDesc,
+		Limit:      1,
+	}
+	latestPage, e := c.sdex.API.Trades(latestReq)
+	if e != nil {
+		return fmt.Errorf("could not fetch latest trade of the account: %s", e)
+	}
+	cursor := "now"
+	if len(latestPage.Embedded.Records) > 0 {
+		cursor = latestPage.Embedded.Records[0].PT
+	}
+
+	c.mutex.Lock()
+	c.isTradesLive = true
+	// we do not know what happened while we were disconnected
+	c.hasNewTrades = true
+	c.mutex.Unlock()
+
+	tradeReq := horizonclient.TradeRequest{
+		ForAccount: c.sdex.TradingAccount,
+		Cursor:     cursor,
+	}
+	return c.sdex.API.StreamTrades(ctx, tradeReq, func(trade hProtocol.Trade) {
+		if !isTradeOnAssets(trade, baseAsset, quoteAsset) {
+			return
+		}
+
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		c.hasNewTrades = true
+	})
+}
+
+// isTradeOnAssets returns true if the trade is between the two assets, in either direction
+func isTradeOnAssets(trade hProtocol.Trade, baseAsset hProtocol.Asset, quoteAsset hProtocol.Asset) bool {
+	tradeBaseAsset := utils.Asset2String(hProtocol.Asset{Type: trade.BaseAssetType, Code: trade.BaseAssetCode, Issuer: trade.BaseAssetIssuer})
+	tradeQuoteAsset := utils.Asset2String(hProtocol.Asset{Type: trade.CounterAssetType, Code: trade.CounterAssetCode, Issuer: trade.CounterAssetIssuer})
+	base := utils.Asset2String(baseAsset)
+	quote := utils.Asset2String(quoteAsset)
+	return (base == tradeBaseAsset && quote == tradeQuoteAsset) || (base == tradeQuoteAsset && quote == tradeBaseAsset)
+}
+
+// GetOrderBook impl, serves the streamed orderbook when the stream is connected and has had an event within staleAfter. Horizon only sends
+// an event when the orderbook changes so a stale stream can also be a quiet market, in which case polling is correct but slower
+func (c *SdexStreamCache) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	if pair != c.sdex.pair {
+		return nil, fmt.Errorf("unregistered trading pair (%s) cannot be converted to horizon.Assets, instance's pair: %s", pair.String(), c.sdex.pair.String())
+	}
+
+	c.mutex.Lock()
+	ob := c.orderbook
+	obTime := c.orderbookTime
+	c.mutex.Unlock()
+
+	if ob == nil || c.clock.Now().Sub(obTime) > c.staleAfter {
+		return c.pollOrderBook(pair, maxCount)
+	}
+	return c.sdex.transformOrderBookSummary(pair, *ob, model.MakeTimestampFromTime(obTime), maxCount)
+}
+
+// GetTradeHistory impl, only polls horizon when the trades stream is disconnected or has seen trades that we have not fetched yet
+func (c *SdexStreamCache) GetTradeHistory(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+	c.mutex.Lock()
+	if c.isTradesLive && !c.hasNewTrades && maybeCursorStart != nil {
+		c.mutex.Unlock()
+		return &api.TradeHistoryResult{
+			Cursor: maybeCursorStart,
+			Trades: []model.Trade{},
+		}, nil
+	}
+	// reset before polling so a trade that is streamed while we poll is picked up by the next call
+	c.hasNewTrades = false
+	c.mutex.Unlock()
+
+	result, e := c.pollTradeHistory(pair, maybeCursorStart, maybeCursorEnd)
+	if e != nil || len(result.Trades) > 0 {
+		// keep polling until we catch up, since the poll can be limited by the page size or rate limits
+		c.mutex.Lock()
+		c.hasNewTrades = true
+		c.mutex.Unlock()
+	}
+	return result, e
+}
+
+// GetLatestTradeCursor impl
+func (c *SdexStreamCache) GetLatestTradeCursor() (interface{}, error) {
+	return c.sdex.GetLatestTradeCursor()
+}
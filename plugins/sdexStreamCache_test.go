@@ -0,0 +1,133 @@
+package plugins
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/utils"
+)
+
+func TestIsTradeOnAssets(t *testing.T) {
+	issuer := "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"
+	coupon := hProtocol.Asset{Type: "credit_alphanum12", Code: "COUPON", Issuer: issuer}
+	testCases := []struct {
+		name  string
+		trade hProtocol.Trade
+		want  bool
+	}{
+		{
+			name:  "same direction",
+			trade: hProtocol.Trade{BaseAssetType: utils.Native, CounterAssetType: "credit_alphanum12", CounterAssetCode: "COUPON", CounterAssetIssuer: issuer},
+			want:  true,
+		}, {
+			name:  "inverted direction",
+			trade: hProtocol.Trade{BaseAssetType: "credit_alphanum12", BaseAssetCode: "COUPON", BaseAssetIssuer: issuer, CounterAssetType: utils.Native},
+			want:  true,
+		}, {
+			name:  "different asset",
+			trade: hProtocol.Trade{BaseAssetType: utils.Native, CounterAssetType: "credit_alphanum4", CounterAssetCode: "USD", CounterAssetIssuer: issuer},
+			want:  false,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.Equal(t, k.want, isTradeOnAssets(k.trade, utils.NativeAsset, coupon))
+		})
+	}
+}
+
+func TestSdexStreamCacheGetTradeHistory(t *testing.T) {
+	numPolls := 0
+	var pollTrades []model.Trade
+	var pollErr error
+	c := &SdexStreamCache{
+		pollTradeHistory: func(pair model.TradingPair, maybeCursorStart interface{}, maybeCursorEnd interface{}) (*api.TradeHistoryResult, error) {
+			numPolls++
+			if pollErr != nil {
+				return nil, pollErr
+			}
+			return &api.TradeHistoryResult{Cursor: "2", Trades: pollTrades}, nil
+		},
+		mutex: &sync.Mutex{},
+	}
+	pair := model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("COUPON")}
+
+	// polls when the stream is not connected
+	_, e := c.GetTradeHistory(pair, "1", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, 1, numPolls)
+
+	// polls once after connecting because we may have missed trades while disconnected, then serves from the cache
+	c.isTradesLive = true
+	c.hasNewTrades = true
+	_, e = c.GetTradeHistory(pair, "1", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, 2, numPolls)
+	result, e := c.GetTradeHistory(pair, "1", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, 2, numPolls)
+	assert.Equal(t, "1", result.Cursor)
+	assert.Equal(t, 0, len(result.Trades))
+
+	// a streamed trade causes us to poll until the poll returns no trades
+	c.hasNewTrades = true
+	pollTrades = []model.Trade{{}}
+	_, e = c.GetTradeHistory(pair, "1", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, 3, numPolls)
+	pollTrades = nil
+	_, e = c.GetTradeHistory(pair, "2", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, 4, numPolls)
+	_, e = c.GetTradeHistory(pair, "2", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, 4, numPolls)
+
+	// an error when polling causes us to poll again
+	c.hasNewTrades = true
+	pollErr = fmt.Errorf("some error")
+	_, e = c.GetTradeHistory(pair, "2", nil)
+	assert.Error(t, e)
+	assert.Equal(t, 5, numPolls)
+	pollErr = nil
+	_, e = c.GetTradeHistory(pair, "2", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, 6, numPolls)
+}
+
+func TestSdexStreamCacheGetOrderBookStale(t *testing.T) {
+	pair := &model.TradingPair{Base: model.Asset("XLM"), Quote: model.Asset("COUPON")}
+	clock := MakeVirtualClock(time.Unix(1000, 0))
+	numPolls := 0
+	c := &SdexStreamCache{
+		sdex:       &SDEX{pair: pair},
+		staleAfter: 10 * time.Second,
+		clock:      clock,
+		pollOrderBook: func(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+			numPolls++
+			return model.MakeOrderBook(pair, []model.Order{}, []model.Order{}), nil
+		},
+		mutex: &sync.Mutex{},
+	}
+
+	// polls when the stream is not connected
+	_, e := c.GetOrderBook(pair, 10)
+	assert.NoError(t, e)
+	assert.Equal(t, 1, numPolls)
+
+	// polls when the last event on the stream is older than staleAfter
+	c.orderbook = &hProtocol.OrderBookSummary{}
+	c.orderbookTime = clock.Now()
+	clock.Advance(11 * time.Second)
+	_, e = c.GetOrderBook(pair, 10)
+	assert.NoError(t, e)
+	assert.Equal(t, 2, numPolls)
+}
@@ -0,0 +1,184 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/kelp/api"
+)
+
+// aggregations supported by the sdex-trades feed
+const (
+	sdexTradesAggregationVwap   = "vwap"
+	sdexTradesAggregationLast   = "last"
+	sdexTradesAggregationMedian = "median"
+)
+
+// sdexTradesMaxPages limits the number of pages of trades fetched from horizon on each call so a busy market with a long window cannot stall
+// the update cycle, in which case the price is computed over the most recent trades in the window
+const sdexTradesMaxPages = 10
+
+// sdexTradeSample is the price and base volume of a trade
+type sdexTradeSample struct {
+	price  float64
+	volume float64
+}
+
+// sdexTradesFeed prices an asset from the trades executed on SDEX within a time window, which for illiquid assets is more honest than the book
+type sdexTradesFeed struct {
+	client      *horizonclient.Client
+	assetBase   *hProtocol.Asset
+	assetQuote  *hProtocol.Asset
+	aggregation string
+	window      time.Duration
+	minVolume   float64
+	clock       api.Clock
+}
+
+// ensure that it implements PriceFeed
+var _ api.PriceFeed = &sdexTradesFeed{}
+
+// makeSDEXTradesFeed creates a price feed from a URL formatted as CODE:ISSUER/CODE:ISSUER/aggregation/windowSeconds[/minBaseVolume]
+// where aggregation is one of vwap, last, or median
+func makeSDEXTradesFeed(url string) (*sdexTradesFeed, error) {
+	urlParts := strings.Split(url, "/")
+	if len(urlParts) < 4 || len(urlParts) > 5 {
+		return nil, fmt.Errorf("invalid format of sdex-trades type URL, needs either 4 or 5 parts after splitting URL by '/', has %d: %s", len(urlParts), url)
+	}
+
+	baseAsset, e := parseHorizonAsset(urlParts[0])
+	if e != nil {
+		return nil, fmt.Errorf("unable to convert base asset url to sdex asset: %s", e)
+	}
+	quoteAsset, e := parseHorizonAsset(urlParts[1])
+	if e != nil {
+		return nil, fmt.Errorf("unable to convert quote asset url to sdex asset: %s", e)
+	}
+
+	aggregation := urlParts[2]
+	if aggregation != sdexTradesAggregationVwap && aggregation != sdexTradesAggregationLast && aggregation != sdexTradesAggregationMedian {
+		return nil, fmt.Errorf("unsupported aggregation '%s' on sdex-trades type URL, needs to be one of %s, %s, or %s", aggregation, sdexTradesAggregationVwap, sdexTradesAggregationLast, sdexTradesAggregationMedian)
+	}
+
+	windowSeconds, e := strconv.ParseFloat(urlParts[3], 64)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse windowSeconds ('%s') on sdex-trades type URL: %s", urlParts[3], e)
+	}
+	if windowSeconds <= 0.0 {
+		return nil, fmt.Errorf("windowSeconds on sdex-trades type URL needs to be greater than 0 but was %f", windowSeconds)
+	}
+
+	minVolume := 0.0
+	if len(urlParts) == 5 {
+		minVolume, e = strconv.ParseFloat(urlParts[4], 64)
+		if e != nil {
+			return nil, fmt.Errorf("unable to parse minBaseVolume ('%s') on sdex-trades type URL: %s", urlParts[4], e)
+		}
+		if minVolume < 0.0 {
+			return nil, fmt.Errorf("minBaseVolume on sdex-trades type URL cannot be negative but was %f", minVolume)
+		}
+	}
+
+	client, _, _ := sdexFeedHorizonConfig()
+	return &sdexTradesFeed{
+		client:      client,
+		assetBase:   baseAsset,
+		assetQuote:  quoteAsset,
+		aggregation: aggregation,
+		window:      time.Duration(windowSeconds * float64(time.Second)),
+		minVolume:   minVolume,
+		clock:       MakeRealClock(),
+	}, nil
+}
+
+// GetPrice impl
+func (f *sdexTradesFeed) GetPrice() (float64, error) {
+	samples, e := f.fetchTradesInWindow()
+	if e != nil {
+		return 0, fmt.Errorf("unable to fetch sdex trades: %s", e)
+	}
+	return aggregateSdexTrades(samples, f.aggregation, f.minVolume)
+}
+
+// fetchTradesInWindow fetches the trades for the pair within the window, most recent first
+func (f *sdexTradesFeed) fetchTradesInWindow() ([]sdexTradeSample, error) {
+	windowStart := f.clock.Now().Add(-f.window)
+	samples := []sdexTradeSample{}
+	cursor := ""
+	for page := 0; page < sdexTradesMaxPages; page++ {
+		tradeReq := horizonclient.TradeRequest{
+			BaseAssetType:      horizonclient.AssetType(f.assetBase.Type),
+			BaseAssetCode:      f.assetBase.Code,
+			BaseAssetIssuer:    f.assetBase.Issuer,
+			CounterAssetType:   horizonclient.AssetType(f.assetQuote.Type),
+			CounterAssetCode:   f.assetQuote.Code,
+			CounterAssetIssuer: f.assetQuote.Issuer,
+			Order:              horizonclient.OrderDesc,
+			Cursor:             cursor,
+			Limit:              uint(maxPageLimit),
+		}
+		tradesPage, e := f.client.Trades(tradeReq)
+		if e != nil {
+			return nil, fmt.Errorf("error while fetching trades from horizon (cursor=%s): %s", cursor, e)
+		}
+
+		for _, t := range tradesPage.Embedded.Records {
+			if t.LedgerCloseTime.Before(windowStart) {
+				return samples, nil
+			}
+
+			price, vol, e := tradeRecord2PriceVolume(t)
+			if e != nil {
+				return nil, fmt.Errorf("could not parse trade (ID=%s): %s", t.ID, e)
+			}
+			samples = append(samples, sdexTradeSample{
+				price:  price.AsFloat(),
+				volume: vol.AsFloat(),
+			})
+			cursor = t.PT
+		}
+
+		if len(tradesPage.Embedded.Records) < maxPageLimit {
+			return samples, nil
+		}
+	}
+	return samples, nil
+}
+
+// aggregateSdexTrades computes the price from the trades, which should be ordered with the most recent trade first
+func aggregateSdexTrades(samples []sdexTradeSample, aggregation string, minVolume float64) (float64, error) {
+	if len(samples) == 0 {
+		return 0, fmt.Errorf("there were no sdex trades in the window")
+	}
+
+	totalVolume := 0.0
+	totalCost := 0.0
+	for _, s := range samples {
+		totalVolume += s.volume
+		totalCost += s.price * s.volume
+	}
+	if totalVolume < minVolume {
+		return 0, fmt.Errorf("the base volume of the %d sdex trades in the window (%.7f) was less than the minimum base volume (%.7f)", len(samples), totalVolume, minVolume)
+	}
+
+	switch aggregation {
+	case sdexTradesAggregationVwap:
+		if totalVolume <= 0.0 {
+			return 0, fmt.Errorf("the base volume of the sdex trades in the window was <= 0.0 (%.7f)", totalVolume)
+		}
+		return totalCost / totalVolume, nil
+	case sdexTradesAggregationLast:
+		return samples[0].price, nil
+	case sdexTradesAggregationMedian:
+		prices := []float64{}
+		for _, s := range samples {
+			prices = append(prices, s.price)
+		}
+		return computeMedian(prices), nil
+	}
+	return 0, fmt.Errorf("unsupported aggregation '%s'", aggregation)
+}
@@ -0,0 +1,59 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateSdexTrades(t *testing.T) {
+	// most recent trade first
+	samples := []sdexTradeSample{
+		{price: 0.12, volume: 10},
+		{price: 0.10, volume: 30},
+		{price: 0.11, volume: 60},
+	}
+
+	testCases := []struct {
+		aggregation string
+		minVolume   float64
+		samples     []sdexTradeSample
+		wantPrice   float64
+		wantError   bool
+	}{
+		{aggregation: sdexTradesAggregationVwap, samples: samples, wantPrice: 0.108},
+		{aggregation: sdexTradesAggregationLast, samples: samples, wantPrice: 0.12},
+		{aggregation: sdexTradesAggregationMedian, samples: samples, wantPrice: 0.11},
+		{aggregation: sdexTradesAggregationVwap, minVolume: 100, samples: samples, wantPrice: 0.108},
+		{aggregation: sdexTradesAggregationVwap, minVolume: 101, samples: samples, wantError: true},
+		{aggregation: sdexTradesAggregationLast, samples: []sdexTradeSample{}, wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.aggregation, func(t *testing.T) {
+			price, e := aggregateSdexTrades(k.samples, k.aggregation, k.minVolume)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 0.0000001)
+		})
+	}
+}
+
+func TestMakeSDEXTradesFeedInvalid(t *testing.T) {
+	for _, url := range []string{
+		"XLM:/COUPON:GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI/vwap",
+		"XLM:/COUPON:GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI/mean/3600",
+		"XLM:/COUPON:GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI/vwap/0",
+		"XLM:/COUPON:GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI/vwap/3600/-1",
+	} {
+		t.Run(url, func(t *testing.T) {
+			_, e := makeSDEXTradesFeed(url)
+			assert.Error(t, e)
+		})
+	}
+}
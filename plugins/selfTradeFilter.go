@@ -0,0 +1,222 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+)
+
+type selfTradeFilterMode string
+
+// type of selfTradeFilterMode
+const (
+	selfTradeFilterModeDrop   selfTradeFilterMode = "drop"
+	selfTradeFilterModeAdjust selfTradeFilterMode = "adjust"
+)
+
+// String is the Stringer method
+func (m selfTradeFilterMode) String() string {
+	return string(m)
+}
+
+func parseSelfTradeFilterMode(mode string) (selfTradeFilterMode, error) {
+	if mode == string(selfTradeFilterModeDrop) {
+		return selfTradeFilterModeDrop, nil
+	} else if mode == string(selfTradeFilterModeAdjust) {
+		return selfTradeFilterModeAdjust, nil
+	}
+	return selfTradeFilterModeDrop, fmt.Errorf("invalid self trade filter mode '%s'", mode)
+}
+
+// SelfTradeFilterConfig lists our own accounts whose offers we should never trade against. In "drop" mode the operations that would
+// cross the offers are dropped, and in "adjust" mode their price is moved so they no longer cross the offers
+type SelfTradeFilterConfig struct {
+	Accounts []string
+	mode     selfTradeFilterMode
+}
+
+// Validate ensures validity
+func (c *SelfTradeFilterConfig) Validate() error {
+	if len(c.Accounts) == 0 {
+		return fmt.Errorf("invalid accounts: needs at least one account")
+	}
+
+	if _, e := parseSelfTradeFilterMode(string(c.mode)); e != nil {
+		return fmt.Errorf("could not parse mode: %s", e)
+	}
+
+	return nil
+}
+
+// String is the stringer method
+func (c *SelfTradeFilterConfig) String() string {
+	return fmt.Sprintf("SelfTradeFilterConfig[Accounts=%v, mode=%s]", c.Accounts, c.mode)
+}
+
+type selfTradeFilter struct {
+	name         string
+	configValue  string
+	baseAsset    hProtocol.Asset
+	quoteAsset   hProtocol.Asset
+	config       *SelfTradeFilterConfig
+	loadOffersFn func(account string) ([]hProtocol.Offer, error)
+}
+
+// makeFilterSelfTrade makes a submit filter that prevents our operations from crossing the offers of our own accounts
+func makeFilterSelfTrade(
+	configValue string,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+	loadOffersFn func(account string) ([]hProtocol.Offer, error),
+	config *SelfTradeFilterConfig,
+) (SubmitFilter, error) {
+	e := config.Validate()
+	if e != nil {
+		return nil, fmt.Errorf("invalid config: %s", e)
+	}
+
+	return &selfTradeFilter{
+		name:         "selfTradeFilter",
+		configValue:  configValue,
+		baseAsset:    baseAsset,
+		quoteAsset:   quoteAsset,
+		config:       config,
+		loadOffersFn: loadOffersFn,
+	}, nil
+}
+
+var _ SubmitFilter = &selfTradeFilter{}
+
+func (f *selfTradeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
+	// the offers of the trader are updated by the ops so we exclude them, like the makerModeFilter excludes them from the orderbook
+	traderOfferIDs := map[int64]bool{}
+	for _, o := range append(sellingOffers, buyingOffers...) {
+		traderOfferIDs[o.ID] = true
+	}
+
+	ownOffers := []hProtocol.Offer{}
+	for _, account := range f.config.Accounts {
+		offers, e := f.loadOffersFn(account)
+		if e != nil {
+			return nil, fmt.Errorf("could not load offers for account '%s': %s", account, e)
+		}
+		for _, o := range offers {
+			if !traderOfferIDs[o.ID] {
+				ownOffers = append(ownOffers, o)
+			}
+		}
+	}
+	ownAsks, ownBids := utils.FilterOffers(ownOffers, f.baseAsset, f.quoteAsset)
+
+	// prices are in units of the quote asset per unit of the base asset
+	lowestAsk := math.Inf(1)
+	for _, o := range ownAsks {
+		lowestAsk = math.Min(lowestAsk, float64(o.PriceR.N)/float64(o.PriceR.D))
+	}
+	highestBid := math.Inf(-1)
+	for _, o := range ownBids {
+		highestBid = math.Max(highestBid, float64(o.PriceR.D)/float64(o.PriceR.N))
+	}
+	log.Printf("selfTradeFilter: loaded %d asks and %d bids from our own accounts, lowestAsk = %.7f, highestBid = %.7f (%s)\n",
+		len(ownAsks), len(ownBids), lowestAsk, highestBid, f.config)
+
+	innerFn := func(op *txnbuild.ManageSellOffer) (*txnbuild.ManageSellOffer, error) {
+		return selfTradeFilterFn(f.config.mode, lowestAsk, highestBid, op, f.baseAsset, f.quoteAsset)
+	}
+	ops, e := filterOps(f.name, f.baseAsset, f.quoteAsset, sellingOffers, buyingOffers, ops, innerFn)
+	if e != nil {
+		return nil, fmt.Errorf("could not apply filter: %s", e)
+	}
+	return ops, nil
+}
+
+// selfTradeFilterFn drops or adjusts a sell op priced at or below the highest bid or a buy op priced at or above the lowest ask,
+// where the prices of the bid and ask are in units of the quote asset per unit of the base asset
+func selfTradeFilterFn(
+	mode selfTradeFilterMode,
+	lowestAsk float64,
+	highestBid float64,
+	op *txnbuild.ManageSellOffer,
+	baseAsset hProtocol.Asset,
+	quoteAsset hProtocol.Asset,
+) (*txnbuild.ManageSellOffer, error) {
+	isSell, e := utils.IsSelling(baseAsset, quoteAsset, op.Selling, op.Buying)
+	if e != nil {
+		return nil, fmt.Errorf("error when running the isSelling check for offer '%+v': %s", *op, e)
+	}
+
+	offerPrice, e := strconv.ParseFloat(op.Price, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert price (%s) to float: %s", op.Price, e)
+	}
+	offerAmount, e := strconv.ParseFloat(op.Amount, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert amount (%s) to float: %s", op.Amount, e)
+	}
+	// A "buy" op has amount = sellAmount * sellPrice, and price = 1/sellPrice
+	// So, we adjust the offer variables by "undoing" those adjustments so the amount is in base units and the price is in quote units
+	if !isSell {
+		offerAmount = offerAmount * offerPrice
+		offerPrice = 1 / offerPrice
+	}
+
+	crosses := func(price float64) bool {
+		if isSell {
+			return price <= highestBid
+		}
+		return price >= lowestAsk
+	}
+	if !crosses(offerPrice) {
+		return op, nil
+	}
+
+	if mode == selfTradeFilterModeDrop {
+		log.Printf("selfTradeFilter: isSell=%v, offerPrice=%.7f crosses our own offers (lowestAsk=%.7f, highestBid=%.7f); keep=false", isSell, offerPrice, lowestAsk, highestBid)
+		return nil, nil
+	}
+
+	// move the price one unit of the SDEX price precision past our own offers, keeping the amount in base units the same
+	tick := math.Pow(10, -float64(sdexOrderConstraints.PricePrecision))
+	newPrice := highestBid + tick
+	if !isSell {
+		newPrice = lowestAsk - tick
+	}
+	if newPrice <= 0 {
+		log.Printf("selfTradeFilter: isSell=%v, offerPrice=%.7f cannot be adjusted below the lowestAsk=%.7f; keep=false", isSell, offerPrice, lowestAsk)
+		return nil, nil
+	}
+
+	newOp := *op
+	if isSell {
+		newOp.Price = fmt.Sprintf("%.7f", newPrice)
+	} else {
+		newOp.Price = fmt.Sprintf("%.7f", 1/newPrice)
+		newOp.Amount = fmt.Sprintf("%.7f", offerAmount*newPrice)
+	}
+
+	// rounding the price of buy ops can make them cross again, in which case we drop them
+	roundedPrice, e := strconv.ParseFloat(newOp.Price, 64)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert adjusted price (%s) to float: %s", newOp.Price, e)
+	}
+	if !isSell {
+		roundedPrice = 1 / roundedPrice
+	}
+	if crosses(roundedPrice) {
+		log.Printf("selfTradeFilter: isSell=%v, offerPrice=%.7f still crosses our own offers after adjusting the price to %.7f; keep=false", isSell, offerPrice, roundedPrice)
+		return nil, nil
+	}
+
+	log.Printf("selfTradeFilter: isSell=%v, adjusted offerPrice from %.7f to %.7f so it does not cross our own offers; keep=true", isSell, offerPrice, roundedPrice)
+	return &newOp, nil
+}
+
+// String is the Stringer method
+func (f *selfTradeFilter) String() string {
+	return f.configValue
+}
@@ -0,0 +1,228 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/openlyinc/pointy"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/support/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSelfTradeAccount1 = "GBGQAGAMK6W6FH6AGGZ2BI2MY5TA5VJEHU2DQRFXACMAZHNRD3SXEV6Z"
+	testSelfTradeAccount2 = "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"
+)
+
+// makeSelfTradeOffer makes an offer where the price is in units of the quote asset per unit of the base asset and is set as n/d
+func makeSelfTradeOffer(id int64, isSell bool, n int32, d int32) hProtocol.Offer {
+	offer := hProtocol.Offer{ID: id}
+	if isSell {
+		offer.Selling = utils.Asset2Asset2(testBaseAsset)
+		offer.Buying = utils.Asset2Asset2(testQuoteAsset)
+		offer.PriceR.N = n
+		offer.PriceR.D = d
+	} else {
+		offer.Selling = utils.Asset2Asset2(testQuoteAsset)
+		offer.Buying = utils.Asset2Asset2(testBaseAsset)
+		offer.PriceR.N = d
+		offer.PriceR.D = n
+	}
+	offer.Price = fmt.Sprintf("%.7f", float64(offer.PriceR.N)/float64(offer.PriceR.D))
+	offer.Amount = "10.0000000"
+	return offer
+}
+
+func TestSelfTradeFilterFn(t *testing.T) {
+	// our own accounts have an ask at 1.1 and a bid at 0.9
+	testCases := []struct {
+		name       string
+		mode       selfTradeFilterMode
+		isSell     bool
+		inputPrice float64
+		wantPrice  *float64
+	}{
+		{
+			name:       "sell above the bid",
+			mode:       selfTradeFilterModeDrop,
+			isSell:     true,
+			inputPrice: 0.95,
+			wantPrice:  pointy.Float64(0.95),
+		}, {
+			name:       "sell at the bid; drop",
+			mode:       selfTradeFilterModeDrop,
+			isSell:     true,
+			inputPrice: 0.9,
+			wantPrice:  nil,
+		}, {
+			name:       "sell below the bid; drop",
+			mode:       selfTradeFilterModeDrop,
+			isSell:     true,
+			inputPrice: 0.8,
+			wantPrice:  nil,
+		}, {
+			name:       "sell below the bid; adjust",
+			mode:       selfTradeFilterModeAdjust,
+			isSell:     true,
+			inputPrice: 0.8,
+			wantPrice:  pointy.Float64(0.9000001),
+		}, {
+			name:       "buy below the ask",
+			mode:       selfTradeFilterModeDrop,
+			isSell:     false,
+			inputPrice: 1.05,
+			wantPrice:  pointy.Float64(1.05),
+		}, {
+			name:       "buy at the ask; drop",
+			mode:       selfTradeFilterModeDrop,
+			isSell:     false,
+			inputPrice: 1.1,
+			wantPrice:  nil,
+		}, {
+			name:       "buy above the ask; adjust",
+			mode:       selfTradeFilterModeAdjust,
+			isSell:     false,
+			inputPrice: 1.2,
+			wantPrice:  pointy.Float64(1.0999999),
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			inputOp := makeSellOpAmtPrice(10.0, k.inputPrice)
+			if !k.isSell {
+				inputOp = makeBuyOpAmtPrice(10.0, k.inputPrice)
+			}
+
+			base := utils.Asset2Asset2(testBaseAsset)
+			quote := utils.Asset2Asset2(testQuoteAsset)
+			actual, e := selfTradeFilterFn(k.mode, 1.1, 0.9, inputOp, base, quote)
+			if !assert.NoError(t, e) {
+				return
+			}
+			if k.wantPrice == nil {
+				assert.Nil(t, actual)
+				return
+			}
+			if !assert.NotNil(t, actual) {
+				return
+			}
+
+			price, e := strconv.ParseFloat(actual.Price, 64)
+			if !assert.NoError(t, e) {
+				return
+			}
+			amount, e := strconv.ParseFloat(actual.Amount, 64)
+			if !assert.NoError(t, e) {
+				return
+			}
+			// convert the buy op back to the price in quote units and the amount in base units
+			if !k.isSell {
+				amount = amount * price
+				price = 1 / price
+			}
+			assert.InDelta(t, *k.wantPrice, price, 0.0000001)
+			assert.InDelta(t, 10.0, amount, 0.00001)
+		})
+	}
+}
+
+func TestSelfTradeFilterApply(t *testing.T) {
+	// the trader trades on the first account and has a bid at 1.2, which is excluded from our own offers when it is passed in as an offer of the trader
+	traderBid := makeSelfTradeOffer(2, false, 12, 10)
+	offersByAccount := map[string][]hProtocol.Offer{
+		testSelfTradeAccount1: {
+			makeSelfTradeOffer(1, true, 11, 10),
+			traderBid,
+		},
+		testSelfTradeAccount2: {
+			makeSelfTradeOffer(3, false, 9, 10),
+		},
+	}
+	loadOffersFn := func(account string) ([]hProtocol.Offer, error) {
+		offers, ok := offersByAccount[account]
+		if !ok {
+			return nil, fmt.Errorf("unknown account")
+		}
+		return offers, nil
+	}
+	config := &SelfTradeFilterConfig{Accounts: []string{testSelfTradeAccount1, testSelfTradeAccount2}, mode: selfTradeFilterModeDrop}
+	f, e := makeFilterSelfTrade("selfTrade/drop/"+testSelfTradeAccount1+","+testSelfTradeAccount2, utils.Asset2Asset2(testBaseAsset), utils.Asset2Asset2(testQuoteAsset), loadOffersFn, config)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// the sell op at 0.85 crosses the bid at 0.9 and the bid of the trader is deleted because it crosses the ask at 1.1
+	ops, e := f.Apply([]txnbuild.Operation{
+		makeSellOpAmtPrice(10.0, 0.95),
+		makeSellOpAmtPrice(10.0, 0.85),
+	}, nil, []hProtocol.Offer{traderBid})
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(ops)) {
+		return
+	}
+	assert.Equal(t, "0.9500000", ops[0].(*txnbuild.ManageSellOffer).Price)
+	assert.Equal(t, int64(2), ops[1].(*txnbuild.ManageSellOffer).OfferID)
+	assert.Equal(t, "0", ops[1].(*txnbuild.ManageSellOffer).Amount)
+
+	// the buy op at 1.15 crosses the ask at 1.1
+	ops, e = f.Apply([]txnbuild.Operation{
+		makeBuyOpAmtPrice(10.0, 0.95),
+		makeBuyOpAmtPrice(10.0, 1.15),
+	}, nil, nil)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 1, len(ops)) {
+		return
+	}
+	assert.Equal(t, fmt.Sprintf("%.7f", 1/0.95), ops[0].(*txnbuild.ManageSellOffer).Price)
+}
+
+func TestMakeSelfTradeFilterConfig(t *testing.T) {
+	testCases := []struct {
+		configInput  string
+		wantAccounts []string
+		wantMode     selfTradeFilterMode
+		wantError    bool
+	}{
+		{
+			configInput:  "selfTrade/drop/" + testSelfTradeAccount1,
+			wantAccounts: []string{testSelfTradeAccount1},
+			wantMode:     selfTradeFilterModeDrop,
+		}, {
+			configInput:  "selfTrade/adjust/" + testSelfTradeAccount1 + ", " + testSelfTradeAccount2 + "," + testSelfTradeAccount1,
+			wantAccounts: []string{testSelfTradeAccount1, testSelfTradeAccount2},
+			wantMode:     selfTradeFilterModeAdjust,
+		}, {
+			configInput: "selfTrade/drop",
+			wantError:   true,
+		}, {
+			configInput: "selfTrade/shrink/" + testSelfTradeAccount1,
+			wantError:   true,
+		}, {
+			configInput: "selfTrade/drop/account1",
+			wantError:   true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.configInput, func(t *testing.T) {
+			config, e := makeSelfTradeFilterConfig(k.configInput)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, k.wantAccounts, config.Accounts)
+			assert.Equal(t, k.wantMode, config.mode)
+		})
+	}
+}
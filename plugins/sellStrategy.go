@@ -23,6 +23,11 @@ type sellConfig struct {
 	RateOffset             float64       `valid:"-" toml:"RATE_OFFSET"`
 	RateOffsetPercentFirst bool          `valid:"-" toml:"RATE_OFFSET_PERCENT_FIRST"`
 	Levels                 []StaticLevel `valid:"-" toml:"LEVELS"`
+
+	// optional params to skew the price and amount based on the inventory, leave both skews at 0 to disable
+	InventoryTargetBasePercent float64 `valid:"-" toml:"INVENTORY_TARGET_BASE_PERCENT"` // share of the total value we want to hold in the base asset, specified as a decimal
+	InventoryPriceSkew         float64 `valid:"-" toml:"INVENTORY_PRICE_SKEW"`          // how far the center price moves per unit of deviation from the target
+	InventoryAmountSkew        float64 `valid:"-" toml:"INVENTORY_AMOUNT_SKEW"`         // how much the amounts change per unit of deviation from the target
 }
 
 // String impl.
@@ -37,9 +42,10 @@ func makeSellStrategy(
 	ieif *IEIF,
 	assetBase *hProtocol.Asset,
 	assetQuote *hProtocol.Asset,
+	priceFeedFactory *PriceFeedFactory,
 	config *sellConfig,
 ) (api.Strategy, error) {
-	pf, e := MakeFeedPair(
+	pf, e := priceFeedFactory.MakeFeedPair(
 		config.DataTypeA,
 		config.DataFeedAURL,
 		config.DataTypeB,
@@ -55,13 +61,25 @@ func makeSellStrategy(
 		absolute:     config.RateOffset,
 		percentFirst: config.RateOffsetPercentFirst,
 	}
+	skew, e := makeInventorySkew(
+		ieif,
+		*assetBase,
+		*assetQuote,
+		config.InventoryTargetBasePercent,
+		config.InventoryPriceSkew,
+		config.InventoryAmountSkew,
+		false,
+	)
+	if e != nil {
+		return nil, fmt.Errorf("cannot make the sell strategy because we could not make the inventory skew: %s", e)
+	}
 	sellSideStrategy := makeSellSideStrategy(
 		sdex,
 		orderConstraints,
 		ieif,
 		assetBase,
 		assetQuote,
-		makeStaticSpreadLevelProvider(config.Levels, config.AmountOfABase, offset, pf, orderConstraints),
+		makeStaticSpreadLevelProvider(config.Levels, config.AmountOfABase, offset, pf, orderConstraints, skew),
 		config.PriceTolerance,
 		config.AmountTolerance,
 		false,
@@ -31,6 +31,7 @@ type sellTwapLevelProvider struct {
 	minChildOrderSizePercentOfParent                      float64
 	random                                                *rand.Rand
 	isBuySide                                             bool
+	clock                                                 api.Clock
 
 	// uninitialized
 	activeBucket    *bucketInfo
@@ -53,6 +54,7 @@ func makeSellTwapLevelProvider(
 	minChildOrderSizePercentOfParent float64,
 	randSeed int64,
 	isBuySide bool,
+	clock api.Clock,
 ) (api.LevelProvider, error) {
 	if numHoursToSell <= 0 || numHoursToSell > 24 {
 		return nil, fmt.Errorf("invalid number of hours to sell, expected 0 < numHoursToSell <= 24; was %d", numHoursToSell)
@@ -97,6 +99,7 @@ func makeSellTwapLevelProvider(
 		minChildOrderSizePercentOfParent:                      minChildOrderSizePercentOfParent,
 		random:                                                random,
 		isBuySide:                                             isBuySide,
+		clock:                                                 clock,
 	}, nil
 }
 
@@ -248,7 +251,7 @@ func (r *roundInfo) String() string {
 
 // GetLevels impl.
 func (p *sellTwapLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
-	now := time.Now().UTC()
+	now := p.clock.Now().UTC()
 	log.Printf("GetLevels, unix timestamp for 'now' in UTC = %d (%s)\n", now.Unix(), now)
 
 	volFilter := p.dowFilter[now.Weekday()]
@@ -93,6 +93,7 @@ func makeTestSellTwapLevelProvider2(
 		minChildOrderSizePercentOfParent,
 		seed,
 		false,
+		MakeRealClock(),
 	)
 	if e != nil {
 		panic(e)
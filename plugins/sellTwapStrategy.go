@@ -2,7 +2,6 @@ package plugins
 
 import (
 	"fmt"
-	"time"
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/api"
@@ -52,9 +51,11 @@ func makeSellTwapStrategy(
 	assetBase *hProtocol.Asset,
 	assetQuote *hProtocol.Asset,
 	filterFactory *FilterFactory,
+	priceFeedFactory *PriceFeedFactory,
 	config *sellTwapConfig,
+	clock api.Clock,
 ) (api.Strategy, error) {
-	startPf, e := MakePriceFeed(config.StartAskFeedType, config.StartAskFeedURL)
+	startPf, e := priceFeedFactory.MakePriceFeed(config.StartAskFeedType, config.StartAskFeedURL)
 	if e != nil {
 		return nil, fmt.Errorf("error when making the start priceFeed: %s", e)
 	}
@@ -79,8 +80,9 @@ func makeSellTwapStrategy(
 		config.DistributeSurplusOverRemainingIntervalsPercentCeiling,
 		config.ExponentialSmoothingFactor,
 		config.MinChildOrderSizePercentOfParent,
-		time.Now().UnixNano(),
+		clock.Now().UnixNano(),
 		false,
+		clock,
 	)
 	if e != nil {
 		return nil, fmt.Errorf("error when making a sellTwapLevelProvider: %s", e)
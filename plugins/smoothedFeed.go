@@ -0,0 +1,225 @@
+package plugins
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/queries"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+// smoothing types supported by the smoothed feed
+const (
+	smoothingEMA  = "ema"
+	smoothingTWAP = "twap"
+)
+
+// smoothedFeed samples the inner feed on every call to GetPrice and returns the price smoothed over the window, so that small jitters in
+// the inner price do not move our offers on every update cycle
+type smoothedFeed struct {
+	feed      api.PriceFeed
+	smoothing string
+	window    time.Duration
+	clock     api.Clock
+	db        *sql.DB // can be nil, the samples are only persisted when the db is set
+	feedKey   string
+
+	// initialized runtime vars
+	mutex *sync.Mutex
+
+	// uninitialized
+	// for twap these are the samples in the window and the last sample before the window (unless it is stale), for ema this is the last
+	// ema value
+	samples []queries.PriceFeedSample
+}
+
+var _ api.PriceFeed = &smoothedFeed{}
+
+// makeSmoothedFeed is a factory method, it loads the persisted samples for the feedKey when the db is set
+func makeSmoothedFeed(feed api.PriceFeed, smoothing string, window time.Duration, clock api.Clock, db *sql.DB, feedKey string) (*smoothedFeed, error) {
+	if smoothing != smoothingEMA && smoothing != smoothingTWAP {
+		return nil, fmt.Errorf("invalid smoothing type '%s', needs to be either '%s' or '%s'", smoothing, smoothingEMA, smoothingTWAP)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("window needs to be greater than 0, was %s", window)
+	}
+
+	f := &smoothedFeed{
+		feed:      feed,
+		smoothing: smoothing,
+		window:    window,
+		clock:     clock,
+		db:        db,
+		feedKey:   feedKey,
+		mutex:     &sync.Mutex{},
+	}
+
+	if db != nil {
+		samples, e := f.loadState()
+		if e != nil {
+			return nil, fmt.Errorf("unable to load persisted samples for %s feed '%s': %s", smoothing, feedKey, e)
+		}
+		f.samples = f.pruneLoadedSamples(samples, clock.Now())
+		log.Printf("%s feed '%s' loaded %d persisted samples\n", smoothing, feedKey, len(f.samples))
+	}
+	return f, nil
+}
+
+// GetPrice impl
+func (f *smoothedFeed) GetPrice() (float64, error) {
+	price, e := f.feed.GetPrice()
+	if e != nil {
+		return 0.0, fmt.Errorf("error fetching price from inner feed of %s feed: %s", f.smoothing, e)
+	}
+	if price <= 0.0 {
+		return 0.0, fmt.Errorf("inner price of %s feed was <= 0.0 (%.10f)", f.smoothing, price)
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	now := f.clock.Now()
+
+	var smoothed float64
+	if f.smoothing == smoothingEMA {
+		smoothed = f.addEMASample(now, price)
+	} else {
+		smoothed = f.addTWAPSample(now, price)
+	}
+
+	e = f.saveState(now)
+	if e != nil {
+		// the smoothed price is still valid, we only lose the samples if we restart before the next successful save
+		log.Printf("unable to persist samples for %s feed '%s': %s\n", f.smoothing, f.feedKey, e)
+	}
+	return smoothed, nil
+}
+
+// addEMASample updates the exponential moving average with a weight that depends on the time since the last sample, so the average does not
+// depend on how often the feed is called
+func (f *smoothedFeed) addEMASample(now time.Time, price float64) float64 {
+	ema := price
+	if len(f.samples) > 0 {
+		last := f.samples[len(f.samples)-1]
+		elapsed := now.Sub(last.Time)
+		alpha := 0.0
+		if elapsed > 0 {
+			alpha = 1 - math.Exp(-float64(elapsed)/float64(f.window))
+		}
+		ema = last.Price + alpha*(price-last.Price)
+	}
+
+	f.samples = []queries.PriceFeedSample{{Time: now, Price: ema}}
+	return ema
+}
+
+// addTWAPSample adds the sample and returns the average of the samples in the window, where each sample is weighted by how long it was the
+// latest price
+func (f *smoothedFeed) addTWAPSample(now time.Time, price float64) float64 {
+	f.samples = f.pruneSamples(append(f.samples, queries.PriceFeedSample{Time: now, Price: price}), now)
+
+	windowStart := now.Add(-f.window)
+	weightedSum := 0.0
+	totalWeight := 0.0
+	for i := 0; i < len(f.samples)-1; i++ {
+		start := f.samples[i].Time
+		if start.Before(windowStart) {
+			start = windowStart
+		}
+		weight := float64(f.samples[i+1].Time.Sub(start))
+		if weight <= 0 {
+			continue
+		}
+		weightedSum += f.samples[i].Price * weight
+		totalWeight += weight
+	}
+
+	if totalWeight == 0 {
+		return price
+	}
+	return weightedSum / totalWeight
+}
+
+// pruneSamples drops samples that can no longer affect the smoothed price
+func (f *smoothedFeed) pruneSamples(samples []queries.PriceFeedSample, now time.Time) []queries.PriceFeedSample {
+	if len(samples) == 0 {
+		return samples
+	}
+
+	if f.smoothing == smoothingEMA {
+		return samples[len(samples)-1:]
+	}
+
+	// keep the last sample before the window since it was the latest price at the start of the window
+	windowStart := now.Add(-f.window)
+	firstIndex := 0
+	for i, s := range samples {
+		if s.Time.After(windowStart) {
+			break
+		}
+		firstIndex = i
+	}
+
+	// unless the next sample is more than a window later, in which case we were not sampling the inner feed (such as after a long run of
+	// errors from the inner feed) and the price is stale
+	if !samples[firstIndex].Time.After(windowStart) && firstIndex+1 < len(samples) && samples[firstIndex+1].Time.Sub(samples[firstIndex].Time) > f.window {
+		firstIndex++
+	}
+	return samples[firstIndex:]
+}
+
+// pruneLoadedSamples drops the persisted samples from before the window since we do not know what the price was while we were not running
+func (f *smoothedFeed) pruneLoadedSamples(samples []queries.PriceFeedSample, now time.Time) []queries.PriceFeedSample {
+	windowStart := now.Add(-f.window)
+	inWindow := []queries.PriceFeedSample{}
+	for _, s := range samples {
+		if s.Time.After(windowStart) {
+			inWindow = append(inWindow, s)
+		}
+	}
+	return f.pruneSamples(inWindow, now)
+}
+
+func (f *smoothedFeed) loadState() ([]queries.PriceFeedSample, error) {
+	q, e := queries.MakePriceFeedState(f.db, f.feedKey)
+	if e != nil {
+		return nil, fmt.Errorf("unable to make PriceFeedState query: %s", e)
+	}
+	result, e := q.QueryRow()
+	if e != nil {
+		return nil, fmt.Errorf("unable to run PriceFeedState query: %s", e)
+	}
+	samples, ok := result.([]queries.PriceFeedSample)
+	if !ok {
+		return nil, fmt.Errorf("unable to convert result of PriceFeedState query to []queries.PriceFeedSample: %v (type=%T)", result, result)
+	}
+	return samples, nil
+}
+
+func (f *smoothedFeed) saveState(now time.Time) error {
+	if f.db == nil {
+		return nil
+	}
+
+	samplesJSON, e := json.Marshal(f.samples)
+	if e != nil {
+		return fmt.Errorf("could not marshal samples: %s", e)
+	}
+
+	sqlUpsert := fmt.Sprintf(kelpdb.SqlPriceFeedStateUpsertTemplate,
+		f.feedKey,
+		string(samplesJSON),
+		now.UTC().Format(postgresdb.TimestampFormatString),
+	)
+	_, e = f.db.Exec(sqlUpsert)
+	if e != nil {
+		return fmt.Errorf("could not execute sql upsert statement (%s): %s", sqlUpsert, e)
+	}
+	return nil
+}
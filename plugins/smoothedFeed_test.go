@@ -0,0 +1,88 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/queries"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmoothedFeed(t *testing.T) {
+	testCases := []struct {
+		name       string
+		smoothing  string
+		offsets    []int // in seconds, defaults to samples 10 seconds apart
+		prices     []float64
+		wantPrices []float64
+	}{
+		{
+			name:      "twap",
+			smoothing: smoothingTWAP,
+			// samples 10 seconds apart with a 30 second window
+			prices:     []float64{1.0, 2.0, 3.0, 4.0, 5.0},
+			wantPrices: []float64{1.0, 1.0, 1.5, 2.0, 3.0},
+		}, {
+			name:      "twap with a gap longer than the window",
+			smoothing: smoothingTWAP,
+			// the price of 2.0 is not carried across the gap
+			offsets:    []int{0, 10, 100, 110},
+			prices:     []float64{1.0, 2.0, 3.0, 4.0},
+			wantPrices: []float64{1.0, 1.0, 3.0, 3.0},
+		}, {
+			name:      "ema",
+			smoothing: smoothingEMA,
+			// alpha = 1 - e^(-10/30) = 0.2834687
+			prices:     []float64{1.0, 2.0, 2.0},
+			wantPrices: []float64{1.0, 1.2834687, 1.4865829},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock := MakeVirtualClock(start)
+			inner, e := newFixedFeed("1.0")
+			if !assert.NoError(t, e) {
+				return
+			}
+			f, e := makeSmoothedFeed(inner, k.smoothing, 30*time.Second, clock, nil, "")
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			for i, p := range k.prices {
+				offset := i * 10
+				if k.offsets != nil {
+					offset = k.offsets[i]
+				}
+				clock.Set(start.Add(time.Duration(offset) * time.Second))
+				inner.price = p
+
+				price, e := f.GetPrice()
+				if !assert.NoError(t, e) {
+					return
+				}
+				assert.InDelta(t, k.wantPrices[i], price, 0.0000001, "index=%d", i)
+			}
+		})
+	}
+}
+
+func TestSmoothedFeedPruneLoadedSamples(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := []queries.PriceFeedSample{
+		{Time: start, Price: 1.0},
+		{Time: start.Add(10 * time.Second), Price: 2.0},
+		{Time: start.Add(50 * time.Second), Price: 3.0},
+	}
+	f, e := makeSmoothedFeed(nil, smoothingTWAP, 30*time.Second, MakeVirtualClock(start), nil, "")
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// the sample from before the window is not kept after a restart
+	assert.Equal(t, samples[2:], f.pruneLoadedSamples(samples, start.Add(60*time.Second)))
+	// none of the samples are kept after a restart that took longer than the window
+	assert.Equal(t, []queries.PriceFeedSample{}, f.pruneLoadedSamples(samples, start.Add(200*time.Second)))
+}
@@ -3,7 +3,9 @@ package plugins
 import (
 	"fmt"
 	"log"
+	"math"
 
+	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 )
@@ -33,6 +35,94 @@ type rateOffset struct {
 	invert bool
 }
 
+// inventorySkew shifts the center price and the amounts of one side based on how far the share of the total value held in the base asset
+// deviates from the target share. When we hold too much of the base asset the center price is lowered, the sell side gets larger and the
+// buy side gets smaller, so we are more likely to be filled on the side that brings us back to the target.
+type inventorySkew struct {
+	ieif              *IEIF
+	assetBase         hProtocol.Asset // always the base asset of the market, even on the buy side
+	assetQuote        hProtocol.Asset // always the quote asset of the market, even on the buy side
+	targetBasePercent float64
+	priceSkew         float64
+	amountSkew        float64
+	isBuySide         bool
+}
+
+// makeInventorySkew is a factory method, it returns nil when both priceSkew and amountSkew are 0 since there is nothing to skew
+func makeInventorySkew(
+	ieif *IEIF,
+	assetBase hProtocol.Asset,
+	assetQuote hProtocol.Asset,
+	targetBasePercent float64,
+	priceSkew float64,
+	amountSkew float64,
+	isBuySide bool,
+) (*inventorySkew, error) {
+	if priceSkew == 0 && amountSkew == 0 {
+		return nil, nil
+	}
+	if targetBasePercent < 0 || targetBasePercent > 1 {
+		return nil, fmt.Errorf("targetBasePercent needs to be between 0.0 and 1.0 (inclusive), was %f", targetBasePercent)
+	}
+	// the inventory deviation is in the range [-1, 1] so this keeps the center price positive
+	if priceSkew < 0 || priceSkew >= 1 {
+		return nil, fmt.Errorf("priceSkew needs to be in the range [0, 1), was %f", priceSkew)
+	}
+	if amountSkew < 0 {
+		return nil, fmt.Errorf("amountSkew cannot be negative, was %f", amountSkew)
+	}
+
+	return &inventorySkew{
+		ieif:              ieif,
+		assetBase:         assetBase,
+		assetQuote:        assetQuote,
+		targetBasePercent: targetBasePercent,
+		priceSkew:         priceSkew,
+		amountSkew:        amountSkew,
+		isBuySide:         isBuySide,
+	}, nil
+}
+
+// factors returns the factors to multiply the center price and the amounts by, midPrice is in the units of this side
+func (s *inventorySkew) factors(midPrice float64) (float64 /*priceFactor*/, float64 /*amountFactor*/, error) {
+	baseBalance, e := s.ieif.GetAssetBalance(s.assetBase)
+	if e != nil {
+		return 0, 0, fmt.Errorf("could not fetch balance of base asset: %s", e)
+	}
+	quoteBalance, e := s.ieif.GetAssetBalance(s.assetQuote)
+	if e != nil {
+		return 0, 0, fmt.Errorf("could not fetch balance of quote asset: %s", e)
+	}
+
+	// the buy side is inverted so convert back to the price of the base asset in units of the quote asset
+	basePrice := midPrice
+	if s.isBuySide {
+		basePrice = 1 / midPrice
+	}
+	inventory, e := computeInventorySkew(baseBalance.Balance, quoteBalance.Balance, basePrice, s.targetBasePercent)
+	if e != nil {
+		return 0, 0, fmt.Errorf("could not compute inventory: %s", e)
+	}
+
+	priceFactor, amountFactor := computeInventorySkewFactors(inventory, s.priceSkew, s.amountSkew, s.isBuySide)
+	log.Printf("inventory skew (isBuySide=%v): inventory=%.4f, priceFactor=%.6f, amountFactor=%.4f\n", s.isBuySide, inventory, priceFactor, amountFactor)
+	return priceFactor, amountFactor, nil
+}
+
+// computeInventorySkewFactors converts the deviation of the inventory from the target into factors for the center price and amounts of
+// one side. The center price of the market moves by (1 - inventory * priceSkew), which is inverted on the buy side, and the amounts move by
+// (1 + inventory * amountSkew) on the sell side and (1 - inventory * amountSkew) on the buy side, never going below 0. The levels of a side
+// with an amount factor of 0 are dropped by makeLevels.
+func computeInventorySkewFactors(inventory float64, priceSkew float64, amountSkew float64, isBuySide bool) (float64 /*priceFactor*/, float64 /*amountFactor*/) {
+	priceFactor := 1 - inventory*priceSkew
+	amountFactor := 1 + inventory*amountSkew
+	if isBuySide {
+		priceFactor = 1 / priceFactor
+		amountFactor = 1 - inventory*amountSkew
+	}
+	return priceFactor, math.Max(amountFactor, 0)
+}
+
 // staticSpreadLevelProvider provides a fixed number of levels using a static percentage spread
 type staticSpreadLevelProvider struct {
 	staticLevels     []StaticLevel
@@ -40,40 +130,73 @@ type staticSpreadLevelProvider struct {
 	offset           rateOffset
 	pf               *api.FeedPair
 	orderConstraints *model.OrderConstraints
+	skew             *inventorySkew // nil when disabled
 }
 
 // ensure it implements the LevelProvider interface
 var _ api.LevelProvider = &staticSpreadLevelProvider{}
 
 // makeStaticSpreadLevelProvider is a factory method
-func makeStaticSpreadLevelProvider(staticLevels []StaticLevel, amountOfBase float64, offset rateOffset, pf *api.FeedPair, orderConstraints *model.OrderConstraints) api.LevelProvider {
+func makeStaticSpreadLevelProvider(staticLevels []StaticLevel, amountOfBase float64, offset rateOffset, pf *api.FeedPair, orderConstraints *model.OrderConstraints, skew *inventorySkew) api.LevelProvider {
 	return &staticSpreadLevelProvider{
 		staticLevels:     staticLevels,
 		amountOfBase:     amountOfBase,
 		offset:           offset,
 		pf:               pf,
 		orderConstraints: orderConstraints,
+		skew:             skew,
 	}
 }
 
 // GetLevels impl.
 func (p *staticSpreadLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	midPrice, e := p.getMidPrice()
+	if e != nil {
+		return nil, e
+	}
+	return p.makeLevels(midPrice, p.staticLevels)
+}
+
+// getMidPrice returns the mid price from the feed after applying the rate offset
+func (p *staticSpreadLevelProvider) getMidPrice() (float64, error) {
 	midPrice, e := p.pf.GetFeedPairPrice()
 	if e != nil {
-		return nil, fmt.Errorf("mid price couldn't be loaded: %s", e)
+		return 0, fmt.Errorf("mid price couldn't be loaded: %s", e)
 	}
 	midPrice, wasModified := p.offset.apply(midPrice)
 	if wasModified {
 		log.Printf("mid price (adjusted): %.7f\n", midPrice)
 	}
+	return midPrice, nil
+}
+
+// makeLevels converts the static levels into levels around the mid price after applying the inventory skew, the passed in levels can differ
+// from the configured levels when a wrapping level provider adjusts them
+func (p *staticSpreadLevelProvider) makeLevels(midPrice float64, staticLevels []StaticLevel) ([]api.Level, error) {
+	amountFactor := 1.0
+	if p.skew != nil {
+		priceFactor, af, e := p.skew.factors(midPrice)
+		if e != nil {
+			return nil, fmt.Errorf("unable to compute inventory skew: %s", e)
+		}
+		midPrice = midPrice * priceFactor
+		amountFactor = af
+	}
 
 	levels := []api.Level{}
-	for _, sl := range p.staticLevels {
+	for _, sl := range staticLevels {
+		amount := model.NumberFromFloat(sl.AMOUNT*p.amountOfBase*amountFactor, p.orderConstraints.VolumePrecision)
+		// the inventory skew can shrink the amounts of one side to 0, the side strategies cannot place offers without an amount
+		if amount.AsFloat() <= 0 {
+			log.Printf("dropping level with spread %.4f because the amount is 0 (amountFactor=%.4f)\n", sl.SPREAD, amountFactor)
+			continue
+		}
+
 		absoluteSpread := midPrice * sl.SPREAD
 		levels = append(levels, api.Level{
 			// we always add here because it is only used in the context of selling so we always charge a higher price to include a spread
 			Price:  *model.NumberFromFloat(midPrice+absoluteSpread, p.orderConstraints.PricePrecision),
-			Amount: *model.NumberFromFloat(sl.AMOUNT*p.amountOfBase, p.orderConstraints.VolumePrecision),
+			Amount: *amount,
 		})
 	}
 	return levels, nil
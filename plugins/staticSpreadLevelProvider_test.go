@@ -0,0 +1,64 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeInventorySkewFactors(t *testing.T) {
+	testCases := []struct {
+		inventory        float64
+		isBuySide        bool
+		wantPriceFactor  float64
+		wantAmountFactor float64
+	}{
+		{inventory: 0, isBuySide: false, wantPriceFactor: 1.0, wantAmountFactor: 1.0},
+		{inventory: 0, isBuySide: true, wantPriceFactor: 1.0, wantAmountFactor: 1.0},
+		// holding too much base lowers the center price, sells more and buys less
+		{inventory: 0.2, isBuySide: false, wantPriceFactor: 0.99, wantAmountFactor: 1.4},
+		{inventory: 0.2, isBuySide: true, wantPriceFactor: 1 / 0.99, wantAmountFactor: 0.6},
+		// holding too little base raises the center price, sells less and buys more
+		{inventory: -0.2, isBuySide: false, wantPriceFactor: 1.01, wantAmountFactor: 0.6},
+		{inventory: -0.2, isBuySide: true, wantPriceFactor: 1 / 1.01, wantAmountFactor: 1.4},
+		// amounts never go below 0, the levels are dropped instead (see TestStaticSpreadMakeLevels)
+		{inventory: -0.5, isBuySide: false, wantPriceFactor: 1.025, wantAmountFactor: 0.0},
+		{inventory: 0.6, isBuySide: true, wantPriceFactor: 1 / 0.97, wantAmountFactor: 0.0},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%.2f_%v", k.inventory, k.isBuySide), func(t *testing.T) {
+			priceFactor, amountFactor := computeInventorySkewFactors(k.inventory, 0.05, 2.0, k.isBuySide)
+			assert.InDelta(t, k.wantPriceFactor, priceFactor, 0.0000001)
+			assert.InDelta(t, k.wantAmountFactor, amountFactor, 0.0000001)
+		})
+	}
+}
+
+func TestStaticSpreadMakeLevels(t *testing.T) {
+	p := &staticSpreadLevelProvider{
+		amountOfBase:     10.0,
+		orderConstraints: model.MakeOrderConstraints(7, 7, 0.0),
+	}
+	staticLevels := []StaticLevel{
+		{SPREAD: 0.01, AMOUNT: 1.0},
+		// rounds to an amount of 0
+		{SPREAD: 0.02, AMOUNT: 0.000000001},
+		{SPREAD: 0.03, AMOUNT: 0.0},
+		{SPREAD: 0.04, AMOUNT: 2.0},
+	}
+
+	levels, e := p.makeLevels(1.0, staticLevels)
+	if !assert.NoError(t, e) {
+		return
+	}
+	if !assert.Equal(t, 2, len(levels)) {
+		return
+	}
+	assert.Equal(t, "1.0100000", levels[0].Price.AsString())
+	assert.Equal(t, "10.0000000", levels[0].Amount.AsString())
+	assert.Equal(t, "1.0400000", levels[1].Price.AsString())
+	assert.Equal(t, "20.0000000", levels[1].Amount.AsString())
+}
@@ -0,0 +1,37 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/kelpdb"
+)
+
+// loadTradesInRangeFromDB reads the trades of the market from the trades table in the range [start, end) ordered by time, and calls fn
+// with the time, price and base volume of each trade
+func loadTradesInRangeFromDB(db *sql.DB, marketID string, start time.Time, end time.Time, fn func(t time.Time, price float64, volume float64)) error {
+	if db == nil {
+		return fmt.Errorf("the provided db should be non-nil")
+	}
+
+	rows, e := db.Query(kelpdb.SqlQueryTradesForMarketInRange, marketID, start.UTC(), end.UTC())
+	if e != nil {
+		return fmt.Errorf("could not execute sql select query (%s) for marketId (%s): %s", kelpdb.SqlQueryTradesForMarketInRange, marketID, e)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t time.Time
+		var price, volume float64
+		e = rows.Scan(&t, &price, &volume)
+		if e != nil {
+			return fmt.Errorf("could not scan trade row: %s", e)
+		}
+		fn(t.UTC(), price, volume)
+	}
+	if e = rows.Err(); e != nil {
+		return fmt.Errorf("error while iterating over rows of trades: %s", e)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/stellar/kelp/api"
+)
+
+// volatility sources supported by the volatility estimator
+const (
+	volatilitySourceTrades = "trades"
+	volatilitySourceFeed   = "feed"
+)
+
+// minimum time between two samples of the mid price when estimating volatility from the price feed, this ensures that
+// both sides of a strategy can share the same estimator without double-counting samples in a single update cycle
+const volatilityMinSampleInterval = time.Second
+
+// priceSample is a price observed at a point in time
+type priceSample struct {
+	time  time.Time
+	price float64
+}
+
+// volatilityEstimator estimates the volatility of the price over a rolling window, either from the trades saved in the db for the market or
+// from samples of the mid price that are collected on every update cycle
+type volatilityEstimator struct {
+	source     string
+	window     time.Duration
+	minSamples int
+	db         *sql.DB
+	marketID   string
+	clock      api.Clock
+
+	// uninitialized
+	samples []priceSample
+}
+
+// makeVolatilityEstimator is a factory method
+func makeVolatilityEstimator(
+	source string,
+	window time.Duration,
+	minSamples int,
+	db *sql.DB,
+	marketID string,
+	clock api.Clock,
+) (*volatilityEstimator, error) {
+	if source != volatilitySourceTrades && source != volatilitySourceFeed {
+		return nil, fmt.Errorf("invalid volatility source '%s', needs to be one of '%s' or '%s'", source, volatilitySourceTrades, volatilitySourceFeed)
+	}
+	if source == volatilitySourceTrades && db == nil {
+		return nil, fmt.Errorf("volatility source '%s' needs the database to be enabled", volatilitySourceTrades)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("volatility window needs to be greater than 0, was %s", window)
+	}
+	if minSamples < 2 {
+		return nil, fmt.Errorf("min volatility samples needs to be at least 2, was %d", minSamples)
+	}
+
+	return &volatilityEstimator{
+		source:     source,
+		window:     window,
+		minSamples: minSamples,
+		db:         db,
+		marketID:   marketID,
+		clock:      clock,
+	}, nil
+}
+
+// variancePerSecond returns the variance of log returns per second over the window and true, or false if we do not have enough samples yet
+func (v *volatilityEstimator) variancePerSecond(midPrice float64) (float64, bool, error) {
+	samples, e := v.loadSamples(v.clock.Now(), midPrice)
+	if e != nil {
+		return 0, false, fmt.Errorf("could not load price samples: %s", e)
+	}
+
+	if len(samples) < v.minSamples {
+		log.Printf("volatility: only have %d price samples (need %d)\n", len(samples), v.minSamples)
+		return 0, false, nil
+	}
+	return computeVariancePerSecond(samples), true, nil
+}
+
+// estimate returns the standard deviation of log returns over the window and true, or false if we do not have enough samples yet
+func (v *volatilityEstimator) estimate(midPrice float64) (float64, bool, error) {
+	variance, ok, e := v.variancePerSecond(midPrice)
+	if e != nil || !ok {
+		return 0, ok, e
+	}
+	return math.Sqrt(variance * v.window.Seconds()), true, nil
+}
+
+// loadSamples returns the price samples within the window ending at now
+func (v *volatilityEstimator) loadSamples(now time.Time, midPrice float64) ([]priceSample, error) {
+	windowStart := now.Add(-v.window)
+
+	if v.source == volatilitySourceTrades {
+		samples := []priceSample{}
+		e := loadTradesInRangeFromDB(v.db, v.marketID, windowStart, now, func(t time.Time, price float64, volume float64) {
+			samples = append(samples, priceSample{time: t, price: price})
+		})
+		if e != nil {
+			return nil, fmt.Errorf("could not load trades from db: %s", e)
+		}
+		return samples, nil
+	}
+
+	if len(v.samples) == 0 || now.Sub(v.samples[len(v.samples)-1].time) >= volatilityMinSampleInterval {
+		v.samples = append(v.samples, priceSample{time: now, price: midPrice})
+	}
+	// drop samples that have fallen out of the window
+	i := 0
+	for i < len(v.samples) && v.samples[i].time.Before(windowStart) {
+		i++
+	}
+	v.samples = v.samples[i:]
+	return v.samples, nil
+}
+
+// computeVariancePerSecond computes the variance of log returns normalized to one second, samples need to be sorted by time
+func computeVariancePerSecond(samples []priceSample) float64 {
+	sumSquaredReturns := 0.0
+	elapsedSeconds := 0.0
+	for i := 1; i < len(samples); i++ {
+		prev := samples[i-1]
+		cur := samples[i]
+		if prev.price <= 0 || cur.price <= 0 {
+			continue
+		}
+		r := math.Log(cur.price / prev.price)
+		sumSquaredReturns += r * r
+		elapsedSeconds += cur.time.Sub(prev.time).Seconds()
+	}
+
+	if elapsedSeconds <= 0 {
+		return 0
+	}
+	return sumSquaredReturns / elapsedSeconds
+}
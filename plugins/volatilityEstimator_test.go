@@ -0,0 +1,111 @@
+package plugins
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeVariancePerSecond(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name         string
+		samples      []priceSample
+		wantVariance float64
+	}{
+		{
+			name:         "no samples",
+			samples:      []priceSample{},
+			wantVariance: 0,
+		}, {
+			name:         "single sample",
+			samples:      []priceSample{{time: start, price: 1.0}},
+			wantVariance: 0,
+		}, {
+			name: "flat price",
+			samples: []priceSample{
+				{time: start, price: 1.0},
+				{time: start.Add(10 * time.Second), price: 1.0},
+			},
+			wantVariance: 0,
+		}, {
+			name: "price moves up and down",
+			samples: []priceSample{
+				{time: start, price: 1.0},
+				{time: start.Add(5 * time.Second), price: math.Exp(0.01)},
+				{time: start.Add(10 * time.Second), price: 1.0},
+			},
+			wantVariance: (0.01*0.01 + 0.01*0.01) / 10,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			assert.InDelta(t, k.wantVariance, computeVariancePerSecond(k.samples), 0.000000001)
+		})
+	}
+}
+
+func TestVolatilityEstimatorFromFeed(t *testing.T) {
+	clock := MakeVirtualClock(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	estimator, e := makeVolatilityEstimator(volatilitySourceFeed, time.Minute, 3, nil, "market", clock)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	_, ok, e := estimator.estimate(1.0)
+	assert.NoError(t, e)
+	assert.False(t, ok)
+
+	clock.Advance(30 * time.Second)
+	_, ok, e = estimator.estimate(math.Exp(0.01))
+	assert.NoError(t, e)
+	assert.False(t, ok)
+
+	clock.Advance(30 * time.Second)
+	volatility, ok, e := estimator.estimate(1.0)
+	assert.NoError(t, e)
+	assert.True(t, ok)
+	// two returns of 0.01 over 60 seconds, scaled to the 60 second window
+	assert.InDelta(t, math.Sqrt(2*0.01*0.01), volatility, 0.0000001)
+
+	// the first two samples fall out of the window so we no longer have enough samples
+	clock.Advance(31 * time.Second)
+	_, ok, e = estimator.estimate(1.0)
+	assert.NoError(t, e)
+	assert.False(t, ok)
+}
+
+func TestVolatilityEstimatorLoadSamplesFromFeed(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := MakeVirtualClock(start)
+	v, e := makeVolatilityEstimator(volatilitySourceFeed, time.Minute, 2, nil, "market", clock)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// the second call in the same update cycle (buy and sell sides) should not add a sample
+	samples, e := v.loadSamples(clock.Now(), 1.0)
+	assert.NoError(t, e)
+	assert.Equal(t, 1, len(samples))
+	samples, e = v.loadSamples(clock.Now(), 1.0)
+	assert.NoError(t, e)
+	assert.Equal(t, 1, len(samples))
+
+	clock.Advance(30 * time.Second)
+	samples, e = v.loadSamples(clock.Now(), 1.1)
+	assert.NoError(t, e)
+	assert.Equal(t, 2, len(samples))
+
+	// the first sample falls out of the window
+	clock.Advance(45 * time.Second)
+	samples, e = v.loadSamples(clock.Now(), 1.2)
+	assert.NoError(t, e)
+	if !assert.Equal(t, 2, len(samples)) {
+		return
+	}
+	assert.Equal(t, 1.1, samples[0].price)
+	assert.Equal(t, 1.2, samples[1].price)
+}
@@ -0,0 +1,106 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/stellar/kelp/api"
+)
+
+// computeVolatilityMultiplier returns the ratio of the volatility to the reference volatility, bounded by minMultiplier and maxMultiplier
+func computeVolatilityMultiplier(volatility float64, referenceVolatility float64, minMultiplier float64, maxMultiplier float64) float64 {
+	multiplier := volatility / referenceVolatility
+	return math.Min(math.Max(multiplier, minMultiplier), maxMultiplier)
+}
+
+// adjustStaticLevels scales the spread of each level up and its amount down by the multiplier, so the book is wider and thinner when the
+// price is volatile and tighter and thicker when it is quiet. The spread is bounded by minSpread and maxSpread (0 disables maxSpread).
+func adjustStaticLevels(staticLevels []StaticLevel, multiplier float64, minSpread float64, maxSpread float64) []StaticLevel {
+	adjusted := []StaticLevel{}
+	for _, sl := range staticLevels {
+		spread := math.Max(sl.SPREAD*multiplier, minSpread)
+		if maxSpread > 0 {
+			spread = math.Min(spread, maxSpread)
+		}
+		adjusted = append(adjusted, StaticLevel{
+			SPREAD: spread,
+			AMOUNT: sl.AMOUNT / multiplier,
+		})
+	}
+	return adjusted
+}
+
+// volatilitySpreadLevelProvider adjusts the levels of a staticSpreadLevelProvider based on a rolling volatility estimate, the configured
+// levels are used as-is when the volatility is equal to the reference volatility or when there are not enough samples to estimate it
+type volatilitySpreadLevelProvider struct {
+	static              *staticSpreadLevelProvider
+	estimator           *volatilityEstimator
+	referenceVolatility float64
+	minMultiplier       float64
+	maxMultiplier       float64
+	minSpread           float64
+	maxSpread           float64
+}
+
+// ensure it implements the LevelProvider interface
+var _ api.LevelProvider = &volatilitySpreadLevelProvider{}
+
+// makeVolatilitySpreadLevelProvider is a factory method
+func makeVolatilitySpreadLevelProvider(
+	static *staticSpreadLevelProvider,
+	estimator *volatilityEstimator,
+	referenceVolatility float64,
+	minMultiplier float64,
+	maxMultiplier float64,
+	minSpread float64,
+	maxSpread float64,
+) (api.LevelProvider, error) {
+	if referenceVolatility <= 0 {
+		return nil, fmt.Errorf("referenceVolatility needs to be greater than 0, was %f", referenceVolatility)
+	}
+	if minMultiplier <= 0 || maxMultiplier < minMultiplier {
+		return nil, fmt.Errorf("minMultiplier (%f) needs to be greater than 0 and maxMultiplier (%f) needs to be greater than or equal to minMultiplier", minMultiplier, maxMultiplier)
+	}
+	if minSpread < 0 {
+		return nil, fmt.Errorf("minSpread cannot be negative, was %f", minSpread)
+	}
+	if maxSpread != 0 && maxSpread < minSpread {
+		return nil, fmt.Errorf("maxSpread (%f) needs to be 0 (disabled) or greater than or equal to minSpread (%f)", maxSpread, minSpread)
+	}
+
+	return &volatilitySpreadLevelProvider{
+		static:              static,
+		estimator:           estimator,
+		referenceVolatility: referenceVolatility,
+		minMultiplier:       minMultiplier,
+		maxMultiplier:       maxMultiplier,
+		minSpread:           minSpread,
+		maxSpread:           maxSpread,
+	}, nil
+}
+
+// GetLevels impl.
+func (p *volatilitySpreadLevelProvider) GetLevels(maxAssetBase float64, maxAssetQuote float64) ([]api.Level, error) {
+	midPrice, e := p.static.getMidPrice()
+	if e != nil {
+		return nil, e
+	}
+
+	volatility, ok, e := p.estimator.estimate(midPrice)
+	if e != nil {
+		return nil, fmt.Errorf("unable to estimate volatility: %s", e)
+	}
+	multiplier := 1.0
+	if ok {
+		multiplier = computeVolatilityMultiplier(volatility, p.referenceVolatility, p.minMultiplier, p.maxMultiplier)
+	}
+	log.Printf("volatility: volatility=%.8f, hasEstimate=%v, multiplier=%.4f\n", volatility, ok, multiplier)
+
+	return p.static.makeLevels(midPrice, adjustStaticLevels(p.static.staticLevels, multiplier, p.minSpread, p.maxSpread))
+}
+
+// GetFillHandlers impl
+func (p *volatilitySpreadLevelProvider) GetFillHandlers() ([]api.FillHandler, error) {
+	return p.static.GetFillHandlers()
+}
@@ -0,0 +1,74 @@
+package plugins
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeVolatilityMultiplier(t *testing.T) {
+	testCases := []struct {
+		volatility     float64
+		wantMultiplier float64
+	}{
+		{volatility: 0.01, wantMultiplier: 1.0},
+		{volatility: 0.015, wantMultiplier: 1.5},
+		{volatility: 0.1, wantMultiplier: 3.0},
+		{volatility: 0.0, wantMultiplier: 0.5},
+		{volatility: 0.007, wantMultiplier: 0.7},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%.4f", k.volatility), func(t *testing.T) {
+			assert.InDelta(t, k.wantMultiplier, computeVolatilityMultiplier(k.volatility, 0.01, 0.5, 3.0), 0.0000001)
+		})
+	}
+}
+
+func TestAdjustStaticLevels(t *testing.T) {
+	staticLevels := []StaticLevel{
+		{SPREAD: 0.001, AMOUNT: 100},
+		{SPREAD: 0.004, AMOUNT: 50},
+	}
+	testCases := []struct {
+		name       string
+		multiplier float64
+		minSpread  float64
+		maxSpread  float64
+		wantLevels []StaticLevel
+	}{
+		{
+			name:       "unchanged",
+			multiplier: 1.0,
+			wantLevels: staticLevels,
+		}, {
+			name:       "volatile market widens and thins",
+			multiplier: 2.0,
+			wantLevels: []StaticLevel{{SPREAD: 0.002, AMOUNT: 50}, {SPREAD: 0.008, AMOUNT: 25}},
+		}, {
+			name:       "quiet market tightens and thickens",
+			multiplier: 0.5,
+			wantLevels: []StaticLevel{{SPREAD: 0.0005, AMOUNT: 200}, {SPREAD: 0.002, AMOUNT: 100}},
+		}, {
+			name:       "spread bounded by floor and ceiling",
+			multiplier: 2.0,
+			minSpread:  0.003,
+			maxSpread:  0.005,
+			wantLevels: []StaticLevel{{SPREAD: 0.003, AMOUNT: 50}, {SPREAD: 0.005, AMOUNT: 25}},
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			levels := adjustStaticLevels(staticLevels, k.multiplier, k.minSpread, k.maxSpread)
+			if !assert.Equal(t, len(k.wantLevels), len(levels)) {
+				return
+			}
+			for i, l := range levels {
+				assert.InDelta(t, k.wantLevels[i].SPREAD, l.SPREAD, 0.0000001)
+				assert.InDelta(t, k.wantLevels[i].AMOUNT, l.AMOUNT, 0.0000001)
+			}
+		})
+	}
+}
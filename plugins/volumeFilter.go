@@ -10,6 +10,7 @@ import (
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/kelp/api"
 	"github.com/stellar/kelp/model"
 	"github.com/stellar/kelp/queries"
 	"github.com/stellar/kelp/support/postgresdb"
@@ -24,6 +25,9 @@ const (
 	volumeFilterModeIgnore volumeFilterMode = "ignore"
 )
 
+// volumeFilterMinWindow is the smallest rolling window supported by the volume filter
+const volumeFilterMinWindow = time.Minute
+
 // String is the Stringer method
 func (v volumeFilterMode) String() string {
 	return string(v)
@@ -46,6 +50,8 @@ type VolumeFilterConfig struct {
 	mode                     volumeFilterMode
 	additionalMarketIDs      []string // can be nil
 	optionalAccountIDs       []string // can be nil
+	// zero limits the volume of the current day in UTC, otherwise limits the volume of the rolling window ending now
+	window time.Duration
 }
 
 type limitParameters struct {
@@ -60,10 +66,12 @@ type volumeFilter struct {
 	baseAsset              hProtocol.Asset
 	quoteAsset             hProtocol.Asset
 	config                 *VolumeFilterConfig
-	dailyVolumeByDateQuery *queries.DailyVolumeByDate
+	dailyVolumeByDateQuery *queries.DailyVolumeByDate // nil when the config has a rolling window
+	volumeInWindowQuery    *queries.VolumeInWindow    // nil when the config does not have a rolling window
+	clock                  api.Clock
 }
 
-// makeFilterVolume makes a submit filter that limits orders placed based on the daily volume traded
+// makeFilterVolume makes a submit filter that limits orders placed based on the daily volume traded or the volume traded in a rolling window
 func makeFilterVolume(
 	configValue string,
 	exchangeName string,
@@ -72,6 +80,7 @@ func makeFilterVolume(
 	baseAsset hProtocol.Asset,
 	quoteAsset hProtocol.Asset,
 	db *sql.DB,
+	clock api.Clock,
 	config *VolumeFilterConfig,
 ) (SubmitFilter, error) {
 	// use assetDisplayFn to make baseAssetString and quoteAssetString because it is issuer independent for non-sdex exchanges keeping a consistent marketID
@@ -87,9 +96,18 @@ func makeFilterVolume(
 	marketID := MakeMarketID(exchangeName, baseAssetString, quoteAssetString)
 	// note that append(s, nil) is valid
 	marketIDs := utils.Dedupe(append([]string{marketID}, config.additionalMarketIDs...))
-	dailyVolumeByDateQuery, e := queries.MakeDailyVolumeByDateForMarketIdsAction(db, marketIDs, config.action, config.optionalAccountIDs)
-	if e != nil {
-		return nil, fmt.Errorf("could not make daily volume by date Query: %s", e)
+	var dailyVolumeByDateQuery *queries.DailyVolumeByDate
+	var volumeInWindowQuery *queries.VolumeInWindow
+	if config.window > 0 {
+		volumeInWindowQuery, e = queries.MakeVolumeInWindowForMarketIdsAction(db, marketIDs, config.action, config.optionalAccountIDs)
+		if e != nil {
+			return nil, fmt.Errorf("could not make volume in window Query: %s", e)
+		}
+	} else {
+		dailyVolumeByDateQuery, e = queries.MakeDailyVolumeByDateForMarketIdsAction(db, marketIDs, config.action, config.optionalAccountIDs)
+		if e != nil {
+			return nil, fmt.Errorf("could not make daily volume by date Query: %s", e)
+		}
 	}
 
 	e = config.Validate()
@@ -104,6 +122,8 @@ func makeFilterVolume(
 		quoteAsset:             quoteAsset,
 		config:                 config,
 		dailyVolumeByDateQuery: dailyVolumeByDateQuery,
+		volumeInWindowQuery:    volumeInWindowQuery,
+		clock:                  clock,
 	}, nil
 }
 
@@ -127,29 +147,43 @@ func (c *VolumeFilterConfig) Validate() error {
 		return fmt.Errorf("could not parse action: %s", e)
 	}
 
+	if c.window != 0 && c.window < volumeFilterMinWindow {
+		return fmt.Errorf("invalid window: window needs to be at least %s but was %s", volumeFilterMinWindow, c.window)
+	}
+
 	return nil
 }
 
 // String is the stringer method
 func (c *VolumeFilterConfig) String() string {
-	return fmt.Sprintf("VolumeFilterConfig[BaseAssetCapInBaseUnits=%s, BaseAssetCapInQuoteUnits=%s, mode=%s, action=%s, additionalMarketIDs=%v, optionalAccountIDs=%v]",
-		utils.CheckedFloatPtr(c.BaseAssetCapInBaseUnits), utils.CheckedFloatPtr(c.BaseAssetCapInQuoteUnits), c.mode, c.action, c.additionalMarketIDs, c.optionalAccountIDs)
+	return fmt.Sprintf("VolumeFilterConfig[BaseAssetCapInBaseUnits=%s, BaseAssetCapInQuoteUnits=%s, mode=%s, action=%s, additionalMarketIDs=%v, optionalAccountIDs=%v, window=%s]",
+		utils.CheckedFloatPtr(c.BaseAssetCapInBaseUnits), utils.CheckedFloatPtr(c.BaseAssetCapInQuoteUnits), c.mode, c.action, c.additionalMarketIDs, c.optionalAccountIDs, c.window)
 }
 
 func (f *volumeFilter) Apply(ops []txnbuild.Operation, sellingOffers []hProtocol.Offer, buyingOffers []hProtocol.Offer) ([]txnbuild.Operation, error) {
-	dateString := time.Now().UTC().Format(postgresdb.DateFormatString)
+	now := f.clock.Now().UTC()
+	var queryName string
+	var queryResult interface{}
+	var e error
 	// TODO for flipped marketIDs
-	queryResult, e := f.dailyVolumeByDateQuery.QueryRow(dateString)
+	if f.volumeInWindowQuery != nil {
+		queryName = fmt.Sprintf("volumeInWindow for the last %s (ending %s)", f.config.window, now.Format(postgresdb.TimestampFormatString))
+		queryResult, e = f.volumeInWindowQuery.QueryRow(now.Add(-f.config.window), now)
+	} else {
+		dateString := now.Format(postgresdb.DateFormatString)
+		queryName = fmt.Sprintf("dailyValuesByDate for today (%s)", dateString)
+		queryResult, e = f.dailyVolumeByDateQuery.QueryRow(dateString)
+	}
 	if e != nil {
-		return nil, fmt.Errorf("could not load dailyValuesByDate for today (%s): %s", dateString, e)
+		return nil, fmt.Errorf("could not load %s: %s", queryName, e)
 	}
 	dailyValuesBaseSold, ok := queryResult.(*queries.DailyVolume)
 	if !ok {
-		return nil, fmt.Errorf("incorrect type returned from DailyVolumeByDate query, expecting '*queries.DailyVolume' but was '%T'", queryResult)
+		return nil, fmt.Errorf("incorrect type returned from %s query, expecting '*queries.DailyVolume' but was '%T'", queryName, queryResult)
 	}
 
-	log.Printf("dailyValuesByDate for today (%s): baseSoldUnits = %.8f %s, quoteCostUnits = %.8f %s (%s)\n",
-		dateString, dailyValuesBaseSold.BaseVol, utils.Asset2String(f.baseAsset), dailyValuesBaseSold.QuoteVol, utils.Asset2String(f.quoteAsset), f.config)
+	log.Printf("%s: baseSoldUnits = %.8f %s, quoteCostUnits = %.8f %s (%s)\n",
+		queryName, dailyValuesBaseSold.BaseVol, utils.Asset2String(f.baseAsset), dailyValuesBaseSold.QuoteVol, utils.Asset2String(f.quoteAsset), f.config)
 
 	// daily on-the-books
 	dailyOTB := makeIntermediateVolumeFilterConfig(&dailyValuesBaseSold.BaseVol, &dailyValuesBaseSold.QuoteVol)
@@ -32,6 +32,7 @@ func makeWantVolumeFilter(config *VolumeFilterConfig, marketIDs []string, accoun
 		quoteAsset:             utils.NativeAsset,
 		config:                 config,
 		dailyVolumeByDateQuery: query,
+		clock:                  MakeRealClock(),
 	}
 }
 
@@ -140,6 +141,7 @@ func TestMakeFilterVolume(t *testing.T) {
 							utils.NativeAsset,
 							utils.NativeAsset,
 							&sql.DB{},
+							MakeRealClock(),
 							config,
 						)
 
@@ -168,6 +170,7 @@ func TestMakeFilterVolume_FailsOnEmptyConfig(t *testing.T) {
 		utils.NativeAsset,
 		utils.NativeAsset,
 		&sql.DB{},
+		MakeRealClock(),
 		configUnderTest,
 	)
 	if !assert.Error(t, e) {
@@ -0,0 +1,121 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/kelp/model"
+)
+
+// prefixes of the vwap modifiers on the exchange and sdex price feeds
+const (
+	vwapBuyPrefix  = "vwap-buy:"
+	vwapSellPrefix = "vwap-sell:"
+)
+
+// vwapOrderbookDepth is the number of levels fetched on each side of the orderbook when computing the vwap
+const vwapOrderbookDepth int32 = 100
+
+// vwapModifier computes the volume-weighted price of filling an amount against the orderbook, which unlike the top of the book cannot be
+// moved by dust orders. It is formatted as vwap-buy:<amount>[:quote] or vwap-sell:<amount>[:quote], where the amount is in units of the
+// base asset unless the ":quote" suffix is used. vwap-buy walks the asks and vwap-sell walks the bids.
+type vwapModifier struct {
+	isBuy         bool
+	amount        float64
+	isQuoteAmount bool
+}
+
+// String is the Stringer method
+func (m *vwapModifier) String() string {
+	prefix := vwapSellPrefix
+	if m.isBuy {
+		prefix = vwapBuyPrefix
+	}
+	unit := "base"
+	if m.isQuoteAmount {
+		unit = "quote"
+	}
+	return fmt.Sprintf("%s%f (%s)", prefix, m.amount, unit)
+}
+
+// isVwapModifier returns true if the modifier should be parsed as a vwapModifier
+func isVwapModifier(modifier string) bool {
+	return strings.HasPrefix(modifier, vwapBuyPrefix) || strings.HasPrefix(modifier, vwapSellPrefix)
+}
+
+// parseVwapModifier parses a vwap modifier
+func parseVwapModifier(modifier string) (*vwapModifier, error) {
+	var isBuy bool
+	var params string
+	if strings.HasPrefix(modifier, vwapBuyPrefix) {
+		isBuy = true
+		params = strings.TrimPrefix(modifier, vwapBuyPrefix)
+	} else if strings.HasPrefix(modifier, vwapSellPrefix) {
+		isBuy = false
+		params = strings.TrimPrefix(modifier, vwapSellPrefix)
+	} else {
+		return nil, fmt.Errorf("vwap modifier needs to start with '%s' or '%s': %s", vwapBuyPrefix, vwapSellPrefix, modifier)
+	}
+
+	parts := strings.Split(params, ":")
+	if len(parts) > 2 || (len(parts) == 2 && parts[1] != "quote") {
+		return nil, fmt.Errorf("invalid format of vwap modifier, needs to be formatted as vwap-buy:<amount>[:quote] or vwap-sell:<amount>[:quote]: %s", modifier)
+	}
+
+	amount, e := strconv.ParseFloat(parts[0], 64)
+	if e != nil {
+		return nil, fmt.Errorf("unable to parse amount ('%s') of vwap modifier: %s", parts[0], e)
+	}
+	if amount <= 0.0 {
+		return nil, fmt.Errorf("amount of vwap modifier needs to be greater than 0 but was %f", amount)
+	}
+
+	return &vwapModifier{
+		isBuy:         isBuy,
+		amount:        amount,
+		isQuoteAmount: len(parts) == 2,
+	}, nil
+}
+
+// computeVwap walks the levels of the orderbook until the amount is filled and returns the volume-weighted price
+func (m *vwapModifier) computeVwap(ob *model.OrderBook) (float64, error) {
+	side := "bids"
+	levels := ob.Bids()
+	if m.isBuy {
+		side = "asks"
+		levels = ob.Asks()
+	}
+
+	remaining := m.amount
+	filledBase := 0.0
+	filledQuote := 0.0
+	for _, level := range levels {
+		price := level.Price.AsFloat()
+		volume := level.Volume.AsFloat()
+		if price <= 0.0 || volume <= 0.0 {
+			continue
+		}
+
+		levelAmount := volume
+		if m.isQuoteAmount {
+			levelAmount = volume * price
+		}
+
+		if levelAmount >= remaining {
+			fillBase := remaining
+			if m.isQuoteAmount {
+				fillBase = remaining / price
+			}
+			filledBase += fillBase
+			filledQuote += fillBase * price
+			return filledQuote / filledBase, nil
+		}
+
+		filledBase += volume
+		filledQuote += volume * price
+		remaining -= levelAmount
+	}
+
+	return 0.0, fmt.Errorf("not enough depth in the %d %s of the orderbook to fill %s, remaining amount %f", len(levels), side, m, remaining)
+}
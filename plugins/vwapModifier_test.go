@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/model"
+)
+
+func TestVwapModifierComputeVwap(t *testing.T) {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USDT}
+	makeOrders := func(levels [][2]float64) []model.Order {
+		orders := []model.Order{}
+		for _, l := range levels {
+			orders = append(orders, model.Order{
+				Pair:   pair,
+				Price:  model.NumberFromFloat(l[0], 7),
+				Volume: model.NumberFromFloat(l[1], 7),
+			})
+		}
+		return orders
+	}
+	// a dust order at the top of each side
+	ob := model.MakeOrderBook(
+		pair,
+		makeOrders([][2]float64{{0.101, 1}, {0.11, 99}, {0.12, 100}}),
+		makeOrders([][2]float64{{0.099, 1}, {0.09, 99}, {0.08, 100}}),
+	)
+
+	testCases := []struct {
+		modifier  string
+		wantPrice float64
+		wantError bool
+	}{
+		{modifier: "vwap-buy:100", wantPrice: 0.10991},
+		{modifier: "vwap-sell:100", wantPrice: 0.09009},
+		{modifier: "vwap-buy:150", wantPrice: (0.101 + 0.11*99 + 0.12*50) / 150},
+		{modifier: "vwap-buy:10.991:quote", wantPrice: 0.10991},
+		{modifier: "vwap-sell:0.099:quote", wantPrice: 0.099},
+		{modifier: "vwap-buy:201", wantError: true},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.modifier, func(t *testing.T) {
+			m, e := parseVwapModifier(k.modifier)
+			if !assert.NoError(t, e) {
+				return
+			}
+
+			price, e := m.computeVwap(ob)
+			if k.wantError {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.InDelta(t, k.wantPrice, price, 0.0000001)
+		})
+	}
+}
+
+func TestParseVwapModifierInvalid(t *testing.T) {
+	for _, modifier := range []string{"vwap-buy:", "vwap-buy:-1", "vwap-sell:10:base", "vwap-sell:10:quote:1", "vwap:10"} {
+		t.Run(modifier, func(t *testing.T) {
+			_, e := parseVwapModifier(modifier)
+			assert.Error(t, e)
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/kelp/model"
+)
+
+// krakenWsBookDepth is the depth of the orderbook subscription on the kraken websocket
+const krakenWsBookDepth = 100
+
+// krakenWsChecksumDepth is the number of levels on each side of the orderbook that are included in the checksum of a book update
+const krakenWsChecksumDepth = 10
+
+// krakenWsAssetCodes are the asset codes that are different on the kraken websocket
+var krakenWsAssetCodes = map[string]string{
+	"BTC":  "XBT",
+	"DOGE": "XDG",
+}
+
+// krakenWsProtocol streams the book and ticker channels of the kraken websocket API
+type krakenWsProtocol struct{}
+
+var _ wsProtocol = &krakenWsProtocol{}
+
+func (p *krakenWsProtocol) url() string {
+	return "wss://ws.kraken.com"
+}
+
+func (p *krakenWsProtocol) toSymbol(pair *model.TradingPair) (string, error) {
+	codes := []string{}
+	for _, asset := range []model.Asset{pair.Base, pair.Quote} {
+		code, e := model.CcxtAssetConverter.ToString(asset)
+		if e != nil {
+			return "", e
+		}
+		if krakenCode, ok := krakenWsAssetCodes[code]; ok {
+			code = krakenCode
+		}
+		codes = append(codes, code)
+	}
+	return strings.Join(codes, "/"), nil
+}
+
+func (p *krakenWsProtocol) subscribeMessages(symbol string) []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"event":        "subscribe",
+			"pair":         []string{symbol},
+			"subscription": map[string]interface{}{"name": "book", "depth": krakenWsBookDepth},
+		},
+		map[string]interface{}{
+			"event":        "subscribe",
+			"pair":         []string{symbol},
+			"subscription": map[string]interface{}{"name": "ticker"},
+		},
+	}
+}
+
+// handleMessage handles the events (json objects) and channel messages (json arrays formatted as [channelID, payload..., channelName, pair])
+func (p *krakenWsProtocol) handleMessage(message []byte, state *wsMarketState) error {
+	if strings.HasPrefix(strings.TrimSpace(string(message)), "{") {
+		var event struct {
+			Event        string `json:"event"`
+			Status       string `json:"status"`
+			ErrorMessage string `json:"errorMessage"`
+		}
+		e := json.Unmarshal(message, &event)
+		if e != nil {
+			return fmt.Errorf("could not unmarshal event: %s", e)
+		}
+		if event.Event == "subscriptionStatus" && event.Status == "error" {
+			return fmt.Errorf("subscription failed: %s", event.ErrorMessage)
+		}
+		// heartbeats, system status, and successful subscriptions
+		return nil
+	}
+
+	var parts []json.RawMessage
+	e := json.Unmarshal(message, &parts)
+	if e != nil {
+		return fmt.Errorf("could not unmarshal channel message: %s", e)
+	}
+	if len(parts) < 4 {
+		return fmt.Errorf("channel message needs at least 4 parts but had %d", len(parts))
+	}
+	var channelName string
+	e = json.Unmarshal(parts[len(parts)-2], &channelName)
+	if e != nil {
+		return fmt.Errorf("could not unmarshal channel name: %s", e)
+	}
+	payloads := parts[1 : len(parts)-2]
+
+	if channelName == "ticker" {
+		var ticker struct {
+			Close []string `json:"c"`
+		}
+		e = json.Unmarshal(payloads[0], &ticker)
+		if e != nil {
+			return fmt.Errorf("could not unmarshal ticker: %s", e)
+		}
+		if len(ticker.Close) == 0 {
+			return fmt.Errorf("ticker is missing the last trade price")
+		}
+		return state.setLastPrice(ticker.Close[0])
+	}
+
+	if !strings.HasPrefix(channelName, "book") {
+		return nil
+	}
+
+	// book updates for the bids and asks can be sent in the same payload or in separate payloads of the same message, the checksum is
+	// sent in the last payload of an update
+	checksum := ""
+	for _, payload := range payloads {
+		var book map[string]json.RawMessage
+		e = json.Unmarshal(payload, &book)
+		if e != nil {
+			return fmt.Errorf("could not unmarshal book: %s", e)
+		}
+
+		if asRaw, ok := book["as"]; ok {
+			asks, e := krakenWsLevels(asRaw)
+			if e != nil {
+				return fmt.Errorf("could not read asks of snapshot: %s", e)
+			}
+			bids, e := krakenWsLevels(book["bs"])
+			if e != nil {
+				return fmt.Errorf("could not read bids of snapshot: %s", e)
+			}
+			e = state.applySnapshot(bids, asks)
+			if e != nil {
+				return e
+			}
+			continue
+		}
+
+		for key, isBid := range map[string]bool{"a": false, "b": true} {
+			levelsRaw, ok := book[key]
+			if !ok {
+				continue
+			}
+			levels, e := krakenWsLevels(levelsRaw)
+			if e != nil {
+				return fmt.Errorf("could not read levels of update: %s", e)
+			}
+			e = state.applyUpdates(isBid, levels)
+			if e != nil {
+				return e
+			}
+		}
+
+		if checksumRaw, ok := book["c"]; ok {
+			e = json.Unmarshal(checksumRaw, &checksum)
+			if e != nil {
+				return fmt.Errorf("could not unmarshal checksum: %s", e)
+			}
+		}
+	}
+	state.truncate(krakenWsBookDepth)
+
+	if checksum == "" {
+		return nil
+	}
+	// a mismatch means we missed or misapplied an update, returning the error reconnects and resubscribes to get a new snapshot
+	computed := krakenWsChecksum(state.topLevels(false, krakenWsChecksumDepth), state.topLevels(true, krakenWsChecksumDepth))
+	if computed != checksum {
+		return fmt.Errorf("orderbook checksum mismatch, expected %s but computed %s", checksum, computed)
+	}
+	return nil
+}
+
+// krakenWsChecksum is the CRC32 of the top asks (lowest first) followed by the top bids (highest first), where each level is written as
+// its price and volume without the decimal point and leading zeros
+func krakenWsChecksum(asks []wsLevel, bids []wsLevel) string {
+	var sb strings.Builder
+	for _, l := range append(asks, bids...) {
+		for _, v := range []string{l.price, l.volume} {
+			sb.WriteString(strings.TrimLeft(strings.Replace(v, ".", "", 1), "0"))
+		}
+	}
+	return strconv.FormatUint(uint64(crc32.ChecksumIEEE([]byte(sb.String()))), 10)
+}
+
+// krakenWsLevels reads levels formatted as [price, volume, timestamp(, updateType)]
+func krakenWsLevels(raw json.RawMessage) ([]wsLevel, error) {
+	var rawLevels [][]interface{}
+	e := json.Unmarshal(raw, &rawLevels)
+	if e != nil {
+		return nil, fmt.Errorf("could not unmarshal levels: %s", e)
+	}
+
+	levels := []wsLevel{}
+	for _, l := range rawLevels {
+		if len(l) < 2 {
+			return nil, fmt.Errorf("level needs at least 2 elements but had %d", len(l))
+		}
+		price, ok := l[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("price of level was not a string: %v", l[0])
+		}
+		volume, ok := l[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("volume of level was not a string: %v", l[1])
+		}
+		levels = append(levels, wsLevel{price: price, volume: volume})
+	}
+	return levels, nil
+}
+
+// coinbaseproWsProtocol streams the level2_batch, ticker, and heartbeat channels of the coinbase exchange websocket API, the level2_batch
+// channel sends the same messages as the level2 channel batched every 50ms and does not need an authenticated connection
+type coinbaseproWsProtocol struct{}
+
+var _ wsProtocol = &coinbaseproWsProtocol{}
+
+func (p *coinbaseproWsProtocol) url() string {
+	return "wss://ws-feed.exchange.coinbase.com"
+}
+
+func (p *coinbaseproWsProtocol) toSymbol(pair *model.TradingPair) (string, error) {
+	return pair.ToString(model.CcxtAssetConverter, "-")
+}
+
+func (p *coinbaseproWsProtocol) subscribeMessages(symbol string) []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"type":        "subscribe",
+			"product_ids": []string{symbol},
+			"channels":    []string{"level2_batch", "ticker", "heartbeat"},
+		},
+	}
+}
+
+func (p *coinbaseproWsProtocol) handleMessage(message []byte, state *wsMarketState) error {
+	var m struct {
+		Type    string     `json:"type"`
+		Bids    [][]string `json:"bids"`
+		Asks    [][]string `json:"asks"`
+		Changes [][]string `json:"changes"`
+		Price   string     `json:"price"`
+		Message string     `json:"message"`
+		Reason  string     `json:"reason"`
+	}
+	e := json.Unmarshal(message, &m)
+	if e != nil {
+		return fmt.Errorf("could not unmarshal message: %s", e)
+	}
+
+	switch m.Type {
+	case "snapshot":
+		bids, e := coinbaseproWsLevels(m.Bids)
+		if e != nil {
+			return fmt.Errorf("could not read bids of snapshot: %s", e)
+		}
+		asks, e := coinbaseproWsLevels(m.Asks)
+		if e != nil {
+			return fmt.Errorf("could not read asks of snapshot: %s", e)
+		}
+		return state.applySnapshot(bids, asks)
+	case "l2update":
+		// changes are formatted as [side, price, size] where side is "buy" or "sell"
+		for _, c := range m.Changes {
+			if len(c) != 3 || (c[0] != "buy" && c[0] != "sell") {
+				return fmt.Errorf("invalid change: %v", c)
+			}
+			e = state.applyUpdates(c[0] == "buy", []wsLevel{{price: c[1], volume: c[2]}})
+			if e != nil {
+				return e
+			}
+		}
+		return nil
+	case "ticker":
+		return state.setLastPrice(m.Price)
+	case "error":
+		return fmt.Errorf("received error: %s (%s)", m.Message, m.Reason)
+	}
+	// subscriptions and heartbeats
+	return nil
+}
+
+// coinbaseproWsLevels reads levels formatted as [price, size]
+func coinbaseproWsLevels(raw [][]string) ([]wsLevel, error) {
+	levels := []wsLevel{}
+	for _, l := range raw {
+		if len(l) < 2 {
+			return nil, fmt.Errorf("level needs at least 2 elements but had %d", len(l))
+		}
+		levels = append(levels, wsLevel{price: l[0], volume: l[1]})
+	}
+	return levels, nil
+}
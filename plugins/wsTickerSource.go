@@ -0,0 +1,532 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+)
+
+// wsExchangePrefix is the prefix of the exchange name on exchange feeds that stream the ticker and orderbook over a websocket,
+// i.e. ws-kraken/XLM/USD/mid
+const wsExchangePrefix = "ws-"
+
+// wsStaleAfter is how long the websocket can go without receiving a message before it is considered unhealthy, the exchanges send a
+// heartbeat every second so this is only reached when the connection is broken
+const wsStaleAfter = 10 * time.Second
+
+// reconnect delays for the websocket, the delay doubles after every failed connection attempt
+const (
+	wsMinReconnectDelay = 1 * time.Second
+	wsMaxReconnectDelay = 1 * time.Minute
+)
+
+// wsProtocols are the exchanges that support streaming the ticker and orderbook over a websocket
+var wsProtocols = map[string]wsProtocol{
+	"kraken":      &krakenWsProtocol{},
+	"coinbasepro": &coinbaseproWsProtocol{},
+}
+
+// wsProtocol knows how to subscribe to the orderbook and ticker of an exchange over a websocket and how to read its messages
+type wsProtocol interface {
+	// url is the websocket endpoint of the exchange
+	url() string
+	// toSymbol converts the trading pair to the symbol used by the exchange on the websocket
+	toSymbol(pair *model.TradingPair) (string, error)
+	// subscribeMessages are sent as JSON after connecting
+	subscribeMessages(symbol string) []interface{}
+	// handleMessage applies a message received on the websocket to the market state, returning an error means the state cannot be
+	// trusted anymore and we need to reconnect to get a new snapshot
+	handleMessage(message []byte, state *wsMarketState) error
+}
+
+// wsLevel is a price level as received on the websocket
+type wsLevel struct {
+	price  string
+	volume string
+}
+
+// wsMarketState is the local orderbook and last trade price of a market that is kept current by the websocket
+type wsMarketState struct {
+	mutex           *sync.Mutex
+	bids            map[float64]float64
+	asks            map[float64]float64
+	pricePrecision  int8
+	volumePrecision int8
+	lastPrice       float64
+	lastPriceStr    string
+	hasSnapshot     bool
+	lastMessageTime time.Time
+}
+
+func makeWsMarketState() *wsMarketState {
+	return &wsMarketState{
+		mutex: &sync.Mutex{},
+		bids:  map[float64]float64{},
+		asks:  map[float64]float64{},
+	}
+}
+
+// reset clears the state, used when the websocket is disconnected
+func (s *wsMarketState) reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.bids = map[float64]float64{}
+	s.asks = map[float64]float64{}
+	s.lastPrice = 0
+	s.lastPriceStr = ""
+	s.hasSnapshot = false
+}
+
+// touch records that a message was received
+func (s *wsMarketState) touch(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastMessageTime = now
+}
+
+// applySnapshot replaces the orderbook
+func (s *wsMarketState) applySnapshot(bids []wsLevel, asks []wsLevel) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.bids = map[float64]float64{}
+	s.asks = map[float64]float64{}
+	e := s.applyLevels(s.bids, bids)
+	if e != nil {
+		return fmt.Errorf("could not apply bids of snapshot: %s", e)
+	}
+	e = s.applyLevels(s.asks, asks)
+	if e != nil {
+		return fmt.Errorf("could not apply asks of snapshot: %s", e)
+	}
+	s.hasSnapshot = true
+	return nil
+}
+
+// applyUpdates updates the levels on one side of the orderbook, a volume of 0 removes the level
+func (s *wsMarketState) applyUpdates(isBid bool, levels []wsLevel) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.hasSnapshot {
+		return fmt.Errorf("received an orderbook update before the snapshot")
+	}
+
+	side := s.asks
+	if isBid {
+		side = s.bids
+	}
+	return s.applyLevels(side, levels)
+}
+
+// truncate drops the levels beyond the depth on both sides, exchanges that send a limited depth expect the client to do this
+func (s *wsMarketState) truncate(depth int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	truncateSide(s.bids, depth, true)
+	truncateSide(s.asks, depth, false)
+}
+
+// setLastPrice sets the price of the last trade
+func (s *wsMarketState) setLastPrice(price string) error {
+	p, e := strconv.ParseFloat(price, 64)
+	if e != nil {
+		return fmt.Errorf("could not parse last price '%s': %s", price, e)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastPrice = p
+	s.lastPriceStr = price
+	return nil
+}
+
+// applyLevels should be called while holding the mutex
+func (s *wsMarketState) applyLevels(side map[float64]float64, levels []wsLevel) error {
+	for _, l := range levels {
+		price, e := strconv.ParseFloat(l.price, 64)
+		if e != nil {
+			return fmt.Errorf("could not parse price '%s': %s", l.price, e)
+		}
+		volume, e := strconv.ParseFloat(l.volume, 64)
+		if e != nil {
+			return fmt.Errorf("could not parse volume '%s': %s", l.volume, e)
+		}
+
+		if volume == 0 {
+			delete(side, price)
+			continue
+		}
+		side[price] = volume
+		s.pricePrecision = maxPrecision(s.pricePrecision, l.price)
+		s.volumePrecision = maxPrecision(s.volumePrecision, l.volume)
+	}
+	return nil
+}
+
+func maxPrecision(current int8, floatStr string) int8 {
+	if !strings.Contains(floatStr, ".") {
+		return current
+	}
+	p := getPrecision(floatStr)
+	if p > current {
+		return p
+	}
+	return current
+}
+
+// sortedPrices returns the prices of the side with the best price first
+func sortedPrices(side map[float64]float64, isBid bool) []float64 {
+	prices := []float64{}
+	for p := range side {
+		prices = append(prices, p)
+	}
+	if isBid {
+		sort.Sort(sort.Reverse(sort.Float64Slice(prices)))
+	} else {
+		sort.Float64s(prices)
+	}
+	return prices
+}
+
+func truncateSide(side map[float64]float64, depth int, isBid bool) {
+	prices := sortedPrices(side, isBid)
+	if len(prices) <= depth {
+		return
+	}
+	for _, p := range prices[depth:] {
+		delete(side, p)
+	}
+}
+
+// isHealthy returns an error describing why the state cannot be used
+func (s *wsMarketState) isHealthy(now time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.hasSnapshot {
+		return fmt.Errorf("no orderbook snapshot received")
+	}
+	if age := now.Sub(s.lastMessageTime); age > wsStaleAfter {
+		return fmt.Errorf("last message was received %s ago", age)
+	}
+	if len(s.bids) == 0 || len(s.asks) == 0 {
+		return fmt.Errorf("orderbook has %d bids and %d asks", len(s.bids), len(s.asks))
+	}
+	return nil
+}
+
+// ticker returns the ticker from the top of the local orderbook and the last trade price. The last trade price is only required when
+// requireLastPrice is set, otherwise LastPrice is nil until a trade is received, which can take a long time on illiquid pairs
+func (s *wsMarketState) ticker(requireLastPrice bool) (*api.Ticker, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if requireLastPrice && s.lastPrice <= 0 {
+		return nil, fmt.Errorf("no last trade price received")
+	}
+	if len(s.bids) == 0 || len(s.asks) == 0 {
+		return nil, fmt.Errorf("orderbook has %d bids and %d asks", len(s.bids), len(s.asks))
+	}
+	bid := sortedPrices(s.bids, true)[0]
+	ask := sortedPrices(s.asks, false)[0]
+	if bid >= ask {
+		return nil, fmt.Errorf("orderbook is crossed (bid=%f, ask=%f)", bid, ask)
+	}
+
+	var lastPrice *model.Number
+	if s.lastPrice > 0 {
+		lastPrice = model.NumberFromFloat(s.lastPrice, maxPrecision(s.pricePrecision, s.lastPriceStr))
+	}
+	return &api.Ticker{
+		AskPrice:  model.NumberFromFloat(ask, s.pricePrecision),
+		BidPrice:  model.NumberFromFloat(bid, s.pricePrecision),
+		LastPrice: lastPrice,
+	}, nil
+}
+
+// topLevels returns up to depth levels of one side of the local orderbook with the best price first, formatted using the precision of the
+// levels received on the websocket
+func (s *wsMarketState) topLevels(isBid bool, depth int) []wsLevel {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	side := s.asks
+	if isBid {
+		side = s.bids
+	}
+	levels := []wsLevel{}
+	for _, p := range sortedPrices(side, isBid) {
+		if len(levels) >= depth {
+			break
+		}
+		levels = append(levels, wsLevel{
+			price:  strconv.FormatFloat(p, 'f', int(s.pricePrecision), 64),
+			volume: strconv.FormatFloat(side[p], 'f', int(s.volumePrecision), 64),
+		})
+	}
+	return levels
+}
+
+// orderbook returns up to maxCount levels of each side of the local orderbook
+func (s *wsMarketState) orderbook(pair *model.TradingPair, maxCount int32) *model.OrderBook {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return model.MakeOrderBook(
+		pair,
+		s.readOrders(s.asks, false, pair, maxCount),
+		s.readOrders(s.bids, true, pair, maxCount),
+	)
+}
+
+// readOrders should be called while holding the mutex
+func (s *wsMarketState) readOrders(side map[float64]float64, isBid bool, pair *model.TradingPair, maxCount int32) []model.Order {
+	orderAction := model.OrderActionSell
+	if isBid {
+		orderAction = model.OrderActionBuy
+	}
+
+	orders := []model.Order{}
+	for _, p := range sortedPrices(side, isBid) {
+		if len(orders) >= int(maxCount) {
+			break
+		}
+		orders = append(orders, model.Order{
+			Pair:        pair,
+			OrderAction: orderAction,
+			OrderType:   model.OrderTypeLimit,
+			Price:       model.NumberFromFloat(p, s.pricePrecision),
+			Volume:      model.NumberFromFloat(side[p], s.volumePrecision),
+			Timestamp:   nil,
+		})
+	}
+	return orders
+}
+
+// wsTickerSource serves the ticker and orderbook of a single trading pair from a websocket, and falls back to polling the REST API of
+// the exchange when the websocket is unhealthy
+type wsTickerSource struct {
+	name          string
+	protocol      wsProtocol
+	pair          *model.TradingPair
+	symbol        string
+	state         *wsMarketState
+	restTicker    api.TickerAPI
+	restOrderbook api.OrderbookFetcher
+	clock         api.Clock
+
+	// usingREST is used to log when we switch between the websocket and the REST API
+	usingREST bool
+	modeMutex *sync.Mutex
+}
+
+// ensure that it implements TickerAPI and OrderbookFetcher
+var _ api.TickerAPI = &wsTickerSource{}
+var _ api.OrderbookFetcher = &wsTickerSource{}
+
+func makeWsTickerSource(
+	name string,
+	protocol wsProtocol,
+	pair *model.TradingPair,
+	restTicker api.TickerAPI,
+	restOrderbook api.OrderbookFetcher,
+	clock api.Clock,
+) (*wsTickerSource, error) {
+	symbol, e := protocol.toSymbol(pair)
+	if e != nil {
+		return nil, fmt.Errorf("could not convert pair %s to a websocket symbol: %s", pair, e)
+	}
+
+	return &wsTickerSource{
+		name:          name,
+		protocol:      protocol,
+		pair:          pair,
+		symbol:        symbol,
+		state:         makeWsMarketState(),
+		restTicker:    restTicker,
+		restOrderbook: restOrderbook,
+		clock:         clock,
+		usingREST:     true,
+		modeMutex:     &sync.Mutex{},
+	}, nil
+}
+
+// wsTickerSources are the websocket sources that have been started, keyed by exchange and symbol so feeds with different modifiers
+// on the same pair share a single websocket connection
+var wsTickerSources = map[string]*wsTickerSource{}
+var wsTickerSourcesMutex = &sync.Mutex{}
+
+// isWsExchange returns true if the exchange name on an exchange feed should be streamed over a websocket
+func isWsExchange(exchangeName string) bool {
+	return strings.HasPrefix(exchangeName, wsExchangePrefix)
+}
+
+// wsRestExchangeName returns the name of the exchange that is used for the REST fallback of the websocket exchange
+func wsRestExchangeName(exchangeName string) (string, error) {
+	name := strings.TrimPrefix(exchangeName, wsExchangePrefix)
+	if _, ok := wsProtocols[name]; !ok {
+		supported := []string{}
+		for k := range wsProtocols {
+			supported = append(supported, wsExchangePrefix+k)
+		}
+		sort.Strings(supported)
+		return "", fmt.Errorf("websocket feeds are not supported for exchange '%s', supported exchanges are %v", exchangeName, supported)
+	}
+	return "ccxt-" + name, nil
+}
+
+// getOrStartWsTickerSource returns the running websocket source for the exchange and pair, starting it if needed
+func getOrStartWsTickerSource(exchangeName string, pair *model.TradingPair, restExchange api.Exchange) (*wsTickerSource, error) {
+	protocol, ok := wsProtocols[strings.TrimPrefix(exchangeName, wsExchangePrefix)]
+	if !ok {
+		return nil, fmt.Errorf("websocket feeds are not supported for exchange '%s'", exchangeName)
+	}
+
+	wsTickerSourcesMutex.Lock()
+	defer wsTickerSourcesMutex.Unlock()
+
+	key := fmt.Sprintf("%s/%s", exchangeName, pair)
+	if s, ok := wsTickerSources[key]; ok {
+		return s, nil
+	}
+
+	s, e := makeWsTickerSource(exchangeName, protocol, pair, restExchange, restExchange, MakeRealClock())
+	if e != nil {
+		return nil, e
+	}
+	go s.run()
+	wsTickerSources[key] = s
+	return s, nil
+}
+
+// run keeps the websocket connected, it never returns
+func (s *wsTickerSource) run() {
+	delay := wsMinReconnectDelay
+	for {
+		e := s.connectAndRead()
+		hadSnapshot := s.state.isHealthy(s.clock.Now()) == nil
+		s.state.reset()
+		if hadSnapshot {
+			delay = wsMinReconnectDelay
+		}
+		log.Printf("websocket feed (%s, %s): disconnected, reconnecting in %s: %s\n", s.name, s.symbol, delay, e)
+		recordPriceFeedEvent(fmt.Sprintf("ws_disconnected_%s", s.name))
+
+		time.Sleep(delay)
+		delay *= 2
+		if delay > wsMaxReconnectDelay {
+			delay = wsMaxReconnectDelay
+		}
+	}
+}
+
+// connectAndRead connects to the websocket and applies messages to the state until there is an error
+func (s *wsTickerSource) connectAndRead() error {
+	conn, _, e := websocket.DefaultDialer.Dial(s.protocol.url(), nil)
+	if e != nil {
+		return fmt.Errorf("could not connect to '%s': %s", s.protocol.url(), e)
+	}
+	defer conn.Close()
+
+	for _, m := range s.protocol.subscribeMessages(s.symbol) {
+		e = conn.WriteJSON(m)
+		if e != nil {
+			return fmt.Errorf("could not send subscribe message: %s", e)
+		}
+	}
+
+	for {
+		e = conn.SetReadDeadline(time.Now().Add(wsStaleAfter))
+		if e != nil {
+			return fmt.Errorf("could not set read deadline: %s", e)
+		}
+		_, message, e := conn.ReadMessage()
+		if e != nil {
+			return fmt.Errorf("error reading message: %s", e)
+		}
+
+		e = s.protocol.handleMessage(message, s.state)
+		if e != nil {
+			return fmt.Errorf("error handling message '%s': %s", string(message), e)
+		}
+		s.state.touch(s.clock.Now())
+	}
+}
+
+// checkWs returns true if the websocket can be used, and logs when we switch between the websocket and the REST API
+func (s *wsTickerSource) checkWs() bool {
+	healthError := s.state.isHealthy(s.clock.Now())
+
+	s.modeMutex.Lock()
+	defer s.modeMutex.Unlock()
+
+	if healthError != nil {
+		if !s.usingREST {
+			log.Printf("websocket feed (%s, %s): websocket is unhealthy, falling back to REST: %s\n", s.name, s.symbol, healthError)
+		}
+		s.usingREST = true
+		recordPriceFeedEvent(fmt.Sprintf("ws_rest_fallback_%s", s.name))
+		return false
+	}
+
+	if s.usingREST {
+		log.Printf("websocket feed (%s, %s): websocket is healthy, using websocket\n", s.name, s.symbol)
+	}
+	s.usingREST = false
+	return true
+}
+
+// GetTickerPrice impl.
+func (s *wsTickerSource) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	return s.getTickerPrice(pairs, true)
+}
+
+// getTickerPrice returns the ticker from the websocket, falling back to REST when the websocket cannot be used
+func (s *wsTickerSource) getTickerPrice(pairs []model.TradingPair, requireLastPrice bool) (map[model.TradingPair]api.Ticker, error) {
+	if len(pairs) != 1 || pairs[0] != *s.pair || !s.checkWs() {
+		return s.restTicker.GetTickerPrice(pairs)
+	}
+
+	ticker, e := s.state.ticker(requireLastPrice)
+	if e != nil {
+		log.Printf("websocket feed (%s, %s): could not get ticker from websocket, using REST: %s\n", s.name, s.symbol, e)
+		recordPriceFeedEvent(fmt.Sprintf("ws_rest_fallback_%s", s.name))
+		return s.restTicker.GetTickerPrice(pairs)
+	}
+	return map[model.TradingPair]api.Ticker{*s.pair: *ticker}, nil
+}
+
+// wsBookTicker is the TickerAPI of a websocket source for feeds that do not use the last trade price, so the ticker is built from the local
+// orderbook without waiting for a trade and LastPrice is nil until one is received
+type wsBookTicker struct {
+	source *wsTickerSource
+}
+
+// ensure that it implements TickerAPI
+var _ api.TickerAPI = &wsBookTicker{}
+
+// GetTickerPrice impl.
+func (t *wsBookTicker) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	return t.source.getTickerPrice(pairs, false)
+}
+
+// GetOrderBook impl
+func (s *wsTickerSource) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	if *pair != *s.pair || !s.checkWs() {
+		return s.restOrderbook.GetOrderBook(pair, maxCount)
+	}
+	return s.state.orderbook(pair, maxCount), nil
+}
@@ -0,0 +1,248 @@
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// GetTickerPrice lets the fakePriceFeed stand in for the REST ticker of an exchange, all the ticker prices are set to the price
+func (f *fakePriceFeed) GetTickerPrice(pairs []model.TradingPair) (map[model.TradingPair]api.Ticker, error) {
+	price, e := f.GetPrice()
+	if e != nil {
+		return nil, e
+	}
+
+	m := map[model.TradingPair]api.Ticker{}
+	for _, p := range pairs {
+		m[p] = api.Ticker{
+			AskPrice:  model.NumberFromFloat(price, 7),
+			BidPrice:  model.NumberFromFloat(price, 7),
+			LastPrice: model.NumberFromFloat(price, 7),
+		}
+	}
+	return m, nil
+}
+
+// GetOrderBook lets the fakePriceFeed stand in for the REST orderbook of an exchange, the orderbook is always empty
+func (f *fakePriceFeed) GetOrderBook(pair *model.TradingPair, maxCount int32) (*model.OrderBook, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return model.MakeOrderBook(pair, []model.Order{}, []model.Order{}), nil
+}
+
+func TestKrakenWsProtocol(t *testing.T) {
+	p := &krakenWsProtocol{}
+	symbol, e := p.toSymbol(&model.TradingPair{Base: model.BTC, Quote: model.USD})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "XBT/USD", symbol)
+
+	state := makeWsMarketState()
+	messages := []string{
+		`{"event":"systemStatus","status":"online","version":"1.0.0"}`,
+		`{"event":"subscriptionStatus","status":"subscribed","pair":"XLM/USD","subscription":{"name":"book","depth":100}}`,
+		`[336,{"as":[["0.1010","100.0","1.1"],["0.1020","200.0","1.1"]],"bs":[["0.1000","150.0","1.1"],["0.0990","50.0","1.1"]]},"book-100","XLM/USD"]`,
+		`[336,{"a":[["0.1010","0.00000000","1.2"],["0.1015","20.0","1.2"]]},{"b":[["0.1005","10.0","1.2","r"]]},"book-100","XLM/USD"]`,
+		`[340,{"a":["0.1015",1,"1.0"],"b":["0.1005",1,"1.0"],"c":["0.1012","5.0"]},"ticker","XLM/USD"]`,
+		`{"event":"heartbeat"}`,
+	}
+	for _, m := range messages {
+		e = p.handleMessage([]byte(m), state)
+		if !assert.NoError(t, e, m) {
+			return
+		}
+	}
+
+	ticker, e := state.ticker(true)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "0.1005", ticker.BidPrice.AsString())
+	assert.Equal(t, "0.1015", ticker.AskPrice.AsString())
+	assert.Equal(t, "0.1012", ticker.LastPrice.AsString())
+
+	ob := state.orderbook(&model.TradingPair{Base: model.XLM, Quote: model.USD}, 10)
+	assert.Equal(t, 2, len(ob.Asks()))
+	assert.Equal(t, 3, len(ob.Bids()))
+	assert.Equal(t, "0.1020", ob.Asks()[1].Price.AsString())
+	assert.Equal(t, "0.0990", ob.Bids()[2].Price.AsString())
+
+	e = p.handleMessage([]byte(`{"event":"subscriptionStatus","status":"error","errorMessage":"Currency pair not supported"}`), state)
+	assert.Error(t, e)
+}
+
+func TestKrakenWsChecksum(t *testing.T) {
+	p := &krakenWsProtocol{}
+	state := makeWsMarketState()
+	snapshot := `[336,{"as":[["0.1010","100.00000000","1.1"],["0.1020","200.00000000","1.1"]],"bs":[["0.1000","150.00000000","1.1"]]},"book-100","XLM/USD"]`
+	e := p.handleMessage([]byte(snapshot), state)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	e = p.handleMessage([]byte(`[336,{"a":[["0.1015","20.00000000","1.2"]],"c":"3302412130"},"book-100","XLM/USD"]`), state)
+	assert.NoError(t, e)
+
+	// an update that was missed leaves the local orderbook out of sync with the exchange
+	e = p.handleMessage([]byte(`[336,{"b":[["0.0990","50.00000000","1.3"]],"c":"3302412130"},"book-100","XLM/USD"]`), state)
+	assert.Error(t, e)
+}
+
+func TestCoinbaseproWsProtocol(t *testing.T) {
+	p := &coinbaseproWsProtocol{}
+	symbol, e := p.toSymbol(&model.TradingPair{Base: model.XLM, Quote: model.USD})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "XLM-USD", symbol)
+
+	state := makeWsMarketState()
+	e = p.handleMessage([]byte(`{"type":"l2update","product_id":"XLM-USD","changes":[["buy","0.1000","1.0"]]}`), state)
+	assert.Error(t, e, "updates before the snapshot cannot be applied")
+
+	messages := []string{
+		`{"type":"subscriptions","channels":[{"name":"level2_batch","product_ids":["XLM-USD"]}]}`,
+		`{"type":"snapshot","product_id":"XLM-USD","bids":[["0.1000","150.0"],["0.0990","50.0"]],"asks":[["0.1010","100.0"]]}`,
+		`{"type":"l2update","product_id":"XLM-USD","changes":[["sell","0.1010","0"],["sell","0.1015","20.0"],["buy","0.1005","10.0"]]}`,
+		`{"type":"ticker","product_id":"XLM-USD","price":"0.1012"}`,
+		`{"type":"heartbeat","product_id":"XLM-USD"}`,
+	}
+	for _, m := range messages {
+		e = p.handleMessage([]byte(m), state)
+		if !assert.NoError(t, e, m) {
+			return
+		}
+	}
+
+	ticker, e := state.ticker(true)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "0.1005", ticker.BidPrice.AsString())
+	assert.Equal(t, "0.1015", ticker.AskPrice.AsString())
+	assert.Equal(t, "0.1012", ticker.LastPrice.AsString())
+
+	e = p.handleMessage([]byte(`{"type":"error","message":"Failed to subscribe","reason":"XLM-XYZ is not a valid product"}`), state)
+	assert.Error(t, e)
+}
+
+func TestWsMarketStateTruncate(t *testing.T) {
+	state := makeWsMarketState()
+	e := state.applySnapshot(
+		[]wsLevel{{"3", "1"}, {"2", "1"}, {"1", "1"}},
+		[]wsLevel{{"4", "1"}, {"5", "1"}, {"6", "1"}},
+	)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	state.truncate(2)
+	assert.Equal(t, []float64{3, 2}, sortedPrices(state.bids, true))
+	assert.Equal(t, []float64{4, 5}, sortedPrices(state.asks, false))
+}
+
+func TestWsTickerSourceFallback(t *testing.T) {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USD}
+	rest := makeFakePriceFeed(0.15)
+	clock := MakeVirtualClock(time.Unix(1000, 0))
+	s, e := makeWsTickerSource("ws-coinbasepro", &coinbaseproWsProtocol{}, pair, rest, rest, clock)
+	if !assert.NoError(t, e) {
+		return
+	}
+
+	// no snapshot yet
+	m, e := s.GetTickerPrice([]model.TradingPair{*pair})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0.15, m[*pair].LastPrice.AsFloat())
+	assert.Equal(t, 1, rest.calls)
+
+	// healthy websocket
+	for _, msg := range []string{
+		`{"type":"snapshot","product_id":"XLM-USD","bids":[["0.1000","150.0"]],"asks":[["0.1010","100.0"]]}`,
+		`{"type":"ticker","product_id":"XLM-USD","price":"0.1005"}`,
+	} {
+		e = s.protocol.handleMessage([]byte(msg), s.state)
+		if !assert.NoError(t, e) {
+			return
+		}
+		s.state.touch(clock.Now())
+	}
+	m, e = s.GetTickerPrice([]model.TradingPair{*pair})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0.1005, m[*pair].LastPrice.AsFloat())
+	ob, e := s.GetOrderBook(pair, 10)
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 1, len(ob.Asks()))
+	assert.Equal(t, 1, rest.calls)
+
+	// stale websocket
+	clock.Advance(wsStaleAfter + time.Second)
+	m, e = s.GetTickerPrice([]model.TradingPair{*pair})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0.15, m[*pair].LastPrice.AsFloat())
+	_, e = s.GetOrderBook(pair, 10)
+	assert.NoError(t, e)
+	assert.Equal(t, 3, rest.calls)
+}
+
+func TestWsRestExchangeName(t *testing.T) {
+	name, e := wsRestExchangeName("ws-kraken")
+	if assert.NoError(t, e) {
+		assert.Equal(t, "ccxt-kraken", name)
+	}
+	name, e = wsRestExchangeName("ws-coinbasepro")
+	if assert.NoError(t, e) {
+		assert.Equal(t, "ccxt-coinbasepro", name)
+	}
+	_, e = wsRestExchangeName("ws-binance")
+	assert.Error(t, e)
+}
+
+func TestWsTickerSourceWithoutTrades(t *testing.T) {
+	pair := &model.TradingPair{Base: model.XLM, Quote: model.USD}
+	rest := makeFakePriceFeed(0.15)
+	clock := MakeVirtualClock(time.Unix(1000, 0))
+	s, e := makeWsTickerSource("ws-coinbasepro", &coinbaseproWsProtocol{}, pair, rest, rest, clock)
+	if !assert.NoError(t, e) {
+		return
+	}
+	e = s.protocol.handleMessage([]byte(`{"type":"snapshot","product_id":"XLM-USD","bids":[["0.1000","150.0"]],"asks":[["0.1010","100.0"]]}`), s.state)
+	if !assert.NoError(t, e) {
+		return
+	}
+	s.state.touch(clock.Now())
+
+	// the orderbook is enough when the last trade price is not needed
+	bookTicker := &wsBookTicker{source: s}
+	m, e := bookTicker.GetTickerPrice([]model.TradingPair{*pair})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, "0.1000", m[*pair].BidPrice.AsString())
+	assert.Equal(t, "0.1010", m[*pair].AskPrice.AsString())
+	assert.Nil(t, m[*pair].LastPrice)
+	assert.Equal(t, 0, rest.calls)
+
+	// the "last" modifier needs a trade so it falls back to REST
+	m, e = s.GetTickerPrice([]model.TradingPair{*pair})
+	if !assert.NoError(t, e) {
+		return
+	}
+	assert.Equal(t, 0.15, m[*pair].LastPrice.AsFloat())
+	assert.Equal(t, 1, rest.calls)
+}
@@ -127,12 +127,7 @@ func (q *DailyVolumeByDate) QueryRow(args ...interface{}) (interface{}, error) {
 
 func makeSQLQueryDailyVolume(marketIDs []string, optionalAccountIDs []string) string {
 	// add filter on marketIDs
-	marketsInClauseParts := []string{}
-	for _, mid := range marketIDs {
-		marketsInValue := fmt.Sprintf("'%s'", mid)
-		marketsInClauseParts = append(marketsInClauseParts, marketsInValue)
-	}
-	marketsInClause := strings.Join(marketsInClauseParts, ", ")
+	marketsInClause := makeInClauseValues(marketIDs)
 
 	// len(a), where a is a nil array, is valid and returns 0
 	if len(optionalAccountIDs) == 0 {
@@ -140,11 +135,15 @@ func makeSQLQueryDailyVolume(marketIDs []string, optionalAccountIDs []string) st
 	}
 
 	// include filter on account_id
-	accountsInClauseParts := []string{}
-	for _, aid := range optionalAccountIDs {
-		accountsInValue := fmt.Sprintf("'%s'", aid)
-		accountsInClauseParts = append(accountsInClauseParts, accountsInValue)
-	}
-	accountsInClause := strings.Join(accountsInClauseParts, ", ")
+	accountsInClause := makeInClauseValues(optionalAccountIDs)
 	return fmt.Sprintf(sqlQueryDailyValuesTemplateSpecificAccounts, marketsInClause, accountsInClause)
 }
+
+// makeInClauseValues quotes the values and joins them so they can be used in an IN clause
+func makeInClauseValues(values []string) string {
+	inClauseParts := []string{}
+	for _, v := range values {
+		inClauseParts = append(inClauseParts, fmt.Sprintf("'%s'", v))
+	}
+	return strings.Join(inClauseParts, ", ")
+}
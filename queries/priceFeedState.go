@@ -0,0 +1,73 @@
+package queries
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryPriceFeedState queries the price_feed_state table by feed_key (primary key)
+const sqlQueryPriceFeedState = "SELECT samples FROM price_feed_state WHERE feed_key = $1"
+
+// PriceFeedSample is a price sampled by a price feed, the samples of a feed are persisted as a json array
+type PriceFeedSample struct {
+	Time  time.Time `json:"t"`
+	Price float64   `json:"p"`
+}
+
+// PriceFeedState is a query that fetches the persisted samples for a price feed, it returns a nil []PriceFeedSample when there is no state saved
+type PriceFeedState struct {
+	db       *sql.DB
+	sqlQuery string
+	feedKey  string
+}
+
+var _ api.Query = &PriceFeedState{}
+
+// MakePriceFeedState makes the PriceFeedState query
+func MakePriceFeedState(db *sql.DB, feedKey string) (*PriceFeedState, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &PriceFeedState{
+		db:       db,
+		sqlQuery: sqlQueryPriceFeedState,
+		feedKey:  feedKey,
+	}, nil
+}
+
+// Name impl.
+func (q *PriceFeedState) Name() string {
+	return "PriceFeedState"
+}
+
+// QueryRow impl.
+func (q *PriceFeedState) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 args, but got args %v", args)
+	}
+
+	row := q.db.QueryRow(q.sqlQuery, q.feedKey)
+	var samplesJSON string
+	e := row.Scan(&samplesJSON)
+	if e != nil {
+		if strings.Contains(e.Error(), "no rows in result set") {
+			return []PriceFeedSample(nil), nil
+		}
+		return nil, fmt.Errorf("could not read data from PriceFeedState query: %s", e)
+	}
+
+	var samples []PriceFeedSample
+	e = json.Unmarshal([]byte(samplesJSON), &samples)
+	if e != nil {
+		return nil, fmt.Errorf("could not unmarshal samples from PriceFeedState query (%s): %s", samplesJSON, e)
+	}
+	return samples, nil
+}
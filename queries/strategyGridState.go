@@ -0,0 +1,68 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryStrategyGridState queries the strategy_grid_state table by market_id (primary key)
+const sqlQueryStrategyGridState = "SELECT min_price, max_price, num_levels, spacing, anchor_index FROM strategy_grid_state WHERE market_id = $1"
+
+// GridState is the persisted state of the grid strategy for a market
+type GridState struct {
+	MinPrice    float64
+	MaxPrice    float64
+	NumLevels   int
+	Spacing     string
+	AnchorIndex int
+}
+
+// StrategyGridState is a query that fetches the grid state for a market, it returns a nil *GridState when there is no state saved
+type StrategyGridState struct {
+	db       *sql.DB
+	sqlQuery string
+	marketID string
+}
+
+var _ api.Query = &StrategyGridState{}
+
+// MakeStrategyGridState makes the StrategyGridState query
+func MakeStrategyGridState(db *sql.DB, marketID string) (*StrategyGridState, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	return &StrategyGridState{
+		db:       db,
+		sqlQuery: sqlQueryStrategyGridState,
+		marketID: marketID,
+	}, nil
+}
+
+// Name impl.
+func (q *StrategyGridState) Name() string {
+	return "StrategyGridState"
+}
+
+// QueryRow impl.
+func (q *StrategyGridState) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected 0 args, but got args %v", args)
+	}
+
+	row := q.db.QueryRow(q.sqlQuery, q.marketID)
+	var state GridState
+	e := row.Scan(&state.MinPrice, &state.MaxPrice, &state.NumLevels, &state.Spacing, &state.AnchorIndex)
+	if e != nil {
+		if strings.Contains(e.Error(), "no rows in result set") {
+			return (*GridState)(nil), nil
+		}
+		return nil, fmt.Errorf("could not read data from StrategyGridState query: %s", e)
+	}
+	return &state, nil
+}
@@ -0,0 +1,92 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/support/utils"
+)
+
+// sqlQueryWindowValuesTemplateAllAccounts queries the trades table to get the values in a window of time, aggregating without a group by
+// so we always get back a single row with null values when there are no trades in the window
+const sqlQueryWindowValuesTemplateAllAccounts = "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN (%s) AND date_utc >= $1 AND date_utc < $2 and action = $3"
+
+// sqlQueryWindowValuesTemplateSpecificAccounts queries the trades table to get the values in a window of time filtered by specific accounts
+const sqlQueryWindowValuesTemplateSpecificAccounts = "SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN (%s) AND account_id IN (%s) AND date_utc >= $1 AND date_utc < $2 and action = $3"
+
+// VolumeInWindow is a query that fetches the volume of sales in a window of time, such as the rolling last 60 minutes
+type VolumeInWindow struct {
+	db       *sql.DB
+	sqlQuery string
+	action   DailyVolumeAction
+}
+
+var _ api.Query = &VolumeInWindow{}
+
+// MakeVolumeInWindowForMarketIdsAction makes the VolumeInWindow query for a set of marketIds and an action
+func MakeVolumeInWindowForMarketIdsAction(
+	db *sql.DB,
+	marketIDs []string,
+	action DailyVolumeAction,
+	optionalAccountIDs []string,
+) (*VolumeInWindow, error) {
+	if db == nil {
+		utils.PrintErrorHintf("the provided POSTGRES_DB config in the trader.cfg file should be non-nil")
+		return nil, fmt.Errorf("the provided db should be non-nil")
+	}
+
+	sqlQuery := makeSQLQueryVolumeInWindow(marketIDs, optionalAccountIDs)
+	return &VolumeInWindow{
+		db:       db,
+		sqlQuery: sqlQuery,
+		action:   action,
+	}, nil
+}
+
+// Name impl.
+func (q *VolumeInWindow) Name() string {
+	return "VolumeInWindow"
+}
+
+// QueryRow impl. The window includes the start time and excludes the end time
+func (q *VolumeInWindow) QueryRow(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expected 2 args (startUTC time.Time, endUTC time.Time), but got args %v", args)
+	}
+	start, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("first input arg needs to be of type 'time.Time', but was of type '%T'", args[0])
+	}
+	end, ok := args[1].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("second input arg needs to be of type 'time.Time', but was of type '%T'", args[1])
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("start of window (%s) needs to be before the end of the window (%s)", start, end)
+	}
+
+	row := q.db.QueryRow(q.sqlQuery, start.UTC(), end.UTC(), q.action.String())
+
+	var baseVol sql.NullFloat64
+	var quoteVol sql.NullFloat64
+	e := row.Scan(&baseVol, &quoteVol)
+	if e != nil {
+		return nil, fmt.Errorf("could not read data from SqlQueryWindowValues query: %s", e)
+	}
+
+	// the sums are null when there are no trades in the window
+	return &DailyVolume{
+		BaseVol:  baseVol.Float64,
+		QuoteVol: quoteVol.Float64,
+	}, nil
+}
+
+func makeSQLQueryVolumeInWindow(marketIDs []string, optionalAccountIDs []string) string {
+	marketsInClause := makeInClauseValues(marketIDs)
+	if len(optionalAccountIDs) == 0 {
+		return fmt.Sprintf(sqlQueryWindowValuesTemplateAllAccounts, marketsInClause)
+	}
+	return fmt.Sprintf(sqlQueryWindowValuesTemplateSpecificAccounts, marketsInClause, makeInClauseValues(optionalAccountIDs))
+}
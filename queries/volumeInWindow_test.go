@@ -0,0 +1,120 @@
+package queries
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/kelp/kelpdb"
+	"github.com/stellar/kelp/model"
+	"github.com/stellar/kelp/support/postgresdb"
+)
+
+func TestMakeSQLQueryVolumeInWindow(t *testing.T) {
+	assert.Equal(t,
+		"SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN ('market1', 'market2') AND date_utc >= $1 AND date_utc < $2 and action = $3",
+		makeSQLQueryVolumeInWindow([]string{"market1", "market2"}, nil))
+	assert.Equal(t,
+		"SELECT SUM(base_volume) as total_base_volume, SUM(counter_cost) as total_counter_volume FROM trades WHERE market_id IN ('market1') AND account_id IN ('accountID1') AND date_utc >= $1 AND date_utc < $2 and action = $3",
+		makeSQLQueryVolumeInWindow([]string{"market1"}, []string{"accountID1"}))
+}
+
+func TestVolumeInWindow_QueryRow(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2020-01-21T15:00:00Z")
+	trades := []struct {
+		txid   string
+		date   time.Time
+		action model.OrderAction
+		volume float64
+		cost   float64
+	}{
+		{"1", now.Add(-2 * time.Hour), model.OrderActionSell, 100.0, 10.0},
+		{"2", now.Add(-59 * time.Minute), model.OrderActionSell, 101.0, 11.11},
+		{"3", now.Add(-10 * time.Minute), model.OrderActionSell, 6.0, 0.72},
+		{"4", now.Add(-10 * time.Minute), model.OrderActionBuy, 92.0, 4.2},
+		{"5", now, model.OrderActionSell, 102.0, 12.24},
+	}
+	setupStatements := []string{
+		kelpdb.SqlTradesTableCreate,
+		"ALTER TABLE trades DROP COLUMN IF EXISTS account_id",
+		"ALTER TABLE trades DROP COLUMN IF EXISTS order_id",
+		kelpdb.SqlTradesTableAlter1,
+		kelpdb.SqlTradesTableAlter2,
+		"DELETE FROM trades", // clear table
+	}
+	for _, trade := range trades {
+		setupStatements = append(setupStatements, fmt.Sprintf(kelpdb.SqlTradesInsertTemplate,
+			"market1",
+			trade.txid,
+			trade.date.Format(postgresdb.TimestampFormatString),
+			trade.action.String(),
+			model.OrderTypeLimit.String(),
+			trade.cost/trade.volume, // price
+			trade.volume,
+			trade.cost,
+			0.0, // fee
+			"accountID1",
+			"",
+		))
+	}
+	db := connectTestDb()
+	defer db.Close()
+	for _, s := range setupStatements {
+		_, e := db.Exec(s)
+		if e != nil {
+			panic(e)
+		}
+	}
+
+	testCases := []struct {
+		action    DailyVolumeAction
+		window    time.Duration
+		wantBase  float64
+		wantQuote float64
+	}{
+		{
+			action:    DailyVolumeActionSell,
+			window:    60 * time.Minute,
+			wantBase:  107.0,
+			wantQuote: 11.83,
+		}, {
+			action:    DailyVolumeActionSell,
+			window:    3 * time.Hour,
+			wantBase:  207.0,
+			wantQuote: 21.83,
+		}, {
+			action:    DailyVolumeActionSell,
+			window:    5 * time.Minute,
+			wantBase:  0.0,
+			wantQuote: 0.0,
+		}, {
+			action:    DailyVolumeActionBuy,
+			window:    60 * time.Minute,
+			wantBase:  92.0,
+			wantQuote: 4.2,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(fmt.Sprintf("%s/%s", k.action, k.window), func(t *testing.T) {
+			query, e := MakeVolumeInWindowForMarketIdsAction(db, []string{"market1"}, k.action, nil)
+			if !assert.NoError(t, e) {
+				return
+			}
+			assert.Equal(t, "VolumeInWindow", query.Name())
+
+			result, e := query.QueryRow(now.Add(-k.window), now)
+			if !assert.NoError(t, e) {
+				return
+			}
+			volume, ok := result.(*DailyVolume)
+			if !assert.True(t, ok) {
+				return
+			}
+			assert.InDelta(t, k.wantBase, volume.BaseVol, 0.0000001)
+			assert.InDelta(t, k.wantQuote, volume.QuoteVol, 0.0000001)
+		})
+	}
+}
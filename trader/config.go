@@ -12,6 +12,12 @@ import (
 // XLM is a constant for XLM
 const XLM = "XLM"
 
+// The following are the types of time controllers that decide when the bot runs its update cycle
+const (
+	TimeControllerInterval = "interval"
+	TimeControllerEvent    = "event"
+)
+
 // FeeConfig represents input data for how to deal with network fees
 type FeeConfig struct {
 	CapacityTrigger float64 `valid:"-" toml:"CAPACITY_TRIGGER" json:"capacity_trigger"`     // trigger when "ledger_capacity_usage" in /fee_stats is >= this value
@@ -32,6 +38,13 @@ type BotConfig struct {
 	TickIntervalMillis                 int32      `valid:"-" toml:"TICK_INTERVAL_MILLIS" json:"tick_interval_millis"`
 	MaxTickDelayMillis                 int64      `valid:"-" toml:"MAX_TICK_DELAY_MILLIS" json:"max_tick_delay_millis"`
 	SleepMode                          string     `valid:"-" toml:"SLEEP_MODE" json:"sleep_mode"`
+	TimeController                     string     `valid:"-" toml:"TIME_CONTROLLER" json:"time_controller"`
+	EventMinIntervalMillis             int64      `valid:"-" toml:"EVENT_MIN_INTERVAL_MILLIS" json:"event_min_interval_millis"`
+	EventPollIntervalMillis            int64      `valid:"-" toml:"EVENT_POLL_INTERVAL_MILLIS" json:"event_poll_interval_millis"`
+	EventPriceFeedType                 string     `valid:"-" toml:"EVENT_PRICE_FEED_TYPE" json:"event_price_feed_type"`
+	EventPriceFeedURL                  string     `valid:"-" toml:"EVENT_PRICE_FEED_URL" json:"event_price_feed_url"`
+	EventPriceChangeThreshold          float64    `valid:"-" toml:"EVENT_PRICE_CHANGE_THRESHOLD" json:"event_price_change_threshold"`
+	EventTriggerOnFills                bool       `valid:"-" toml:"EVENT_TRIGGER_ON_FILLS" json:"event_trigger_on_fills"`
 	DeleteCyclesThreshold              int64      `valid:"-" toml:"DELETE_CYCLES_THRESHOLD" json:"delete_cycles_threshold"`
 	SubmitMode                         string     `valid:"-" toml:"SUBMIT_MODE" json:"submit_mode"`
 	FillTrackerSleepMillis             uint32     `valid:"-" toml:"FILL_TRACKER_SLEEP_MILLIS" json:"fill_tracker_sleep_millis"`
@@ -40,9 +53,12 @@ type BotConfig struct {
 	SynchronizeStateLoadMaxRetries     int        `valid:"-" toml:"SYNCHRONIZE_STATE_LOAD_MAX_RETRIES"`
 	FillTrackerLastTradeCursorOverride string     `valid:"-" toml:"FILL_TRACKER_LAST_TRADE_CURSOR_OVERRIDE"`
 	HorizonURL                         string     `valid:"-" toml:"HORIZON_URL" json:"horizon_url"`
+	HorizonStreamingEnable             bool       `valid:"-" toml:"HORIZON_STREAMING_ENABLE" json:"horizon_streaming_enable"`
+	HorizonStreamingStaleAfterMillis   int64      `valid:"-" toml:"HORIZON_STREAMING_STALE_AFTER_MILLIS" json:"horizon_streaming_stale_after_millis"`
 	CcxtRestURL                        *string    `valid:"-" toml:"CCXT_REST_URL" json:"ccxt_rest_url"`
 	DollarValueFeedBaseAsset           string     `valid:"-" toml:"DOLLAR_VALUE_FEED_BASE_ASSET" json:"dollar_value_feed_base_asset"`
 	DollarValueFeedQuoteAsset          string     `valid:"-" toml:"DOLLAR_VALUE_FEED_QUOTE_ASSET" json:"dollar_value_feed_quote_asset"`
+	PriceFeedCacheTTLMillis            int64      `valid:"-" toml:"PRICE_FEED_CACHE_TTL_MILLIS" json:"price_feed_cache_ttl_millis"`
 	Fee                                *FeeConfig `valid:"-" toml:"FEE" json:"fee"`
 	CentralizedPricePrecisionOverride  *int8      `valid:"-" toml:"CENTRALIZED_PRICE_PRECISION_OVERRIDE" json:"centralized_price_precision_override"`
 	CentralizedVolumePrecisionOverride *int8      `valid:"-" toml:"CENTRALIZED_VOLUME_PRECISION_OVERRIDE" json:"centralized_volume_precision_override"`
@@ -65,6 +81,7 @@ type BotConfig struct {
 	ExchangeAPIKeys                    toml.ExchangeAPIKeysToml `valid:"-" toml:"EXCHANGE_API_KEYS" json:"exchange_api_keys"`
 	ExchangeParams                     toml.ExchangeParamsToml  `valid:"-" toml:"EXCHANGE_PARAMS" json:"exchange_params"`
 	ExchangeHeaders                    toml.ExchangeHeadersToml `valid:"-" toml:"EXCHANGE_HEADERS" json:"exchange_headers"`
+	Markets                            []*MarketConfig          `valid:"-" toml:"MARKETS" json:"markets"`
 
 	// initialized later
 	tradingAccount *string
@@ -74,6 +91,65 @@ type BotConfig struct {
 	isTradingSdex  bool
 }
 
+// MarketConfig represents an additional market that is traded from the same account as the primary market of the bot
+type MarketConfig struct {
+	AssetCodeA         string   `valid:"-" toml:"ASSET_CODE_A" json:"asset_code_a"`
+	IssuerA            string   `valid:"-" toml:"ISSUER_A" json:"issuer_a"`
+	AssetCodeB         string   `valid:"-" toml:"ASSET_CODE_B" json:"asset_code_b"`
+	IssuerB            string   `valid:"-" toml:"ISSUER_B" json:"issuer_b"`
+	Strategy           string   `valid:"-" toml:"STRATEGY" json:"strategy"`
+	StrategyConfigPath string   `valid:"-" toml:"STRATEGY_CONFIG_PATH" json:"strategy_config_path"`
+	Filters            []string `valid:"-" toml:"FILTERS" json:"filters"`
+
+	// initialized later
+	assetBase  hProtocol.Asset
+	assetQuote hProtocol.Asset
+}
+
+// String impl.
+func (m MarketConfig) String() string {
+	return utils.StructString(m, 0, nil)
+}
+
+// AssetBase returns the market's assetBase
+func (m *MarketConfig) AssetBase() hProtocol.Asset {
+	return m.assetBase
+}
+
+// AssetQuote returns the market's assetQuote
+func (m *MarketConfig) AssetQuote() hProtocol.Asset {
+	return m.assetQuote
+}
+
+// TradingPair returns the market's trading pair name
+func (m *MarketConfig) TradingPair() string {
+	return fmt.Sprintf("%s:%s/%s:%s", m.AssetCodeA, m.IssuerA, m.AssetCodeB, m.IssuerB)
+}
+
+// Init initializes this market config
+func (m *MarketConfig) Init() error {
+	if m.AssetCodeA == m.AssetCodeB && m.IssuerA == m.IssuerB {
+		return fmt.Errorf("error: both assets cannot be the same '%s:%s'", m.AssetCodeA, m.IssuerA)
+	}
+
+	asset, e := utils.ParseAsset(m.AssetCodeA, m.IssuerA)
+	if e != nil {
+		return fmt.Errorf("Error while parsing Asset A: %s", e)
+	}
+	m.assetBase = *asset
+
+	asset, e = utils.ParseAsset(m.AssetCodeB, m.IssuerB)
+	if e != nil {
+		return fmt.Errorf("Error while parsing Asset B: %s", e)
+	}
+	m.assetQuote = *asset
+
+	if m.Strategy == "" {
+		return fmt.Errorf("no STRATEGY specified")
+	}
+	return nil
+}
+
 // MakeBotConfig factory method for BotConfig
 func MakeBotConfig(
 	sourceSecretSeed string,
@@ -182,6 +258,11 @@ func (b *BotConfig) TradingExchangeName() string {
 	return b.TradingExchange
 }
 
+// IsEventTimeController returns whether the config uses the event time controller instead of the default interval time controller
+func (b *BotConfig) IsEventTimeController() bool {
+	return b.TimeController == TimeControllerEvent
+}
+
 // Init initializes this config
 func (b *BotConfig) Init() error {
 	b.isTradingSdex = b.IsTradingSdex()
@@ -202,6 +283,22 @@ func (b *BotConfig) Init() error {
 	}
 	b.assetQuote = *asset
 
+	if len(b.Markets) > 0 && !b.isTradingSdex {
+		return fmt.Errorf("MARKETS can only be specified when trading on sdex")
+	}
+	pairs := map[string]bool{b.TradingPair(): true}
+	for i, m := range b.Markets {
+		e = m.Init()
+		if e != nil {
+			return fmt.Errorf("invalid entry at index %d in MARKETS: %s", i, e)
+		}
+
+		if pairs[m.TradingPair()] {
+			return fmt.Errorf("market '%s' at index %d in MARKETS is already being traded by this bot", m.TradingPair(), i)
+		}
+		pairs[m.TradingPair()] = true
+	}
+
 	b.tradingAccount, e = utils.ParseSecret(b.TradingSecretSeed)
 	if e != nil {
 		return e
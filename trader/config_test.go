@@ -0,0 +1,87 @@
+package trader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBotConfigInitMarkets(t *testing.T) {
+	issuer := "GBMMZMK2DC4FFP4CAI6KCVNCQ7WLO5A7DQU7EC7WGHRDQBZB763X4OQI"
+	testCases := []struct {
+		name            string
+		tradingExchange string
+		markets         []*MarketConfig
+		wantErr         bool
+	}{
+		{
+			name:    "no markets",
+			markets: nil,
+			wantErr: false,
+		}, {
+			name: "valid additional market",
+			markets: []*MarketConfig{
+				{AssetCodeA: "XLM", AssetCodeB: "EURT", IssuerB: issuer, Strategy: "buysell", StrategyConfigPath: "buysell.cfg"},
+			},
+			wantErr: false,
+		}, {
+			name: "duplicate of primary market",
+			markets: []*MarketConfig{
+				{AssetCodeA: "XLM", AssetCodeB: "COUPON", IssuerB: issuer, Strategy: "buysell", StrategyConfigPath: "buysell.cfg"},
+			},
+			wantErr: true,
+		}, {
+			name: "duplicate additional markets",
+			markets: []*MarketConfig{
+				{AssetCodeA: "XLM", AssetCodeB: "EURT", IssuerB: issuer, Strategy: "buysell", StrategyConfigPath: "buysell.cfg"},
+				{AssetCodeA: "XLM", AssetCodeB: "EURT", IssuerB: issuer, Strategy: "sell", StrategyConfigPath: "sell.cfg"},
+			},
+			wantErr: true,
+		}, {
+			name: "missing strategy",
+			markets: []*MarketConfig{
+				{AssetCodeA: "XLM", AssetCodeB: "EURT", IssuerB: issuer},
+			},
+			wantErr: true,
+		}, {
+			name: "invalid asset",
+			markets: []*MarketConfig{
+				{AssetCodeA: "XLM", AssetCodeB: "EURT", Strategy: "buysell", StrategyConfigPath: "buysell.cfg"},
+			},
+			wantErr: true,
+		}, {
+			name:            "not trading on sdex",
+			tradingExchange: "kraken",
+			markets: []*MarketConfig{
+				{AssetCodeA: "XLM", AssetCodeB: "EURT", IssuerB: issuer, Strategy: "buysell", StrategyConfigPath: "buysell.cfg"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, k := range testCases {
+		t.Run(k.name, func(t *testing.T) {
+			botConfig := BotConfig{
+				TradingSecretSeed: "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H",
+				AssetCodeA:        "XLM",
+				AssetCodeB:        "COUPON",
+				IssuerB:           issuer,
+				TradingExchange:   k.tradingExchange,
+				Markets:           k.markets,
+			}
+
+			e := botConfig.Init()
+			if k.wantErr {
+				assert.Error(t, e)
+				return
+			}
+			if !assert.NoError(t, e) {
+				return
+			}
+			for _, m := range k.markets {
+				assert.Equal(t, m.AssetCodeB, m.AssetQuote().Code)
+				assert.Equal(t, m.IssuerB, m.AssetQuote().Issuer)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nikhilsaraf/go-tools/multithreading"
+
+	"github.com/stellar/kelp/api"
+	"github.com/stellar/kelp/plugins"
+)
+
+// MultiTrader runs the update cycles of multiple Traders that trade different markets from the same account in a single loop.
+// The traders are updated one after the other so they never submit transactions with the shared sequence number concurrently.
+type MultiTrader struct {
+	traders         []*Trader
+	timeController  api.TimeController
+	clock           api.Clock
+	sleepMode       SleepMode
+	threadTracker   *multithreading.ThreadTracker
+	fixedIterations *uint64
+	metricsTracker  *plugins.MetricsTracker
+	startTime       time.Time
+}
+
+// MakeMultiTrader is the factory method for the MultiTrader struct
+func MakeMultiTrader(
+	traders []*Trader,
+	timeController api.TimeController,
+	clock api.Clock,
+	sleepMode SleepMode,
+	threadTracker *multithreading.ThreadTracker,
+	fixedIterations *uint64,
+	metricsTracker *plugins.MetricsTracker,
+	startTime time.Time,
+) *MultiTrader {
+	return &MultiTrader{
+		traders:         traders,
+		timeController:  timeController,
+		clock:           clock,
+		sleepMode:       sleepMode,
+		threadTracker:   threadTracker,
+		fixedIterations: fixedIterations,
+		metricsTracker:  metricsTracker,
+		startTime:       startTime,
+	}
+}
+
+// Start starts the update loop for all the traders
+func (m *MultiTrader) Start() {
+	runUpdateLoop(
+		m.UpdateOnce,
+		fmt.Sprintf("update loop across %d markets", len(m.traders)),
+		m.timeController,
+		m.clock,
+		m.sleepMode,
+		m.threadTracker,
+		m.fixedIterations,
+		m.metricsTracker,
+		m.startTime,
+	)
+}
+
+// UpdateOnce runs a single update cycle of each trader in order and returns the combined result, which is successful only if the
+// update of every market was successful
+func (m *MultiTrader) UpdateOnce() plugins.UpdateLoopResult {
+	combined := plugins.UpdateLoopResult{Success: true}
+	for i, t := range m.traders {
+		log.Printf("updating market %d of %d (1-indexed): %s/%s\n", i+1, len(m.traders), t.assetBase.Code, t.assetQuote.Code)
+		// UpdateOnce waits for the submission goroutines of this market to finish before we move on to the next market
+		result := t.UpdateOnce()
+		combined = combineUpdateLoopResults(combined, result)
+	}
+	return combined
+}
+
+func combineUpdateLoopResults(a plugins.UpdateLoopResult, b plugins.UpdateLoopResult) plugins.UpdateLoopResult {
+	return plugins.UpdateLoopResult{
+		Success:            a.Success && b.Success,
+		NumPruneOps:        a.NumPruneOps + b.NumPruneOps,
+		NumUpdateOpsDelete: a.NumUpdateOpsDelete + b.NumUpdateOpsDelete,
+		NumUpdateOpsUpdate: a.NumUpdateOpsUpdate + b.NumUpdateOpsUpdate,
+		NumUpdateOpsCreate: a.NumUpdateOpsCreate + b.NumUpdateOpsCreate,
+	}
+}
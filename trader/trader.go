@@ -35,6 +35,7 @@ type Trader struct {
 	exchangeShim                   api.ExchangeShim
 	strategy                       api.Strategy // the instance of this bot is bound to this strategy
 	timeController                 api.TimeController
+	clock                          api.Clock
 	sleepMode                      SleepMode
 	synchronizeStateLoadEnable     bool
 	synchronizeStateLoadMaxRetries int
@@ -74,6 +75,7 @@ func MakeTrader(
 	exchangeShim api.ExchangeShim,
 	strategy api.Strategy,
 	timeController api.TimeController,
+	clock api.Clock,
 	sleepMode SleepMode,
 	synchronizeStateLoadEnable bool,
 	synchronizeStateLoadMaxRetries int,
@@ -100,6 +102,7 @@ func MakeTrader(
 		exchangeShim:                   exchangeShim,
 		strategy:                       strategy,
 		timeController:                 timeController,
+		clock:                          clock,
 		sleepMode:                      sleepMode,
 		synchronizeStateLoadEnable:     synchronizeStateLoadEnable,
 		synchronizeStateLoadMaxRetries: synchronizeStateLoadMaxRetries,
@@ -120,6 +123,40 @@ func MakeTrader(
 
 // Start starts the bot with the injected strategy
 func (t *Trader) Start() {
+	runUpdateLoop(
+		t.update,
+		"update loop",
+		t.timeController,
+		t.clock,
+		t.sleepMode,
+		t.threadTracker,
+		t.fixedIterations,
+		t.metricsTracker,
+		t.startTime,
+	)
+}
+
+// UpdateOnce runs a single update cycle and waits for any goroutines started by the update to finish.
+// This is used instead of Start when the bot is driven by an external clock, such as when backtesting
+func (t *Trader) UpdateOnce() plugins.UpdateLoopResult {
+	updateResult := t.update()
+	t.threadTracker.Wait()
+	return updateResult
+}
+
+// runUpdateLoop runs updateFn whenever the time controller allows it and sleeps between the updates according to the sleepMode, it is
+// shared by the Trader and the MultiTrader so they have the same update, metrics and sleep behavior
+func runUpdateLoop(
+	updateFn func() plugins.UpdateLoopResult,
+	updateName string,
+	timeController api.TimeController,
+	clock api.Clock,
+	sleepMode SleepMode,
+	threadTracker *multithreading.ThreadTracker,
+	fixedIterations *uint64,
+	metricsTracker *plugins.MetricsTracker,
+	startTime time.Time,
+) {
 	log.Println("----------------------------------------------------------------------------------------------------")
 	// lastUpdateStartTime is the start time of the last update
 	var lastUpdateStartTime time.Time
@@ -129,24 +166,25 @@ func (t *Trader) Start() {
 	for {
 		// ref time for shouldUpdate depends on the sleepMode
 		updateRefTime := lastUpdateStartTime
-		if t.sleepMode.shouldSleepAtBeginning() {
+		if sleepMode.shouldSleepAtBeginning() {
 			// use lastUpdateEndTime here because we want to sleep starting for the time after the last cycle ended (i.e. we want to sleep in the beginning)
 			updateRefTime = lastUpdateEndTime
 		}
 
 		// skip first sleep cycle if sleeping first so there is no delay when running the bot in the first iteration
-		if t.sleepMode.shouldSleepAtBeginning() && !lastUpdateEndTime.IsZero() {
-			t.doSleep(lastUpdateEndTime)
+		if sleepMode.shouldSleepAtBeginning() && !lastUpdateEndTime.IsZero() {
+			doSleep(timeController, clock, lastUpdateEndTime)
 		}
 
-		currentUpdateTime := time.Now()
-		if updateRefTime.IsZero() || t.timeController.ShouldUpdate(updateRefTime, currentUpdateTime) {
-			updateResult := t.update()
-			millisForUpdate := time.Since(currentUpdateTime).Milliseconds()
-			log.Printf("time taken for update loop: %d millis\n", millisForUpdate)
-			if shouldSendUpdateMetric(t.startTime, currentUpdateTime, t.metricsTracker.GetUpdateEventSentTime()) {
-				e := t.threadTracker.TriggerGoroutine(func(inputs []interface{}) {
-					e := t.metricsTracker.SendUpdateEvent(currentUpdateTime, updateResult, millisForUpdate)
+		currentUpdateTime := clock.Now()
+		if updateRefTime.IsZero() || timeController.ShouldUpdate(updateRefTime, currentUpdateTime) {
+			updateResult := updateFn()
+			millisForUpdate := clock.Now().Sub(currentUpdateTime).Milliseconds()
+			log.Printf("time taken for %s: %d millis\n", updateName, millisForUpdate)
+			plugins.LogPriceFeedCacheStats()
+			if shouldSendUpdateMetric(startTime, currentUpdateTime, metricsTracker.GetUpdateEventSentTime()) {
+				e := threadTracker.TriggerGoroutine(func(inputs []interface{}) {
+					e := metricsTracker.SendUpdateEvent(currentUpdateTime, updateResult, millisForUpdate)
 					if e != nil {
 						log.Printf("failed to send update event metric: %s", e)
 					}
@@ -156,35 +194,35 @@ func (t *Trader) Start() {
 				}
 			}
 
-			if t.fixedIterations != nil && updateResult.Success {
-				*t.fixedIterations = *t.fixedIterations - 1
-				if *t.fixedIterations <= 0 {
+			if fixedIterations != nil && updateResult.Success {
+				*fixedIterations = *fixedIterations - 1
+				if *fixedIterations <= 0 {
 					log.Printf("finished requested number of iterations, waiting for all threads to finish...\n")
-					t.threadTracker.Wait()
+					threadTracker.Wait()
 					log.Printf("...all threads finished, stopping bot update loop\n")
 					return
 				}
 			}
 
 			// wait for any goroutines from the current update to finish so we don't have inconsistent state reads
-			t.threadTracker.Wait()
+			threadTracker.Wait()
 			log.Println("----------------------------------------------------------------------------------------------------")
 			lastUpdateStartTime = currentUpdateTime
-			// lastUpdateEndTime uses the real time.Now() because we want to capture the actual end time
-			lastUpdateEndTime = time.Now()
+			// lastUpdateEndTime uses the clock's Now() because we want to capture the actual end time
+			lastUpdateEndTime = clock.Now()
 		}
 
-		if !t.sleepMode.shouldSleepAtBeginning() {
+		if !sleepMode.shouldSleepAtBeginning() {
 			// this needs to synchronize with the time of the last run attempt
-			t.doSleep(lastUpdateStartTime)
+			doSleep(timeController, clock, lastUpdateStartTime)
 		}
 	}
 }
 
-func (t *Trader) doSleep(lastUpdateTime time.Time) {
-	sleepTime := t.timeController.SleepTime(lastUpdateTime)
+func doSleep(timeController api.TimeController, clock api.Clock, lastUpdateTime time.Time) {
+	sleepTime := timeController.SleepTime(lastUpdateTime)
 	log.Printf("sleeping for %s...\n", sleepTime)
-	time.Sleep(sleepTime)
+	clock.Sleep(sleepTime)
 }
 
 func shouldSendUpdateMetric(start time.Time, currentUpdate time.Time, lastMetricUpdate *time.Time) bool {